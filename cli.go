@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/auth_user"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/db/db_copy"
+	stremio_account "github.com/MunifTanjim/stremthru/internal/stremio/account"
+	ti "github.com/MunifTanjim/stremthru/internal/torrent_info"
+	torznab_indexer "github.com/MunifTanjim/stremthru/internal/torznab/indexer"
+	"github.com/MunifTanjim/stremthru/internal/worker"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
+)
+
+const cliUsage = `Usage: stremthru [command]
+
+Commands:
+  serve                          start the server (default)
+  migrate up                     apply pending database migrations
+  migrate status                 show database migration status
+  db copy --to <uri> [--from <uri>] [--batch-size n] [--restart]
+                                 copy all data to another database
+  worker list                    list available workers
+  worker run <id> [--force]      run a worker job once
+  torrents dump [--output file]  export torrents as JSON lines
+  torrents import [--input file] import torrents from JSON lines
+  vault rotate-secret --new-secret <secret>
+                                 re-encrypt vault data with a new secret
+  config check                   validate and print the configuration
+  config show                    print the effective configuration
+`
+
+type cliCommand func(args []string) error
+
+var cliCommands = map[string]map[string]cliCommand{
+	"migrate": {
+		"up":     runMigrateUp,
+		"status": runMigrateStatus,
+	},
+	"db": {
+		"copy": runDBCopy,
+	},
+	"worker": {
+		"list": runWorkerList,
+		"run":  runWorkerRun,
+	},
+	"torrents": {
+		"dump":   runTorrentsDump,
+		"import": runTorrentsImport,
+	},
+	"vault": {
+		"rotate-secret": runVaultRotateSecret,
+	},
+	"config": {
+		"check": runConfigCheck,
+		"show":  runConfigShow,
+	},
+}
+
+var errCLIUsage = errors.New("invalid usage")
+
+func runCLI(args []string) int {
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0
+	}
+
+	subCommands, ok := cliCommands[args[0]]
+	if !ok || len(args) < 2 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return 2
+	}
+	command, ok := subCommands[args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return 2
+	}
+
+	if err := command(args[2:]); err != nil {
+		if errors.Is(err, errCLIUsage) || errors.Is(err, flag.ErrHelp) {
+			fmt.Fprint(os.Stderr, cliUsage)
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", args[0], args[1], err)
+		return 1
+	}
+	return 0
+}
+
+func openDatabase() *db.DB {
+	database := db.Open()
+	db.Ping()
+	return database
+}
+
+func runMigrateUp(args []string) error {
+	if len(args) != 0 {
+		return errCLIUsage
+	}
+	database := openDatabase()
+	defer db.Close()
+	RunSchemaMigration(database.URI, database)
+	return nil
+}
+
+func runMigrateStatus(args []string) error {
+	if len(args) != 0 {
+		return errCLIUsage
+	}
+	database := openDatabase()
+	defer db.Close()
+	PrintSchemaMigrationStatus(database.URI, database)
+	return nil
+}
+
+func runDBCopy(args []string) error {
+	fs := flag.NewFlagSet("db copy", flag.ContinueOnError)
+	from := fs.String("from", config.DatabaseURI, "source database uri")
+	to := fs.String("to", "", "target database uri")
+	batchSize := fs.Int("batch-size", 5000, "rows per batch")
+	restart := fs.Bool("restart", false, "discard progress of an earlier run")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || fs.NArg() != 0 {
+		return errCLIUsage
+	}
+
+	fromUri, err := db.ParseConnectionURI(*from)
+	if err != nil {
+		return fmt.Errorf("invalid source uri: %w", err)
+	}
+	toUri, err := db.ParseConnectionURI(*to)
+	if err != nil {
+		return fmt.Errorf("invalid target uri: %w", err)
+	}
+	if fromUri.String() == toUri.String() {
+		return errors.New("source and target are the same")
+	}
+
+	source, err := db.OpenURI(fromUri)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	if err := checkSchemaOf(fromUri, source); err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+
+	target, err := db.OpenURI(toUri)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	if err := migrateSchemaOf(toUri, target); err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+
+	statePath := filepath.Join(config.DataDir, "db-copy.json")
+	if *restart {
+		if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	counts, err := db_copy.Copy(&db_copy.Config{
+		From:       fromUri,
+		To:         toUri,
+		BatchSize:  *batchSize,
+		SkipTables: []string{schemaMigrationTableName},
+		StatePath:  statePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	mismatchCount := 0
+	for _, tc := range counts {
+		status := "ok"
+		if !tc.IsMatch() {
+			status = "MISMATCH"
+			mismatchCount++
+		}
+		fmt.Printf("%-30s %12d %12d  %s\n", tc.Table, tc.Source, tc.Target, status)
+	}
+	if mismatchCount > 0 {
+		return fmt.Errorf("row count mismatch in %d table(s)", mismatchCount)
+	}
+	fmt.Fprintf(os.Stderr, "copied %d table(s)\n", len(counts))
+	return nil
+}
+
+func runWorkerList(args []string) error {
+	if len(args) != 0 {
+		return errCLIUsage
+	}
+	ids := make([]string, 0, len(worker.WorkerDetailsById))
+	for id := range worker.WorkerDetailsById {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		fmt.Printf("%-30s %s\n", id, worker.WorkerDetailsById[id].Title)
+	}
+	return nil
+}
+
+func runWorkerRun(args []string) error {
+	fs := flag.NewFlagSet("worker run", flag.ContinueOnError)
+	force := fs.Bool("force", false, "run even if disabled or recently done")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errCLIUsage
+	}
+	id := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errCLIUsage
+	}
+
+	database := openDatabase()
+	defer db.Close()
+	RunSchemaMigration(database.URI, database)
+
+	return worker.RunOnce(id, *force)
+}
+
+func runTorrentsDump(args []string) error {
+	fs := flag.NewFlagSet("torrents dump", flag.ContinueOnError)
+	output := fs.String("output", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	bw := bufio.NewWriter(w)
+
+	openDatabase()
+	defer db.Close()
+
+	encoder := json.NewEncoder(bw)
+	count := 0
+	afterHash := ""
+	for {
+		items, err := ti.ListAfterHash(afterHash, 1000)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			if err := encoder.Encode(&items[i]); err != nil {
+				return err
+			}
+		}
+		count += len(items)
+		if len(items) < 1000 {
+			break
+		}
+		afterHash = items[len(items)-1].Hash
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "dumped %d torrents\n", count)
+	return nil
+}
+
+func runTorrentsImport(args []string) error {
+	fs := flag.NewFlagSet("torrents import", flag.ContinueOnError)
+	input := fs.String("input", "", "input file (default: stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !config.Feature.HasTorrentInfo() {
+		return errors.New("torrent info feature is disabled")
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	openDatabase()
+	defer db.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	count := 0
+	items := make([]ti.TorrentInfoInsertData, 0, 1000)
+	for {
+		var item ti.TorrentInfoInsertData
+		err := decoder.Decode(&item)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode item %d: %w", count+len(items)+1, err)
+		}
+		items = append(items, item)
+		if len(items) == cap(items) {
+			if err := ti.Upsert(items, "", false); err != nil {
+				return err
+			}
+			count += len(items)
+			items = items[:0]
+		}
+	}
+	if err := ti.Upsert(items, "", false); err != nil {
+		return err
+	}
+	count += len(items)
+
+	fmt.Fprintf(os.Stderr, "imported %d torrents\n", count)
+	return nil
+}
+
+func runVaultRotateSecret(args []string) error {
+	fs := flag.NewFlagSet("vault rotate-secret", flag.ContinueOnError)
+	newSecret := fs.String("new-secret", "", "new vault secret")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *newSecret == "" || fs.NArg() != 0 {
+		return errCLIUsage
+	}
+
+	oldSecret := config.VaultSecret
+	if oldSecret == "" {
+		return errors.New("STREMTHRU_VAULT_SECRET is not set")
+	}
+	if oldSecret == *newSecret {
+		return errors.New("new secret is same as the current secret")
+	}
+
+	database := openDatabase()
+	defer db.Close()
+	RunSchemaMigration(database.URI, database)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	userCount, err := auth_user.RotateSecret(tx, oldSecret, *newSecret)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	accountCount, err := stremio_account.RotateSecret(tx, oldSecret, *newSecret)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	indexerCount, err := torznab_indexer.RotateSecret(tx, oldSecret, *newSecret)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	queueItemCount, err := worker_queue.RotateSecret(tx, oldSecret, *newSecret)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "re-encrypted %d user(s), %d stremio account(s), %d torznab indexer(s) and %d worker queue item(s)\n", userCount, accountCount, indexerCount, queueItemCount)
+	fmt.Fprintln(os.Stderr, "update STREMTHRU_VAULT_SECRET to the new secret before restarting")
+	return nil
+}
+
+func runConfigCheck(args []string) error {
+	if len(args) != 0 {
+		return errCLIUsage
+	}
+	config.PrintConfig(getAppState())
+	fmt.Fprintln(os.Stderr, "config ok")
+	return nil
+}
+
+func runConfigShow(args []string) error {
+	if len(args) != 0 {
+		return errCLIUsage
+	}
+	config.PrintEffectiveConfig(getAppState())
+	return nil
+}
@@ -52,6 +52,11 @@ type StremThruError interface {
 	Send(w http.ResponseWriter, r *http.Request)
 }
 
+type ErrorDetail struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
 type Error struct {
 	RequestId string `json:"request_id"`
 
@@ -64,6 +69,8 @@ type Error struct {
 	Path       string `json:"path,omitempty"`
 	StatusCode int    `json:"status_code,omitempty"`
 
+	Errors []ErrorDetail `json:"errors,omitempty"`
+
 	StoreName     string `json:"store_name,omitempty"`
 	UpstreamCause error  `json:"__upstream_cause__,omitempty"`
 
@@ -128,6 +135,11 @@ func (e *Error) WithCause(cause error) *Error {
 	return e
 }
 
+func (e *Error) Append(details ...ErrorDetail) *Error {
+	e.Errors = append(e.Errors, details...)
+	return e
+}
+
 func (e *Error) Send(w http.ResponseWriter, r *http.Request) {
 	e.Pack(r)
 
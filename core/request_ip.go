@@ -3,6 +3,7 @@ package core
 import (
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 )
 
@@ -69,6 +70,45 @@ func GetClientIP(r *http.Request) string {
 	return GetRequestIP(r)
 }
 
+// Address of the connected peer. Forwarded headers are only honored when the
+// peer is a trusted proxy, in which case the nearest untrusted hop in
+// `X-Forwarded-For` is used, falling back to `X-Real-Ip`.
+func GetPeerIP(r *http.Request, isTrustedProxy func(ip netip.Addr) bool) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	peer = peer.Unmap()
+	if isTrustedProxy == nil || !isTrustedProxy(peer) {
+		return peer.String()
+	}
+
+	if hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ","); len(hops) > 0 && strings.TrimSpace(hops[0]) != "" {
+		ip := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			ip = hop.Unmap()
+			if !isTrustedProxy(ip) {
+				break
+			}
+		}
+		return ip.String()
+	}
+
+	if ip, err := netip.ParseAddr(r.Header.Get("X-Real-Ip")); err == nil {
+		return ip.Unmap().String()
+	}
+
+	return peer.String()
+}
+
 func GetRequestIPHeaders(r *http.Request) map[string]string {
 	ipHeaders := make(map[string]string)
 	for _, header := range ipRequestHeaders {
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPeerIP(t *testing.T) {
+	trusted := netip.MustParsePrefix("10.0.0.0/8")
+	isTrustedProxy := func(ip netip.Addr) bool {
+		return trusted.Contains(ip)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		output     string
+	}{
+		{
+			"untrusted peer",
+			"203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-Ip": "198.51.100.2"},
+			"203.0.113.7",
+		},
+		{
+			"trusted peer - x-forwarded-for",
+			"10.0.0.2:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9, 10.0.0.3"},
+			"203.0.113.9",
+		},
+		{
+			"trusted peer - all hops trusted",
+			"10.0.0.2:5000",
+			map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"},
+			"10.0.0.4",
+		},
+		{
+			"trusted peer - x-real-ip",
+			"10.0.0.2:5000",
+			map[string]string{"X-Real-Ip": "198.51.100.2"},
+			"198.51.100.2",
+		},
+		{
+			"trusted peer - no header",
+			"10.0.0.2:5000",
+			nil,
+			"10.0.0.2",
+		},
+		{
+			"ipv4 mapped peer",
+			"[::ffff:203.0.113.7]:5000",
+			nil,
+			"203.0.113.7",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/?client_ip=198.51.100.3", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, tc.output, GetPeerIP(r, isTrustedProxy))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package audit_log
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+const redacted = "[REDACTED]"
+
+var sensitiveKeyParts = []string{
+	"password",
+	"token",
+	"secret",
+	"api_key",
+	"apikey",
+	"key_hash",
+	"authorization",
+	"cookie",
+	"credential",
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "key" {
+		return true
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+type Change struct {
+	Path   string `json:"path"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type Changes []Change
+
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	return db.JSONValue(c)
+}
+
+func (c *Changes) Scan(value any) error {
+	return db.JSONScan(value, c)
+}
+
+type leaf struct {
+	value     any
+	sensitive bool
+}
+
+// Flattens nested objects into dot separated paths. Arrays are kept as is.
+func flatten(prefix string, value any, sensitive bool, result map[string]leaf) {
+	obj, ok := value.(map[string]any)
+	if !ok || (len(obj) == 0 && prefix != "") {
+		result[prefix] = leaf{value: value, sensitive: sensitive}
+		return
+	}
+	for key, v := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		flatten(path, v, sensitive || isSensitiveKey(key), result)
+	}
+}
+
+func redact(value any, sensitive bool) any {
+	if value == nil || value == "" {
+		return value
+	}
+	if sensitive {
+		return redacted
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		obj := make(map[string]any, len(v))
+		for key, val := range v {
+			obj[key] = redact(val, isSensitiveKey(key))
+		}
+		return obj
+	case []any:
+		list := make([]any, len(v))
+		for i, val := range v {
+			list[i] = redact(val, false)
+		}
+		return list
+	}
+	return value
+}
+
+// Diffs two JSON decoded values, field by field. Values of fields that look
+// like secrets are redacted, only the fact that they changed is recorded.
+func Diff(before, after any) Changes {
+	b, a := map[string]leaf{}, map[string]leaf{}
+	if before != nil {
+		flatten("", before, false, b)
+	}
+	if after != nil {
+		flatten("", after, false, a)
+	}
+
+	paths := make([]string, 0, len(b)+len(a))
+	for path := range b {
+		paths = append(paths, path)
+	}
+	for path := range a {
+		if _, ok := b[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	changes := Changes{}
+	for _, path := range paths {
+		bl, al := b[path], a[path]
+		if reflect.DeepEqual(bl.value, al.value) {
+			continue
+		}
+		sensitive := bl.sensitive || al.sensitive
+		changes = append(changes, Change{
+			Path:   path,
+			Before: redact(bl.value, sensitive),
+			After:  redact(al.value, sensitive),
+		})
+	}
+	return changes
+}
+
+// Adds the fields of the request body missing from `after`, e.g. write-only
+// fields like passwords, so that changes to those are recorded too.
+func MergeRequest(after any, request any) any {
+	req, ok := request.(map[string]any)
+	if !ok {
+		return after
+	}
+	if after == nil {
+		return req
+	}
+	obj, ok := after.(map[string]any)
+	if !ok {
+		return after
+	}
+	for key, value := range req {
+		if _, exists := obj[key]; !exists {
+			obj[key] = value
+		}
+	}
+	return obj
+}
+
+// Decodes `data` from a dash API JSON response.
+func ParseResponseData(body []byte) any {
+	res := struct {
+		Data any `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil
+	}
+	return res.Data
+}
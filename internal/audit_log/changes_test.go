@@ -0,0 +1,68 @@
+package audit_log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		before any
+		after  any
+		output Changes
+	}{
+		{
+			"no change",
+			map[string]any{"name": "a", "limit": 5.0},
+			map[string]any{"name": "a", "limit": 5.0},
+			Changes{},
+		},
+		{
+			"create",
+			nil,
+			map[string]any{"name": "a", "limit": nil},
+			Changes{
+				{Path: "name", Before: nil, After: "a"},
+			},
+		},
+		{
+			"update nested",
+			map[string]any{"sync_config": map[string]any{"dir": "none", "ids": []any{"a"}}},
+			map[string]any{"sync_config": map[string]any{"dir": "a2b", "ids": []any{"a"}}},
+			Changes{
+				{Path: "sync_config.dir", Before: "none", After: "a2b"},
+			},
+		},
+		{
+			"delete",
+			map[string]any{"name": "a"},
+			nil,
+			Changes{
+				{Path: "name", Before: "a", After: nil},
+			},
+		},
+		{
+			"redact",
+			map[string]any{"api_key": "old", "auth": map[string]any{"password": ""}},
+			map[string]any{"api_key": "new", "auth": map[string]any{"password": "pw"}},
+			Changes{
+				{Path: "api_key", Before: redacted, After: redacted},
+				{Path: "auth.password", Before: "", After: redacted},
+			},
+		},
+		{
+			"redact in list",
+			map[string]any{"accounts": []any{}},
+			map[string]any{"accounts": []any{map[string]any{"id": "x", "token": "t"}}},
+			Changes{
+				{Path: "accounts", Before: []any{}, After: []any{map[string]any{"id": "x", "token": redacted}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.output, Diff(tc.before, tc.after))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package audit_log
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/rs/xid"
+)
+
+const TableName = "audit_log"
+
+// Entries are only ever inserted, never updated or deleted.
+type AuditLog struct {
+	Id      string
+	Actor   string
+	Action  string
+	Target  string
+	Status  int
+	IP      string
+	Changes Changes
+	CAt     db.Timestamp
+}
+
+var Column = struct {
+	Id      string
+	Actor   string
+	Action  string
+	Target  string
+	Status  string
+	IP      string
+	Changes string
+	CAt     string
+}{
+	Id:      "id",
+	Actor:   "actor",
+	Action:  "action",
+	Target:  "target",
+	Status:  "status",
+	IP:      "ip",
+	Changes: "changes",
+	CAt:     "cat",
+}
+
+var columns = []string{
+	Column.Id,
+	Column.Actor,
+	Column.Action,
+	Column.Target,
+	Column.Status,
+	Column.IP,
+	Column.Changes,
+	Column.CAt,
+}
+
+var query_insert = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (?,?,?,?,?,?,?)`,
+	TableName,
+	db.JoinColumnNames(
+		Column.Id,
+		Column.Actor,
+		Column.Action,
+		Column.Target,
+		Column.Status,
+		Column.IP,
+		Column.Changes,
+	),
+)
+
+func Insert(item *AuditLog) error {
+	if item.Id == "" {
+		item.Id = xid.New().String()
+	}
+	_, err := db.Exec(query_insert, item.Id, item.Actor, item.Action, item.Target, item.Status, item.IP, item.Changes)
+	return err
+}
+
+type GetItemsParams struct {
+	Actor  string
+	Action string
+	// Prefix of the target.
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+}
+
+func (params *GetItemsParams) where() (string, []any) {
+	conds := []string{}
+	args := []any{}
+	if params.Actor != "" {
+		conds = append(conds, Column.Actor+" = ?")
+		args = append(args, params.Actor)
+	}
+	if params.Action != "" {
+		conds = append(conds, Column.Action+" = ?")
+		args = append(args, params.Action)
+	}
+	if params.Target != "" {
+		conds = append(conds, Column.Target+` LIKE ? ESCAPE '\'`)
+		args = append(args, strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(params.Target)+"%")
+	}
+	if !params.Since.IsZero() {
+		conds = append(conds, Column.CAt+" >= ?")
+		args = append(args, db.Timestamp{Time: params.Since})
+	}
+	if !params.Until.IsZero() {
+		conds = append(conds, Column.CAt+" < ?")
+		args = append(args, db.Timestamp{Time: params.Until})
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+var query_get_items_prefix = fmt.Sprintf(
+	`SELECT %s FROM %s`,
+	db.JoinColumnNames(columns...),
+	TableName,
+)
+
+var query_get_items_suffix = fmt.Sprintf(
+	` ORDER BY %s DESC, %s DESC LIMIT ? OFFSET ?`,
+	Column.CAt,
+	Column.Id,
+)
+
+func GetItems(params GetItemsParams) ([]AuditLog, error) {
+	where, args := params.where()
+	if params.Limit <= 0 {
+		params.Limit = 50
+	}
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := db.Query(query_get_items_prefix+where+query_get_items_suffix, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []AuditLog{}
+	for rows.Next() {
+		item := AuditLog{}
+		if err := rows.Scan(&item.Id, &item.Actor, &item.Action, &item.Target, &item.Status, &item.IP, &item.Changes, &item.CAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+var query_count_items = fmt.Sprintf(
+	`SELECT COUNT(1) FROM %s`,
+	TableName,
+)
+
+func CountItems(params GetItemsParams) (int, error) {
+	where, args := params.where()
+	var count int
+	if err := db.QueryRow(query_count_items+where, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
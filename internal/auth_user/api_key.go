@@ -0,0 +1,203 @@
+package auth_user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/rs/xid"
+)
+
+const APIKeyTableName = "auth_user_api_key"
+
+const apiKeyPrefix = "stk_"
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+type APIKey struct {
+	Id       string
+	UserName string
+	Name     string
+	KeyHash  string
+	CAt      db.Timestamp
+}
+
+var APIKeyColumn = struct {
+	Id       string
+	UserName string
+	Name     string
+	KeyHash  string
+	CAt      string
+}{
+	Id:       "id",
+	UserName: "user_name",
+	Name:     "name",
+	KeyHash:  "key_hash",
+	CAt:      "cat",
+}
+
+var apiKeyColumns = []string{
+	APIKeyColumn.Id,
+	APIKeyColumn.UserName,
+	APIKeyColumn.Name,
+	APIKeyColumn.KeyHash,
+	APIKeyColumn.CAt,
+}
+
+// user name by key hash, empty for unknown keys
+var apiKeyUserCache = cache.NewCache[string](&cache.CacheConfig{
+	Lifetime:      5 * time.Minute,
+	Name:          "auth_user_api_key",
+	LocalCapacity: 1024,
+})
+
+var query_insert_api_key = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (?,?,?,?)`,
+	APIKeyTableName,
+	db.JoinColumnNames(
+		APIKeyColumn.Id,
+		APIKeyColumn.UserName,
+		APIKeyColumn.Name,
+		APIKeyColumn.KeyHash,
+	),
+)
+
+// Returns the plain key, which is not stored and can not be retrieved later.
+func CreateAPIKey(userName, name string) (*APIKey, string, error) {
+	key := apiKeyPrefix + rand.Text()
+	item := &APIKey{
+		Id:       xid.New().String(),
+		UserName: userName,
+		Name:     name,
+		KeyHash:  hashAPIKey(key),
+		CAt:      db.Timestamp{Time: time.Now()},
+	}
+	if _, err := db.Exec(query_insert_api_key, item.Id, item.UserName, item.Name, item.KeyHash); err != nil {
+		return nil, "", err
+	}
+	apiKeyUserCache.Remove(item.KeyHash)
+	return item, key, nil
+}
+
+var query_get_api_keys_by_user_name = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ? ORDER BY %s`,
+	db.JoinColumnNames(apiKeyColumns...),
+	APIKeyTableName,
+	APIKeyColumn.UserName,
+	APIKeyColumn.CAt,
+)
+
+func GetAPIKeysByUserName(userName string) ([]APIKey, error) {
+	rows, err := db.Query(query_get_api_keys_by_user_name, userName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []APIKey{}
+	for rows.Next() {
+		item := APIKey{}
+		if err := rows.Scan(&item.Id, &item.UserName, &item.Name, &item.KeyHash, &item.CAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+var query_get_user_name_by_api_key_hash = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	APIKeyColumn.UserName,
+	APIKeyTableName,
+	APIKeyColumn.KeyHash,
+)
+
+// Returns the name of the user the key belongs to, or empty string for
+// unknown or revoked keys.
+func GetUserNameByAPIKey(key string) (string, error) {
+	if !IsAPIKey(key) {
+		return "", nil
+	}
+
+	keyHash := hashAPIKey(key)
+
+	userName := ""
+	if apiKeyUserCache.Get(keyHash, &userName) {
+		return userName, nil
+	}
+
+	row := db.QueryRow(query_get_user_name_by_api_key_hash, keyHash)
+	if err := row.Scan(&userName); err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	if err := apiKeyUserCache.Add(keyHash, userName); err != nil {
+		return "", err
+	}
+
+	return userName, nil
+}
+
+var query_get_api_key_hash = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ? AND %s = ?`,
+	APIKeyColumn.KeyHash,
+	APIKeyTableName,
+	APIKeyColumn.UserName,
+	APIKeyColumn.Id,
+)
+
+var query_delete_api_key = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ? AND %s = ?`,
+	APIKeyTableName,
+	APIKeyColumn.UserName,
+	APIKeyColumn.Id,
+)
+
+// Returns `false` if the key does not exist.
+func RevokeAPIKey(userName, id string) (bool, error) {
+	keyHash := ""
+	if err := db.QueryRow(query_get_api_key_hash, userName, id).Scan(&keyHash); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := db.Exec(query_delete_api_key, userName, id); err != nil {
+		return false, err
+	}
+	apiKeyUserCache.Remove(keyHash)
+	return true, nil
+}
+
+var query_delete_api_keys_by_user_name = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ?`,
+	APIKeyTableName,
+	APIKeyColumn.UserName,
+)
+
+func revokeAllAPIKeys(userName string) error {
+	items, err := GetAPIKeysByUserName(userName)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(query_delete_api_keys_by_user_name, userName); err != nil {
+		return err
+	}
+	for i := range items {
+		apiKeyUserCache.Remove(items[i].KeyHash)
+	}
+	return nil
+}
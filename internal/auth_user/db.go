@@ -0,0 +1,384 @@
+package auth_user
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+func encrypt(value string) (string, error) {
+	return core.Encrypt(config.VaultSecret, value)
+}
+
+func decrypt(value string) (string, error) {
+	return core.Decrypt(config.VaultSecret, value)
+}
+
+func reencrypt(oldSecret, newSecret, value string) (string, error) {
+	decrypted, err := core.Decrypt(oldSecret, value)
+	if err != nil {
+		return "", err
+	}
+	return core.Encrypt(newSecret, decrypted)
+}
+
+const TableName = "auth_user"
+
+type StoreToken struct {
+	Store string `json:"store"`
+	Token string `json:"token"`
+}
+
+type AuthUser struct {
+	Name            string
+	Password        string
+	Disabled        bool
+	StoreAuth       string
+	ConnectionLimit sql.NullInt64
+	Features        db.CommaSeperatedString
+	CAt             db.Timestamp
+	UAt             db.Timestamp
+}
+
+func NewAuthUser(name, password string) (*AuthUser, error) {
+	user := &AuthUser{
+		Name:     name,
+		Features: db.CommaSeperatedString{},
+	}
+	if err := user.SetPassword(password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *AuthUser) SetPassword(password string) error {
+	encPassword, err := encrypt(password)
+	if err != nil {
+		return err
+	}
+	u.Password = encPassword
+	return nil
+}
+
+func (u *AuthUser) DecryptPassword() (string, error) {
+	return decrypt(u.Password)
+}
+
+func (u *AuthUser) SetStoreTokens(tokens []StoreToken) error {
+	if len(tokens) == 0 {
+		u.StoreAuth = ""
+		return nil
+	}
+	blob, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	storeAuth, err := encrypt(string(blob))
+	if err != nil {
+		return err
+	}
+	u.StoreAuth = storeAuth
+	return nil
+}
+
+func (u *AuthUser) GetStoreTokens() ([]StoreToken, error) {
+	tokens := []StoreToken{}
+	if u.StoreAuth == "" {
+		return tokens, nil
+	}
+	blob, err := decrypt(u.StoreAuth)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(blob), &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (u *AuthUser) toManagedUser() (*config.ManagedUser, error) {
+	password, err := u.DecryptPassword()
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := u.GetStoreTokens()
+	if err != nil {
+		return nil, err
+	}
+	mu := &config.ManagedUser{
+		Name:        u.Name,
+		Password:    password,
+		StoreTokens: make([]config.ManagedUserStoreToken, len(tokens)),
+		Features:    u.Features,
+	}
+	for i := range tokens {
+		mu.StoreTokens[i] = config.ManagedUserStoreToken{
+			Store: tokens[i].Store,
+			Token: tokens[i].Token,
+		}
+	}
+	if u.ConnectionLimit.Valid {
+		limit := int(u.ConnectionLimit.Int64)
+		mu.ConnectionLimit = &limit
+	}
+	return mu, nil
+}
+
+var Column = struct {
+	Name            string
+	Password        string
+	Disabled        string
+	StoreAuth       string
+	ConnectionLimit string
+	Features        string
+	CAt             string
+	UAt             string
+}{
+	Name:            "name",
+	Password:        "password",
+	Disabled:        "disabled",
+	StoreAuth:       "store_auth",
+	ConnectionLimit: "connection_limit",
+	Features:        "features",
+	CAt:             "cat",
+	UAt:             "uat",
+}
+
+var columns = []string{
+	Column.Name,
+	Column.Password,
+	Column.Disabled,
+	Column.StoreAuth,
+	Column.ConnectionLimit,
+	Column.Features,
+	Column.CAt,
+	Column.UAt,
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAuthUser(row rowScanner) (*AuthUser, error) {
+	item := AuthUser{}
+	if err := row.Scan(&item.Name, &item.Password, &item.Disabled, &item.StoreAuth, &item.ConnectionLimit, &item.Features, &item.CAt, &item.UAt); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+var query_upsert = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (?,?,?,?,?,?) ON CONFLICT (%s) DO UPDATE SET %s`,
+	TableName,
+	db.JoinColumnNames(
+		Column.Name,
+		Column.Password,
+		Column.Disabled,
+		Column.StoreAuth,
+		Column.ConnectionLimit,
+		Column.Features,
+	),
+	Column.Name,
+	strings.Join([]string{
+		fmt.Sprintf(`%s = EXCLUDED.%s`, Column.Password, Column.Password),
+		fmt.Sprintf(`%s = EXCLUDED.%s`, Column.Disabled, Column.Disabled),
+		fmt.Sprintf(`%s = EXCLUDED.%s`, Column.StoreAuth, Column.StoreAuth),
+		fmt.Sprintf(`%s = EXCLUDED.%s`, Column.ConnectionLimit, Column.ConnectionLimit),
+		fmt.Sprintf(`%s = EXCLUDED.%s`, Column.Features, Column.Features),
+		fmt.Sprintf(`%s = %s`, Column.UAt, db.CurrentTimestamp),
+	}, ", "),
+)
+
+func (u *AuthUser) Upsert() error {
+	_, err := db.Exec(query_upsert,
+		u.Name,
+		u.Password,
+		u.Disabled,
+		u.StoreAuth,
+		u.ConnectionLimit,
+		u.Features,
+	)
+	if err != nil {
+		return err
+	}
+	if u.CAt.IsZero() {
+		u.CAt = db.Timestamp{Time: time.Now()}
+		u.UAt = db.Timestamp{Time: u.CAt.Time}
+	} else {
+		u.UAt = db.Timestamp{Time: time.Now()}
+	}
+	return syncAndPublish()
+}
+
+var query_get_all = fmt.Sprintf(
+	`SELECT %s FROM %s ORDER BY %s`,
+	db.JoinColumnNames(columns...),
+	TableName,
+	Column.Name,
+)
+
+func GetAll() ([]AuthUser, error) {
+	rows, err := db.Query(query_get_all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []AuthUser{}
+	for rows.Next() {
+		item, err := scanAuthUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+var query_get_by_name = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	db.JoinColumnNames(columns...),
+	TableName,
+	Column.Name,
+)
+
+func GetByName(name string) (*AuthUser, error) {
+	item, err := scanAuthUser(db.QueryRow(query_get_by_name, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+var query_delete = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ?`,
+	TableName,
+	Column.Name,
+)
+
+func Delete(name string) error {
+	if err := revokeAllAPIKeys(name); err != nil {
+		return err
+	}
+	if _, err := db.Exec(query_delete, name); err != nil {
+		return err
+	}
+	return syncAndPublish()
+}
+
+var query_count = fmt.Sprintf(
+	`SELECT COUNT(%s) FROM %s`,
+	Column.Name,
+	TableName,
+)
+
+var syncMutex sync.Mutex
+
+// Loads the enabled users into the config, so that they can authenticate
+// like the ones from `STREMTHRU_PROXY_AUTH`.
+func Sync() error {
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
+
+	if !config.Feature.HasVault() {
+		// without vault the users can't be decrypted, and ignoring them
+		// could leave the instance public
+		count := 0
+		if err := db.QueryRow(query_count).Scan(&count); err != nil {
+			return err
+		}
+		config.SetManagedUsers(nil)
+		if count > 0 {
+			return fmt.Errorf("found %d users, but vault is not configured", count)
+		}
+		return nil
+	}
+
+	items, err := GetAll()
+	if err != nil {
+		return err
+	}
+
+	users := make([]config.ManagedUser, 0, len(items))
+	for i := range items {
+		item := &items[i]
+		if item.Disabled {
+			continue
+		}
+		user, err := item.toManagedUser()
+		if err != nil {
+			log.Error("failed to load user", "error", err, "name", item.Name)
+			continue
+		}
+		users = append(users, *user)
+	}
+	config.SetManagedUsers(users)
+	return nil
+}
+
+func syncAndPublish() error {
+	if err := Sync(); err != nil {
+		return err
+	}
+	publishSync()
+	return nil
+}
+
+var query_set_secrets = fmt.Sprintf(
+	`UPDATE %s SET %s = ?, %s = ? WHERE %s = ?`,
+	TableName,
+	Column.Password,
+	Column.StoreAuth,
+	Column.Name,
+)
+
+// Re-encrypts the password and store tokens of every user from
+// `oldSecret` to `newSecret`, within `tx`.
+func RotateSecret(tx *db.Tx, oldSecret, newSecret string) (int, error) {
+	rows, err := tx.Query(query_get_all)
+	if err != nil {
+		return 0, err
+	}
+	items := []AuthUser{}
+	for rows.Next() {
+		item, err := scanAuthUser(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, *item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		password, err := reencrypt(oldSecret, newSecret, item.Password)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt password for %s: %w", item.Name, err)
+		}
+		storeAuth := item.StoreAuth
+		if storeAuth != "" {
+			storeAuth, err = reencrypt(oldSecret, newSecret, storeAuth)
+			if err != nil {
+				return 0, fmt.Errorf("failed to re-encrypt store tokens for %s: %w", item.Name, err)
+			}
+		}
+		if _, err := tx.Exec(query_set_secrets, password, storeAuth, item.Name); err != nil {
+			return 0, err
+		}
+	}
+	return len(items), nil
+}
@@ -0,0 +1,5 @@
+package auth_user
+
+import "github.com/MunifTanjim/stremthru/internal/logger"
+
+var log = logger.Scoped("auth_user")
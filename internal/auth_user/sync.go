@@ -0,0 +1,69 @@
+package auth_user
+
+import (
+	"context"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/redis"
+)
+
+// Other instances pick up changes to the users within this interval, or
+// right away when redis is available.
+const syncInterval = 15 * time.Second
+
+const syncChannel = "stremthru:auth_user:sync"
+
+func publishSync() {
+	client := redis.GetClient()
+	if client == nil {
+		return
+	}
+	if err := client.Publish(context.Background(), syncChannel, config.InstanceId).Err(); err != nil {
+		log.Warn("failed to publish user sync", "error", err)
+	}
+}
+
+func resync() {
+	if err := Sync(); err != nil {
+		log.Error("failed to sync users", "error", err)
+	}
+}
+
+// Keeps the users in sync with the database, across instances.
+func InitSync() func() {
+	ticker := time.NewTicker(syncInterval)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				resync()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var closePubSub func() error
+	if client := redis.GetClient(); client != nil {
+		// reconnects on its own, re-subscribing the channel
+		pubsub := client.Subscribe(context.Background(), syncChannel)
+		closePubSub = pubsub.Close
+		go func() {
+			for m := range pubsub.Channel() {
+				if m.Payload != config.InstanceId {
+					resync()
+				}
+			}
+		}()
+	}
+
+	return func() {
+		ticker.Stop()
+		close(stop)
+		if closePubSub != nil {
+			closePubSub()
+		}
+	}
+}
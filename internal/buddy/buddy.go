@@ -2,12 +2,10 @@ package buddy
 
 import (
 	"net/http"
-	"net/url"
 	"time"
 
-	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
-	"github.com/MunifTanjim/stremthru/internal/request"
+	stremthru "github.com/MunifTanjim/stremthru/sdk/go"
 )
 
 var DefaultHTTPClient = func() *http.Client {
@@ -26,12 +24,7 @@ type APIClientConfig struct {
 }
 
 type APIClient struct {
-	BaseURL    *url.URL
-	HTTPClient *http.Client
-	agent      string
-
-	reqQuery  func(query *url.Values, params request.Context)
-	reqHeader func(query *http.Header, params request.Context)
+	client *stremthru.Client
 }
 
 func NewAPIClient(conf *APIClientConfig) *APIClient {
@@ -45,45 +38,26 @@ func NewAPIClient(conf *APIClientConfig) *APIClient {
 
 	c := &APIClient{}
 
-	if conf.BaseURL != "" {
-		baseUrl, err := url.Parse(conf.BaseURL)
-		if err != nil {
-			panic(err)
-		}
-		c.BaseURL = baseUrl
-	}
-
-	c.HTTPClient = conf.HTTPClient
-	c.agent = conf.agent
-
-	c.reqQuery = func(query *url.Values, params request.Context) {
-	}
-
-	c.reqHeader = func(header *http.Header, params request.Context) {
-		header.Add("User-Agent", c.agent)
-	}
+	c.client = stremthru.NewClient(&stremthru.ClientConfig{
+		BaseURL:    conf.BaseURL,
+		UserAgent:  conf.agent,
+		HTTPClient: conf.HTTPClient,
+		Retry: &stremthru.RetryConfig{
+			MaxRetries: 1,
+			MinWait:    500 * time.Millisecond,
+			MaxWait:    2 * time.Second,
+		},
+	})
 
 	return c
 }
 
-type Ctx = request.Ctx
+type Ctx = stremthru.Ctx
 
-func (c APIClient) Request(method, path string, params request.Context, v ResponseEnvelop) (*http.Response, error) {
-	if params == nil {
-		params = &Ctx{}
-	}
-	req, err := params.NewRequest(c.BaseURL, method, path, c.reqHeader, c.reqQuery)
-	if err != nil {
-		error := core.NewAPIError("failed to create request")
-		error.Cause = err
-		return nil, error
-	}
-	res, err := c.HTTPClient.Do(req)
-	err = processResponseBody(res, err, v)
+func (c APIClient) Request(method, path string, params stremthru.RequestContext, v ResponseEnvelop) (*http.Response, error) {
+	res, err := c.client.Request(method, path, params, v)
 	if err != nil {
-		err := UpstreamErrorWithCause(err)
-		err.InjectReq(req)
-		return res, err
+		return res, UpstreamErrorWithCause(err)
 	}
 	return res, nil
 }
@@ -13,6 +13,8 @@ func UpstreamErrorWithCause(cause error) *core.UpstreamError {
 		err.Msg = rerr.Message
 		err.Code = rerr.Code
 		err.StatusCode = rerr.StatusCode
+		err.Method = rerr.Method
+		err.Path = rerr.Path
 		err.UpstreamCause = rerr
 	} else {
 		err.Cause = cause
@@ -1,6 +1,7 @@
 package buddy
 
 import (
+	"context"
 	"regexp"
 	"slices"
 	"sync"
@@ -152,7 +153,7 @@ func BulkTrackMagnet(s store.Store, tInfos []TorrentInfoInput, cached map[string
 	}
 }
 
-func CheckMagnet(s store.Store, hashes []string, storeToken string, clientIp string, sid string) (*store.CheckMagnetData, error) {
+func CheckMagnet(ctx context.Context, s store.Store, hashes []string, storeToken string, clientIp string, sid string) (*store.CheckMagnetData, error) {
 	if matched, err := regexp.MatchString("^tt[0-9]+(:[0-9]{1,2}:[0-9]{1,3})?$", sid); err != nil || !matched {
 		sid = ""
 	}
@@ -161,7 +162,7 @@ func CheckMagnet(s store.Store, hashes []string, storeToken string, clientIp str
 		Items: []store.CheckMagnetDataItem{},
 	}
 
-	mcs, err := magnet_cache.GetByHashes(s.GetName().Code(), hashes, sid)
+	mcs, err := magnet_cache.GetByHashes(ctx, s.GetName().Code(), hashes, sid)
 	if err != nil {
 		return nil, err
 	}
@@ -12,12 +12,27 @@ type Cache[V any] interface {
 	AddWithLifetime(key string, value V, lifetime time.Duration) error
 	Get(key string, value *V) bool
 	Remove(key string)
+	Flush() error
+	// Returns the cached value, or loads and caches it on miss.
+	GetOrLoad(key string, load func() (V, error)) (V, error)
 }
 
 type CacheConfig struct {
 	Lifetime      time.Duration
 	Name          string
 	LocalCapacity uint32
+	// Duration after `Lifetime` during which `GetOrLoad` serves the stale
+	// value while reloading it in background.
+	StaleWhileRevalidate time.Duration
+	// Duration for which `GetOrLoad` remembers a failed load.
+	ErrorLifetime time.Duration
+	// Reports whether a failed load is remembered for `ErrorLifetime`. Errors
+	// from cancelled or timed out loads and network errors never are, since
+	// they depend on the caller rather than the key.
+	IsCacheableError func(err error) bool
+	// Persist under data dir when Redis is not available, so that entries
+	// survive restarts.
+	Persist bool
 }
 
 func NewCache[V any](conf *CacheConfig) Cache[V] {
@@ -29,5 +44,9 @@ func NewCache[V any](conf *CacheConfig) Cache[V] {
 		return newRedisCache[V](conf)
 	}
 
+	if conf.Persist {
+		return newFileCache[V](conf)
+	}
+
 	return NewLRUCache[V](conf)
 }
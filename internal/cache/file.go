@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/elastic/go-freelru"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const filePersistInterval = 5 * time.Minute
+
+type fileCacheEntry[V any] struct {
+	Key   string `msgpack:"k"`
+	Value V      `msgpack:"v"`
+	// unix milli, `0` for no expiry
+	ExpiresAt int64 `msgpack:"e"`
+}
+
+// In-memory cache, persisted as a snapshot under data dir, so it survives
+// restarts.
+type FileCache[V any] struct {
+	c        *freelru.LRU[string, fileCacheEntry[V]]
+	name     string
+	path     string
+	m        sync.Mutex
+	fm       sync.Mutex // guards the file
+	loader   *loader[V]
+	lifetime time.Duration
+	capacity uint32
+	counters counters
+	dirty    atomic.Bool
+}
+
+// Keys can carry credentials, e.g. store tokens or addon urls, so only
+// their hash is kept and written to the file.
+func fileCacheKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (cache *FileCache[V]) GetName() string {
+	return cache.name
+}
+
+func (cache *FileCache[V]) add(key string, value V, lifetime time.Duration) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	key = fileCacheKey(key)
+	entry := fileCacheEntry[V]{Key: key, Value: value}
+	if lifetime > 0 {
+		entry.ExpiresAt = time.Now().Add(lifetime).UnixMilli()
+	}
+	cache.c.AddWithLifetime(key, entry, lifetime)
+	cache.dirty.Store(true)
+}
+
+func (cache *FileCache[V]) Add(key string, value V) error {
+	cache.add(key, value, cache.lifetime)
+	return nil
+}
+
+func (cache *FileCache[V]) AddWithLifetime(key string, value V, lifetime time.Duration) error {
+	cache.add(key, value, lifetime)
+	return nil
+}
+
+func (cache *FileCache[V]) Get(key string, value *V) bool {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	entry, ok := cache.c.Get(fileCacheKey(key))
+	*value = entry.Value
+	cache.counters.recordGet(cache.name, ok)
+	return ok
+}
+
+func (cache *FileCache[V]) Remove(key string) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	if cache.c.Remove(fileCacheKey(key)) {
+		cache.dirty.Store(true)
+	}
+	cache.loader.forget(key)
+}
+
+func (cache *FileCache[V]) Flush() error {
+	cache.fm.Lock()
+	defer cache.fm.Unlock()
+
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	cache.c.Purge()
+	cache.loader.purge()
+	cache.dirty.Store(false)
+	if err := os.Remove(cache.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (cache *FileCache[V]) GetOrLoad(key string, load func() (V, error)) (V, error) {
+	return cache.loader.getOrLoad(cache, key, load)
+}
+
+func (cache *FileCache[V]) stats() Stats {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	return Stats{
+		Name:          cache.name,
+		Backend:       "file",
+		Lifetime:      cache.lifetime,
+		LocalCapacity: cache.capacity,
+		LocalSize:     cache.c.Len(),
+		Hits:          cache.counters.hits.Load(),
+		Misses:        cache.counters.misses.Load(),
+		Evictions:     cache.c.Metrics().Evictions,
+	}
+}
+
+func (cache *FileCache[V]) snapshot() []fileCacheEntry[V] {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	// oldest first, so that restoring keeps the recency order
+	keys := cache.c.Keys()
+	entries := make([]fileCacheEntry[V], 0, len(keys))
+	for _, key := range keys {
+		if entry, ok := cache.c.Peek(key); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (cache *FileCache[V]) persist() error {
+	cache.fm.Lock()
+	defer cache.fm.Unlock()
+
+	if !cache.dirty.Swap(false) {
+		return nil
+	}
+
+	err := cache.writeFile(cache.snapshot())
+	if err != nil {
+		cache.dirty.Store(true)
+	}
+	return err
+}
+
+func (cache *FileCache[V]) writeFile(entries []fileCacheEntry[V]) error {
+	blob, err := msgpack.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := util.EnsureDir(filepath.Dir(cache.path)); err != nil {
+		return err
+	}
+	tmpPath := cache.path + ".tmp"
+	if err := os.WriteFile(tmpPath, blob, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, cache.path)
+}
+
+func (cache *FileCache[V]) restore() error {
+	blob, err := os.ReadFile(cache.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	entries := []fileCacheEntry[V]{}
+	if err := msgpack.Unmarshal(blob, &entries); err != nil {
+		// value type may have changed, start afresh
+		os.Remove(cache.path)
+		return err
+	}
+
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	now := time.Now().UnixMilli()
+	for _, entry := range entries {
+		if entry.ExpiresAt == 0 {
+			cache.c.AddWithLifetime(entry.Key, entry, 0)
+		} else if ttl := entry.ExpiresAt - now; ttl > 0 {
+			cache.c.AddWithLifetime(entry.Key, entry, time.Duration(ttl)*time.Millisecond)
+		}
+	}
+	return nil
+}
+
+var fileCacheNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func getFileCachePath(name string) string {
+	return filepath.Join(config.DataDir, "cache", fileCacheNameReplacer.ReplaceAllString(name, "_")+".msgpack")
+}
+
+type persistableCache interface {
+	persist() error
+}
+
+var startFilePersistence sync.Once
+
+// Writes the snapshot of every file cache with pending changes.
+func Persist() error {
+	errs := []error{}
+	for _, name := range getCacheNames() {
+		for _, c := range getCaches(name) {
+			if pc, ok := c.(persistableCache); ok {
+				if err := pc.persist(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newFileCache[V any](conf *CacheConfig) *FileCache[V] {
+	if conf.Name == "" {
+		panic("failed to create cache: missing name for file cache")
+	}
+
+	lru, err := freelru.New[string, fileCacheEntry[V]](conf.LocalCapacity, CacheHashKeyString)
+	if err != nil {
+		panic("failed to create cache: " + conf.Name)
+	}
+	if conf.Lifetime != 0 {
+		lru.SetLifetime(conf.Lifetime)
+	}
+
+	cache := &FileCache[V]{
+		c:        lru,
+		name:     conf.Name,
+		path:     getFileCachePath(conf.Name),
+		loader:   newLoader[V](conf),
+		lifetime: conf.Lifetime,
+		capacity: conf.LocalCapacity,
+	}
+
+	if err := cache.restore(); err != nil {
+		cacheLog().Warn("failed to restore file cache", "name", cache.name, "error", err)
+	}
+
+	register(cache)
+
+	startFilePersistence.Do(func() {
+		go func() {
+			for range time.Tick(filePersistInterval) {
+				if err := Persist(); err != nil {
+					cacheLog().Warn("failed to persist file cache", "error", err)
+				}
+			}
+		}()
+	})
+
+	return cache
+}
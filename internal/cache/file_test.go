@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCache(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	conf := &CacheConfig{Name: "test:file", Lifetime: time.Minute, LocalCapacity: 8}
+
+	c := newFileCache[[]string](conf)
+	c.Add("a", []string{"a"})
+	c.Add("token:secret", []string{"t"})
+	c.AddWithLifetime("b", []string{"b"}, time.Millisecond)
+	c.Add("c", []string{"c"})
+	c.Remove("c")
+	assert.NoError(t, c.persist())
+
+	blob, err := os.ReadFile(c.path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(blob), "token:secret", "hashes keys")
+
+	time.Sleep(5 * time.Millisecond)
+
+	restored := newFileCache[[]string](conf)
+	value := []string{}
+	assert.True(t, restored.Get("a", &value))
+	assert.Equal(t, []string{"a"}, value)
+	assert.False(t, restored.Get("b", &value), "honours lifetime")
+	assert.False(t, restored.Get("c", &value))
+	assert.True(t, restored.Get("token:secret", &value))
+
+	assert.NoError(t, restored.Flush())
+	restored = newFileCache[[]string](conf)
+	assert.False(t, restored.Get("a", &value))
+}
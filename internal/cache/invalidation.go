@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/logger/log"
+	"github.com/MunifTanjim/stremthru/internal/redis"
+	"github.com/elastic/go-freelru"
+)
+
+const invalidationChannel = "stremthru:cache:invalidate"
+
+const localCacheMaxLifetime = 1 * time.Minute
+
+type invalidationMessage struct {
+	Origin string `json:"o"`
+	Name   string `json:"n"`
+	Key    string `json:"k,omitempty"`
+	Flush  bool   `json:"f,omitempty"`
+}
+
+var cacheLog = sync.OnceValue(func() *log.Logger {
+	return log.New(context.Background(), "scope", "cache")
+})
+
+type localCacheRef = weak.Pointer[freelru.SyncedLRU[string, []byte]]
+
+var invalidation = struct {
+	once        sync.Once
+	m           sync.RWMutex
+	localByName map[string][]localCacheRef
+}{
+	localByName: map[string][]localCacheRef{},
+}
+
+func registerLocalCache(name string, local localCache) {
+	invalidation.m.Lock()
+	refs := slices.DeleteFunc(invalidation.localByName[name], func(ref localCacheRef) bool {
+		return ref.Value() == nil
+	})
+	invalidation.localByName[name] = append(refs, weak.Make(local.c))
+	invalidation.m.Unlock()
+
+	invalidation.once.Do(subscribeInvalidation)
+}
+
+func handleInvalidation(msg *invalidationMessage) {
+	if msg.Origin == config.InstanceId {
+		return
+	}
+
+	invalidation.m.RLock()
+	defer invalidation.m.RUnlock()
+
+	for _, ref := range invalidation.localByName[msg.Name] {
+		lru := ref.Value()
+		if lru == nil {
+			continue
+		}
+		local := localCache{c: lru}
+		if msg.Flush {
+			local.Purge()
+		} else {
+			local.Del(msg.Name + ":" + msg.Key)
+		}
+	}
+}
+
+func subscribeInvalidation() {
+	client := redis.GetClient()
+	if client == nil {
+		return
+	}
+
+	// reconnects on its own, re-subscribing the channel
+	pubsub := client.Subscribe(context.Background(), invalidationChannel)
+	go func() {
+		for m := range pubsub.Channel() {
+			msg := &invalidationMessage{}
+			if err := json.Unmarshal([]byte(m.Payload), msg); err != nil {
+				cacheLog().Warn("failed to parse invalidation message", "error", err)
+				continue
+			}
+			handleInvalidation(msg)
+		}
+	}()
+}
+
+func publish(msg *invalidationMessage) {
+	client := redis.GetClient()
+	if client == nil {
+		return
+	}
+
+	msg.Origin = config.InstanceId
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := client.Publish(context.Background(), invalidationChannel, payload).Err(); err != nil {
+		cacheLog().Warn("failed to publish invalidation", "name", msg.Name, "error", err)
+	}
+}
+
+func publishInvalidation(name, key string) {
+	publish(&invalidationMessage{Name: name, Key: key})
+}
+
+func publishFlush(name string) {
+	publish(&invalidationMessage{Name: name, Flush: true})
+}
+
+var redisPatternReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func escapeRedisPattern(pattern string) string {
+	return redisPatternReplacer.Replace(pattern)
+}
@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleInvalidation(t *testing.T) {
+	local := newLocalCache(8, time.Minute)
+	registerLocalCache("test:invalidation", local)
+
+	local.Set("test:invalidation:a", []byte("a"))
+	local.Set("test:invalidation:b", []byte("b"))
+
+	handleInvalidation(&invalidationMessage{Origin: config.InstanceId, Name: "test:invalidation", Key: "a"})
+	_, ok := local.Get("test:invalidation:a")
+	assert.True(t, ok, "ignores own message")
+
+	handleInvalidation(&invalidationMessage{Origin: "other", Name: "test:invalidation", Key: "a"})
+	_, ok = local.Get("test:invalidation:a")
+	assert.False(t, ok)
+	_, ok = local.Get("test:invalidation:b")
+	assert.True(t, ok)
+
+	handleInvalidation(&invalidationMessage{Origin: "other", Name: "test:invalidation", Flush: true})
+	_, ok = local.Get("test:invalidation:b")
+	assert.False(t, ok)
+}
+
+func TestEscapeRedisPattern(t *testing.T) {
+	assert.Equal(t, `store:\*\?\[x\]`, escapeRedisPattern("store:*?[x]"))
+}
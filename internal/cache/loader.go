@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/elastic/go-freelru"
+	"golang.org/x/sync/singleflight"
+)
+
+// Backs `GetOrLoad`, concurrent loads for the same key are de-duplicated.
+type loader[V any] struct {
+	g        singleflight.Group
+	lifetime time.Duration
+	stale    time.Duration
+
+	// local only, not shared between instances
+	errs             *freelru.SyncedLRU[string, error]
+	isCacheableError func(err error) bool
+	freshUntil       *freelru.SyncedLRU[string, time.Time]
+}
+
+func newLoader[V any](conf *CacheConfig) *loader[V] {
+	l := &loader[V]{
+		lifetime: conf.Lifetime,
+	}
+
+	if conf.ErrorLifetime > 0 {
+		errs, err := freelru.NewSynced[string, error](max(conf.LocalCapacity/4, 16), CacheHashKeyString)
+		if err != nil {
+			panic(err)
+		}
+		errs.SetLifetime(conf.ErrorLifetime)
+		l.errs = errs
+		l.isCacheableError = conf.IsCacheableError
+	}
+
+	// entries never expire without lifetime, nothing to revalidate
+	if conf.StaleWhileRevalidate > 0 && conf.Lifetime > 0 {
+		freshUntil, err := freelru.NewSynced[string, time.Time](conf.LocalCapacity, CacheHashKeyString)
+		if err != nil {
+			panic(err)
+		}
+		freshUntil.SetLifetime(conf.Lifetime + conf.StaleWhileRevalidate)
+		l.stale = conf.StaleWhileRevalidate
+		l.freshUntil = freshUntil
+	}
+
+	return l
+}
+
+// Entries loaded by other instances, or before restart, are considered fresh.
+func (l *loader[V]) isStale(key string) bool {
+	if l.freshUntil == nil {
+		return false
+	}
+	until, ok := l.freshUntil.Get(key)
+	return ok && time.Now().After(until)
+}
+
+func (l *loader[V]) shouldRememberError(err error) bool {
+	if l.errs == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return false
+	}
+	return l.isCacheableError == nil || l.isCacheableError(err)
+}
+
+func (l *loader[V]) load(c Cache[V], key string, load func() (V, error)) (V, error) {
+	v, err, _ := l.g.Do(key, func() (any, error) {
+		value, err := load()
+		if err != nil {
+			if l.shouldRememberError(err) {
+				l.errs.Add(key, err)
+			}
+			return value, err
+		}
+		if l.errs != nil {
+			l.errs.Remove(key)
+		}
+		if l.freshUntil != nil {
+			l.freshUntil.Add(key, time.Now().Add(l.lifetime))
+			c.AddWithLifetime(key, value, l.lifetime+l.stale)
+		} else {
+			c.Add(key, value)
+		}
+		return value, nil
+	})
+	value, _ := v.(V)
+	return value, err
+}
+
+func (l *loader[V]) getOrLoad(c Cache[V], key string, load func() (V, error)) (V, error) {
+	var value V
+	if c.Get(key, &value) {
+		if l.isStale(key) {
+			go l.load(c, key, load)
+		}
+		return value, nil
+	}
+	if l.errs != nil {
+		if err, ok := l.errs.Get(key); ok {
+			return value, err
+		}
+	}
+	return l.load(c, key, load)
+}
+
+func (l *loader[V]) forget(key string) {
+	if l.errs != nil {
+		l.errs.Remove(key)
+	}
+	if l.freshUntil != nil {
+		l.freshUntil.Remove(key)
+	}
+}
+
+func (l *loader[V]) purge() {
+	if l.errs != nil {
+		l.errs.Purge()
+	}
+	if l.freshUntil != nil {
+		l.freshUntil.Purge()
+	}
+}
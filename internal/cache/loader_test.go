@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	t.Run("coalesce", func(t *testing.T) {
+		c := NewLRUCache[string](&CacheConfig{Name: "test:loader:coalesce", Lifetime: time.Minute})
+
+		var count atomic.Int32
+		release := make(chan struct{})
+		load := func() (string, error) {
+			count.Add(1)
+			<-release
+			return "value", nil
+		}
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				value, err := c.GetOrLoad("key", load)
+				assert.NoError(t, err)
+				assert.Equal(t, "value", value)
+			})
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), count.Load())
+
+		value, err := c.GetOrLoad("key", load)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, int32(1), count.Load())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := NewLRUCache[string](&CacheConfig{Name: "test:loader:error", Lifetime: time.Minute, ErrorLifetime: time.Minute})
+
+		var count atomic.Int32
+		load := func() (string, error) {
+			count.Add(1)
+			return "", errors.New("failed")
+		}
+
+		_, err := c.GetOrLoad("key", load)
+		assert.EqualError(t, err, "failed")
+		_, err = c.GetOrLoad("key", load)
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, int32(1), count.Load())
+
+		c.Remove("key")
+		value, err := c.GetOrLoad("key", func() (string, error) { return "value", nil })
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("error not remembered", func(t *testing.T) {
+		for name, failure := range map[string]error{
+			"canceled":  context.Canceled,
+			"timeout":   fmt.Errorf("fetch: %w", context.DeadlineExceeded),
+			"network":   &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			"transient": errors.New("transient"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				c := NewLRUCache[string](&CacheConfig{
+					Name:          "test:loader:error:" + name,
+					Lifetime:      time.Minute,
+					ErrorLifetime: time.Minute,
+					IsCacheableError: func(err error) bool {
+						return err.Error() != "transient"
+					},
+				})
+
+				_, err := c.GetOrLoad("key", func() (string, error) { return "", failure })
+				assert.ErrorIs(t, err, failure)
+
+				value, err := c.GetOrLoad("key", func() (string, error) { return "value", nil })
+				assert.NoError(t, err)
+				assert.Equal(t, "value", value)
+			})
+		}
+	})
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		c := NewLRUCache[string](&CacheConfig{Name: "test:loader:swr", Lifetime: 50 * time.Millisecond, StaleWhileRevalidate: time.Minute})
+
+		value, err := c.GetOrLoad("key", func() (string, error) { return "old", nil })
+		assert.NoError(t, err)
+		assert.Equal(t, "old", value)
+
+		time.Sleep(60 * time.Millisecond)
+
+		reloaded := make(chan struct{})
+		value, err = c.GetOrLoad("key", func() (string, error) {
+			defer close(reloaded)
+			return "new", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "old", value)
+
+		<-reloaded
+		assert.Eventually(t, func() bool {
+			value := ""
+			return c.Get("key", &value) && value == "new"
+		}, time.Second, 5*time.Millisecond)
+	})
+}
@@ -9,9 +9,13 @@ import (
 )
 
 type LRUCache[V any] struct {
-	c    *freelru.LRU[string, V]
-	name string
-	m    sync.Mutex
+	c        *freelru.LRU[string, V]
+	name     string
+	m        sync.Mutex
+	loader   *loader[V]
+	lifetime time.Duration
+	capacity uint32
+	counters counters
 }
 
 func (cache *LRUCache[V]) GetName() string {
@@ -40,6 +44,7 @@ func (cache *LRUCache[V]) Get(key string, value *V) bool {
 
 	val, ok := cache.c.Get(key)
 	*value = val
+	cache.counters.recordGet(cache.name, ok)
 	return ok
 }
 
@@ -48,6 +53,36 @@ func (cache *LRUCache[V]) Remove(key string) {
 	defer cache.m.Unlock()
 
 	cache.c.Remove(key)
+	cache.loader.forget(key)
+}
+
+func (cache *LRUCache[V]) Flush() error {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	cache.c.Purge()
+	cache.loader.purge()
+	return nil
+}
+
+func (cache *LRUCache[V]) GetOrLoad(key string, load func() (V, error)) (V, error) {
+	return cache.loader.getOrLoad(cache, key, load)
+}
+
+func (cache *LRUCache[V]) stats() Stats {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	return Stats{
+		Name:          cache.name,
+		Backend:       "lru",
+		Lifetime:      cache.lifetime,
+		LocalCapacity: cache.capacity,
+		LocalSize:     cache.c.Len(),
+		Hits:          cache.counters.hits.Load(),
+		Misses:        cache.counters.misses.Load(),
+		Evictions:     cache.c.Metrics().Evictions,
+	}
 }
 
 func CacheHashKeyString(key string) uint32 {
@@ -70,6 +105,13 @@ func NewLRUCache[V any](config *CacheConfig) *LRUCache[V] {
 	if config.Lifetime != 0 {
 		lru.SetLifetime(config.Lifetime)
 	}
-	cache := &LRUCache[V]{c: lru, name: config.Name}
+	cache := &LRUCache[V]{
+		c:        lru,
+		name:     config.Name,
+		loader:   newLoader[V](config),
+		lifetime: config.Lifetime,
+		capacity: config.LocalCapacity,
+	}
+	register(cache)
 	return cache
 }
@@ -10,7 +10,7 @@ import (
 )
 
 type localCache struct {
-	c *freelru.LRU[string, []byte]
+	c *freelru.SyncedLRU[string, []byte]
 }
 
 func (lc localCache) Set(key string, value []byte) {
@@ -25,8 +25,16 @@ func (lc localCache) Del(key string) {
 	lc.c.Remove(key)
 }
 
+func (lc localCache) Purge() {
+	lc.c.Purge()
+}
+
+func (lc localCache) Len() int {
+	return lc.c.Len()
+}
+
 func newLocalCache(capacity uint32, lifetime time.Duration) localCache {
-	lru, err := freelru.New[string, []byte](capacity, CacheHashKeyString)
+	lru, err := freelru.NewSynced[string, []byte](capacity, CacheHashKeyString)
 	if err != nil {
 		panic(err)
 	}
@@ -36,8 +44,12 @@ func newLocalCache(capacity uint32, lifetime time.Duration) localCache {
 
 type RedisCache[V any] struct {
 	c        *rc.Cache
+	local    localCache
 	name     string
 	lifetime time.Duration
+	loader   *loader[V]
+	capacity uint32
+	counters counters
 }
 
 func (cache *RedisCache[V]) GetName() string {
@@ -64,14 +76,60 @@ func (cache *RedisCache[V]) AddWithLifetime(key string, value V, lifetime time.D
 
 func (cache *RedisCache[V]) Get(key string, value *V) bool {
 	err := cache.c.Get(context.Background(), cache.name+":"+key, value)
-	if err != nil {
-		return false
-	}
-	return true
+	cache.counters.recordGet(cache.name, err == nil)
+	return err == nil
 }
 
 func (cache *RedisCache[V]) Remove(key string) {
 	cache.c.Delete(context.Background(), cache.name+":"+key)
+	cache.loader.forget(key)
+	publishInvalidation(cache.name, key)
+}
+
+func (cache *RedisCache[V]) GetOrLoad(key string, load func() (V, error)) (V, error) {
+	return cache.loader.getOrLoad(cache, key, load)
+}
+
+// Removes every key of the cache, from every instance.
+func (cache *RedisCache[V]) Flush() error {
+	ctx := context.Background()
+	client := redis.GetClient()
+	iter := client.Scan(ctx, 0, escapeRedisPattern(cache.name)+":*", 1000).Iterator()
+	keys := []string{}
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 1000 {
+			if err := client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		if err := client.Unlink(ctx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+	cache.local.Purge()
+	cache.loader.purge()
+	publishFlush(cache.name)
+	return nil
+}
+
+func (cache *RedisCache[V]) stats() Stats {
+	return Stats{
+		Name:          cache.name,
+		Backend:       "redis",
+		Lifetime:      cache.lifetime,
+		LocalCapacity: cache.capacity,
+		LocalSize:     cache.local.Len(),
+		Hits:          cache.counters.hits.Load(),
+		Misses:        cache.counters.misses.Load(),
+		Evictions:     cache.local.c.Metrics().Evictions,
+	}
 }
 
 func newRedisCache[V any](conf *CacheConfig) *RedisCache[V] {
@@ -88,14 +146,23 @@ func newRedisCache[V any](conf *CacheConfig) *RedisCache[V] {
 		conf.Lifetime = 5 * time.Minute
 	}
 
+	// kept short, in case an invalidation message is missed
+	local := newLocalCache(conf.LocalCapacity, min(conf.Lifetime/2, localCacheMaxLifetime))
+
 	cache := &RedisCache[V]{
 		c: rc.New(&rc.Options{
-			Redis: redisClient,
-			// LocalCache: newLocalCache(1024, conf.Lifetime/2),
+			Redis:      redisClient,
+			LocalCache: local,
 		}),
+		local:    local,
 		name:     conf.Name,
 		lifetime: conf.Lifetime,
+		loader:   newLoader[V](conf),
+		capacity: conf.LocalCapacity,
 	}
 
+	registerLocalCache(cache.name, local)
+	register(cache)
+
 	return cache
 }
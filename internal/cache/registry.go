@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
+
+	"github.com/MunifTanjim/stremthru/internal/metrics"
+)
+
+type Stats struct {
+	Name          string
+	Backend       string
+	Lifetime      time.Duration
+	LocalCapacity uint32
+	LocalSize     int
+	Hits          uint64
+	Misses        uint64
+	// evictions from local tier, reset on flush
+	Evictions uint64
+}
+
+type counters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func (c *counters) recordGet(name string, hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	metrics.RecordCacheGet(name, hit)
+}
+
+type namedCache interface {
+	GetName() string
+	Remove(key string)
+	Flush() error
+	stats() Stats
+}
+
+type namedCacheRef interface {
+	get() namedCache
+}
+
+// caches can be created on the fly, e.g. per client, registry must not keep them alive
+type weakNamedCacheRef[C any, P interface {
+	*C
+	namedCache
+}] weak.Pointer[C]
+
+func (ref weakNamedCacheRef[C, P]) get() namedCache {
+	if c := weak.Pointer[C](ref).Value(); c != nil {
+		return P(c)
+	}
+	return nil
+}
+
+var registry = struct {
+	m      sync.RWMutex
+	byName map[string][]namedCacheRef
+}{
+	byName: map[string][]namedCacheRef{},
+}
+
+func register[C any, P interface {
+	*C
+	namedCache
+}](c P) {
+	name := c.GetName()
+	if name == "" {
+		return
+	}
+
+	registry.m.Lock()
+	defer registry.m.Unlock()
+
+	refs := slices.DeleteFunc(registry.byName[name], func(ref namedCacheRef) bool {
+		return ref.get() == nil
+	})
+	registry.byName[name] = append(refs, weakNamedCacheRef[C, P](weak.Make((*C)(c))))
+}
+
+func getCaches(name string) []namedCache {
+	registry.m.RLock()
+	defer registry.m.RUnlock()
+
+	caches := []namedCache{}
+	for _, ref := range registry.byName[name] {
+		if c := ref.get(); c != nil {
+			caches = append(caches, c)
+		}
+	}
+	return caches
+}
+
+func getStats(caches []namedCache) Stats {
+	stats := Stats{}
+	for i, c := range caches {
+		s := c.stats()
+		if i == 0 {
+			stats = s
+			continue
+		}
+		stats.LocalCapacity += s.LocalCapacity
+		stats.LocalSize += s.LocalSize
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Evictions += s.Evictions
+	}
+	return stats
+}
+
+func getCacheNames() []string {
+	registry.m.RLock()
+	defer registry.m.RUnlock()
+
+	names := make([]string, 0, len(registry.byName))
+	for name := range registry.byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Caches sharing the same name are reported together.
+func GetAllStats() []Stats {
+	names := getCacheNames()
+	items := make([]Stats, 0, len(names))
+	for _, name := range names {
+		if caches := getCaches(name); len(caches) > 0 {
+			items = append(items, getStats(caches))
+		}
+	}
+	return items
+}
+
+func GetStats(name string) (*Stats, bool) {
+	caches := getCaches(name)
+	if len(caches) == 0 {
+		return nil, false
+	}
+	stats := getStats(caches)
+	return &stats, true
+}
+
+func Flush(name string) (bool, error) {
+	caches := getCaches(name)
+	for _, c := range caches {
+		if err := c.Flush(); err != nil {
+			return true, err
+		}
+	}
+	return len(caches) > 0, nil
+}
+
+func RemoveKey(name, key string) bool {
+	caches := getCaches(name)
+	for _, c := range caches {
+		c.Remove(key)
+	}
+	return len(caches) > 0
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	c := NewLRUCache[string](&CacheConfig{Name: "test:registry", Lifetime: time.Minute, LocalCapacity: 8})
+
+	value := ""
+	c.Get("a", &value)
+	c.Add("a", "a")
+	c.Add("b", "b")
+	c.Get("a", &value)
+
+	stats, ok := GetStats("test:registry")
+	assert.True(t, ok)
+	assert.Equal(t, &Stats{
+		Name:          "test:registry",
+		Backend:       "lru",
+		Lifetime:      time.Minute,
+		LocalCapacity: 8,
+		LocalSize:     2,
+		Hits:          1,
+		Misses:        1,
+	}, stats)
+
+	assert.True(t, RemoveKey("test:registry", "a"))
+	assert.False(t, c.Get("a", &value))
+
+	found, err := Flush("test:registry")
+	assert.True(t, found)
+	assert.NoError(t, err)
+	assert.False(t, c.Get("b", &value))
+
+	_, ok = GetStats("test:missing")
+	assert.False(t, ok)
+	assert.False(t, RemoveKey("test:missing", "a"))
+}
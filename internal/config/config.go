@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,61 +44,38 @@ var Environment = func() string {
 	}
 }()
 
+// Overrides the default values of `configKeys` for the environment.
 var defaultValueByEnv = map[string]map[string]string{
 	EnvDev: {
 		"STREMTHRU_LOG_FORMAT": "text",
 		"STREMTHRU_LOG_LEVEL":  "DEBUG",
 	},
-	EnvProd: {},
 	EnvTest: {
 		"STREMTHRU_LOG_FORMAT": "text",
 		"STREMTHRU_LOG_LEVEL":  "DEBUG",
 		"STREMTHRU_DATA_DIR":   os.TempDir(),
 	},
-	"": {
-		"STREMTHRU_BASE_URL":                               "http://localhost:8080",
-		"STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT":         "*:0",
-		"STREMTHRU_DATABASE_URI":                           "sqlite://./data/stremthru.db",
-		"STREMTHRU_DATA_DIR":                               "./data",
-		"STREMTHRU_LANDING_PAGE":                           "{}",
-		"STREMTHRU_LOG_FORMAT":                             "json",
-		"STREMTHRU_LOG_LEVEL":                              "INFO",
-		"STREMTHRU_PORT":                                   "8080",
-		"STREMTHRU_STORE_CONTENT_PROXY":                    "*:true",
-		"STREMTHRU_STORE_TUNNEL":                           "*:true",
-		"STREMTHRU_STORE_CLIENT_USER_AGENT":                "stremthru",
-		"STREMTHRU_INTEGRATION_ANILIST_LIST_STALE_TIME":    "12h",
-		"STREMTHRU_INTEGRATION_LETTERBOXD_LIST_STALE_TIME": "24h",
-		"STREMTHRU_INTEGRATION_LETTERBOXD_USER_AGENT":      "stremthru",
-		"STREMTHRU_INTEGRATION_MDBLIST_LIST_STALE_TIME":    "12h",
-		"STREMTHRU_INTEGRATION_TMDB_LIST_STALE_TIME":       "12h",
-		"STREMTHRU_INTEGRATION_TRAKT_LIST_STALE_TIME":      "12h",
-		"STREMTHRU_INTEGRATION_TVDB_LIST_STALE_TIME":       "12h",
-		"STREMTHRU_STREMIO_LIST_PUBLIC_MAX_LIST_COUNT":     "10",
-		"STREMTHRU_STREMIO_STORE_CATALOG_ITEM_LIMIT":       "2000",
-		"STREMTHRU_STREMIO_STORE_CATALOG_CACHE_TIME":       "10m",
-		"STREMTHRU_STREMIO_TORZ_INDEXER_MAX_TIMEOUT":       "10s",
-		"STREMTHRU_STREMIO_TORZ_PUBLIC_MAX_INDEXER_COUNT":  "2",
-		"STREMTHRU_STREMIO_TORZ_PUBLIC_MAX_STORE_COUNT":    "3",
-		"STREMTHRU_STREMIO_WRAP_PUBLIC_MAX_UPSTREAM_COUNT": "5",
-		"STREMTHRU_STREMIO_WRAP_PUBLIC_MAX_STORE_COUNT":    "3",
-		"STREMTHRU_IP_CHECKER":                             "aws",
-	},
 }
 
 func getEnv(key string) string {
+	return resolveEnv(ConfigFile, key)
+}
+
+func resolveEnv(file configFileConfig, key string) string {
+	conf, ok := configKeys[key]
+	if !ok {
+		panic("unknown config key: " + key)
+	}
 	if value, exists := os.LookupEnv(key); exists && len(value) > 0 {
 		return value
 	}
+	if val, found := file.lookup(key); found && len(val.value) > 0 {
+		return val.value
+	}
 	if val, found := defaultValueByEnv[Environment][key]; found && len(val) > 0 {
 		return val
 	}
-	if Environment != "" {
-		if val, found := defaultValueByEnv[""][key]; found && len(val) > 0 {
-			return val
-		}
-	}
-	return ""
+	return conf.def
 }
 
 func parseDuration(key string, value string, boundary ...time.Duration) (time.Duration, error) {
@@ -283,11 +261,9 @@ func (cpcl ContentProxyConnectionLimitMap) Get(user string) int {
 		return limit
 	}
 	if user != "*" {
-		cpcl[user] = cpcl.Get("*")
-	} else {
-		cpcl[user] = 0
+		return cpcl.Get("*")
 	}
-	return cpcl[user]
+	return 0
 }
 
 type storeContentCachedStaleTimeMapItem struct {
@@ -364,10 +340,6 @@ type Config struct {
 	LogFormat string
 
 	Port                        string
-	StoreAuthToken              StoreAuthTokenMap
-	ProxyAuthPassword           UserPasswordMap
-	AuthAdmin                   AuthAdminMap
-	AdminPassword               UserPasswordMap
 	BuddyURL                    string
 	HasBuddy                    bool
 	PeerURL                     string
@@ -377,18 +349,18 @@ type Config struct {
 	PullPeerURL                 string
 	RedisURI                    string
 	DatabaseURI                 string
-	Feature                     FeatureConfig
 	Version                     string
 	LandingPage                 string
 	ServerStartTime             time.Time
 	StoreContentProxy           StoreContentProxyMap
 	StoreContentCachedStaleTime storeContentCachedStaleTimeMap
 	StoreClientUserAgent        string
-	ContentProxyConnectionLimit ContentProxyConnectionLimitMap
 	IP                          *IPResolver
 
 	DataDir     string
 	VaultSecret string
+
+	ShutdownTimeout time.Duration
 }
 
 func parseUri(uri string) (parsedUrl, parsedToken string) {
@@ -406,8 +378,25 @@ func parseUri(uri string) (parsedUrl, parsedToken string) {
 	return
 }
 
-var config = func() Config {
-	proxyAuthCredList := strings.FieldsFunc(getEnv("STREMTHRU_PROXY_AUTH"), func(c rune) bool {
+// Generated once, so that it stays the same across reloads.
+var getGeneratedAdminCredential = sync.OnceValues(func() (string, string) {
+	username := "st-" + util.GenerateRandomString(7, util.CharSet.AlphaNumeric)
+	password := util.GenerateRandomString(27, util.CharSet.AlphaNumericMixedCase)
+	return username, password
+})
+
+type reloadableConfig struct {
+	ProxyAuthPassword           UserPasswordMap
+	AuthAdmin                   AuthAdminMap
+	AdminPassword               UserPasswordMap
+	StoreAuthToken              StoreAuthTokenMap
+	ContentProxyConnectionLimit ContentProxyConnectionLimitMap
+	Feature                     FeatureConfig
+	UserFeature                 UserFeatureMap
+}
+
+func parseReloadableConfig(env func(key string) string) (*reloadableConfig, error) {
+	proxyAuthCredList := strings.FieldsFunc(env("STREMTHRU_PROXY_AUTH"), func(c rune) bool {
 		return c == ','
 	})
 	proxyAuthPasswordMap := make(UserPasswordMap)
@@ -419,7 +408,7 @@ var config = func() Config {
 	}
 
 	authAdminMap := AuthAdminMap{}
-	authAdminList := strings.FieldsFunc(getEnv("STREMTHRU_AUTH_ADMIN"), func(c rune) bool {
+	authAdminList := strings.FieldsFunc(env("STREMTHRU_AUTH_ADMIN"), func(c rune) bool {
 		return c == ','
 	})
 	adminPasswordMap := UserPasswordMap{}
@@ -440,13 +429,12 @@ var config = func() Config {
 		}
 	}
 	if len(adminPasswordMap) == 0 {
-		username := "st-" + util.GenerateRandomString(7, util.CharSet.AlphaNumeric)
-		password := util.GenerateRandomString(27, util.CharSet.AlphaNumericMixedCase)
+		username, password := getGeneratedAdminCredential()
 		authAdminMap[username] = true
 		adminPasswordMap[username] = password
 	}
 
-	storeAlldebridTokenList := strings.FieldsFunc(getEnv("STREMTHRU_STORE_AUTH"), func(c rune) bool {
+	storeAlldebridTokenList := strings.FieldsFunc(env("STREMTHRU_STORE_AUTH"), func(c rune) bool {
 		return c == ','
 	})
 	storeAuthTokenMap := make(StoreAuthTokenMap)
@@ -454,7 +442,7 @@ var config = func() Config {
 		if user, storeToken, ok := strings.Cut(userStoreToken, ":"); ok {
 			if storeName, token, ok := strings.Cut(storeToken, ":"); ok {
 				if !store.StoreName(storeName).IsValid() {
-					log.Fatalf("invalid store name: %s", storeName)
+					return nil, fmt.Errorf("invalid store name: %s", storeName)
 				}
 				storeAuthTokenMap.addStore(user, storeName)
 				storeAuthTokenMap.setToken(user, storeName, token)
@@ -462,38 +450,17 @@ var config = func() Config {
 		}
 	}
 
-	buddyUrl, _ := parseUri(getEnv("STREMTHRU_BUDDY_URI"))
-	pullPeerUrl := ""
-	if buddyUrl != "" {
-		pullPeerUrl, _ = parseUri(getEnv("STREMTHRU__PULL__PEER_URI"))
-	}
-
-	defaultPeerUri := ""
-	if peerUri, err := core.Base64Decode("aHR0cHM6Ly9zdHJlbXRocnUuMTMzNzcwMDEueHl6"); err == nil && buddyUrl == "" {
-		defaultPeerUri = peerUri
-	}
-	peerUri := getEnv("STREMTHRU_PEER_URI")
-	if peerUri == "" {
-		peerUri = defaultPeerUri
-	}
-	peerUrl, peerAuthToken := "", ""
-	if peerUri != "-" {
-		peerUrl, peerAuthToken = parseUri(peerUri)
-	}
-
-	databaseUri := getEnv("STREMTHRU_DATABASE_URI")
-
 	feature := FeatureConfig{
 		disabled: []string{FeatureAnime, FeatureStremioP2P},
 	}
-	for _, name := range strings.FieldsFunc(strings.TrimSpace(getEnv("STREMTHRU_FEATURE")), func(c rune) bool {
+	for _, name := range strings.FieldsFunc(strings.TrimSpace(env("STREMTHRU_FEATURE")), func(c rune) bool {
 		return c == ','
 	}) {
 		switch {
 		case strings.HasPrefix(name, "-"):
 			name = strings.TrimPrefix(name, "-")
 			if slices.Contains(feature.enabled, name) {
-				log.Fatalf("feature conflict, trying to disable already enabled feature: -%s", name)
+				return nil, fmt.Errorf("feature conflict, trying to disable already enabled feature: -%s", name)
 			} else {
 				feature.disabled = append(feature.disabled, name)
 			}
@@ -504,17 +471,64 @@ var config = func() Config {
 					return feat == name
 				})
 			} else {
-				log.Fatalf("feature conflict, trying to force enable a not disabled feature: +%s", name)
+				return nil, fmt.Errorf("feature conflict, trying to force enable a not disabled feature: +%s", name)
 			}
 		default:
 			if slices.Contains(feature.disabled, name) {
-				log.Fatalf("feature conflict, trying to enable already disabled feature: %s", name)
+				return nil, fmt.Errorf("feature conflict, trying to enable already disabled feature: %s", name)
 			} else {
 				feature.enabled = append(feature.enabled, name)
 			}
 		}
 	}
 
+	contentProxyConnectionMap := make(ContentProxyConnectionLimitMap)
+	contentProxyConnectionList := strings.FieldsFunc(env("STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT"), func(c rune) bool {
+		return c == ','
+	})
+	for _, contentProxyConnection := range contentProxyConnectionList {
+		if user, limitStr, ok := strings.Cut(contentProxyConnection, ":"); ok {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid content proxy connection limit: %v", err)
+			}
+			contentProxyConnectionMap[user] = max(0, limit)
+		}
+	}
+
+	return &reloadableConfig{
+		ProxyAuthPassword:           proxyAuthPasswordMap,
+		AuthAdmin:                   authAdminMap,
+		AdminPassword:               adminPasswordMap,
+		StoreAuthToken:              storeAuthTokenMap,
+		ContentProxyConnectionLimit: contentProxyConnectionMap,
+		Feature:                     feature,
+		UserFeature:                 UserFeatureMap{},
+	}, nil
+}
+
+var config = func() Config {
+	buddyUrl, _ := parseUri(getEnv("STREMTHRU_BUDDY_URI"))
+	pullPeerUrl := ""
+	if buddyUrl != "" {
+		pullPeerUrl, _ = parseUri(getEnv("STREMTHRU__PULL__PEER_URI"))
+	}
+
+	defaultPeerUri := ""
+	if peerUri, err := core.Base64Decode("aHR0cHM6Ly9zdHJlbXRocnUuMTMzNzcwMDEueHl6"); err == nil && buddyUrl == "" {
+		defaultPeerUri = peerUri
+	}
+	peerUri := getEnv("STREMTHRU_PEER_URI")
+	if peerUri == "" {
+		peerUri = defaultPeerUri
+	}
+	peerUrl, peerAuthToken := "", ""
+	if peerUri != "-" {
+		peerUrl, peerAuthToken = parseUri(peerUri)
+	}
+
+	databaseUri := getEnv("STREMTHRU_DATABASE_URI")
+
 	storeContentProxyList := strings.FieldsFunc(getEnv("STREMTHRU_STORE_CONTENT_PROXY"), func(c rune) bool {
 		return c == ','
 	})
@@ -536,20 +550,6 @@ var config = func() Config {
 		log.Fatalf("Invalid log format: %s, expected: json / text", logFormat)
 	}
 
-	contentProxyConnectionMap := make(ContentProxyConnectionLimitMap)
-	contentProxyConnectionList := strings.FieldsFunc(getEnv("STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT"), func(c rune) bool {
-		return c == ','
-	})
-	for _, contentProxyConnection := range contentProxyConnectionList {
-		if user, limitStr, ok := strings.Cut(contentProxyConnection, ":"); ok {
-			limit, err := strconv.Atoi(limitStr)
-			if err != nil {
-				log.Fatalf("Invalid content proxy connection limit: %v", err)
-			}
-			contentProxyConnectionMap[user] = max(0, limit)
-		}
-	}
-
 	dataDir, err := filepath.Abs(getEnv("STREMTHRU_DATA_DIR"))
 	if err != nil {
 		log.Fatalf("failed to resolve data directory: %v", err)
@@ -591,10 +591,6 @@ var config = func() Config {
 		LogFormat: logFormat,
 
 		Port:                        getEnv("STREMTHRU_PORT"),
-		ProxyAuthPassword:           proxyAuthPasswordMap,
-		AuthAdmin:                   authAdminMap,
-		AdminPassword:               adminPasswordMap,
-		StoreAuthToken:              storeAuthTokenMap,
 		BuddyURL:                    buddyUrl,
 		HasBuddy:                    len(buddyUrl) > 0,
 		PeerURL:                     peerUrl,
@@ -604,20 +600,20 @@ var config = func() Config {
 		PullPeerURL:                 pullPeerUrl,
 		RedisURI:                    getEnv("STREMTHRU_REDIS_URI"),
 		DatabaseURI:                 databaseUri,
-		Feature:                     feature,
 		Version:                     "0.96.2", // x-release-please-version
 		LandingPage:                 getEnv("STREMTHRU_LANDING_PAGE"),
 		ServerStartTime:             time.Now(),
 		StoreContentProxy:           storeContentProxyMap,
 		StoreContentCachedStaleTime: storeContentCachedStaleTimeMap,
 		StoreClientUserAgent:        getEnv("STREMTHRU_STORE_CLIENT_USER_AGENT"),
-		ContentProxyConnectionLimit: contentProxyConnectionMap,
 		IP: &IPResolver{
 			checker: getEnv("STREMTHRU_IP_CHECKER"),
 		},
 
 		DataDir:     dataDir,
 		VaultSecret: vaultSecret,
+
+		ShutdownTimeout: mustParseDuration("shutdown timeout", getEnv("STREMTHRU_SHUTDOWN_TIMEOUT"), 1*time.Second),
 	}
 }()
 
@@ -625,10 +621,6 @@ var LogLevel = config.LogLevel
 var LogFormat = config.LogFormat
 
 var Port = config.Port
-var ProxyAuthPassword = config.ProxyAuthPassword
-var AuthAdmin = config.AuthAdmin
-var AdminPassword = config.AdminPassword
-var StoreAuthToken = config.StoreAuthToken
 var BuddyURL = config.BuddyURL
 var HasBuddy = config.HasBuddy
 var PeerURL = config.PeerURL
@@ -638,14 +630,12 @@ var HasPeer = config.HasPeer
 var PullPeerURL = config.PullPeerURL
 var RedisURI = config.RedisURI
 var DatabaseURI = config.DatabaseURI
-var Feature = config.Feature
 var Version = config.Version
 var LandingPage = config.LandingPage
 var ServerStartTime = config.ServerStartTime
 var StoreContentProxy = config.StoreContentProxy
 var StoreContentCachedStaleTime = config.StoreContentCachedStaleTime
 var StoreClientUserAgent = config.StoreClientUserAgent
-var ContentProxyConnectionLimit = config.ContentProxyConnectionLimit
 var InstanceId = strings.ReplaceAll(uuid.NewString(), "-", "")
 var IP = config.IP
 
@@ -669,7 +659,11 @@ var IsTrusted = func() bool {
 var DataDir = config.DataDir
 var VaultSecret = config.VaultSecret
 
-var IsPublicInstance = len(ProxyAuthPassword) == 0
+var ShutdownTimeout = config.ShutdownTimeout
+
+func IsPublicInstance() bool {
+	return len(ProxyAuthPassword()) == 0
+}
 
 func getRedactedURI(uri string) (string, error) {
 	u, err := url.Parse(uri)
@@ -684,6 +678,16 @@ type AppState struct {
 }
 
 func PrintConfig(state *AppState) {
+	printConfig(state, false)
+}
+
+// Same as `PrintConfig`, followed by every set key with its source, i.e.
+// env, file or default. Secrets are redacted.
+func PrintEffectiveConfig(state *AppState) {
+	printConfig(state, true)
+}
+
+func printConfig(state *AppState, withValues bool) {
 	hasTunnel := Tunnel.hasProxy()
 	defaultProxyHost := Tunnel.GetDefaultProxyHost()
 
@@ -709,6 +713,9 @@ func PrintConfig(state *AppState) {
 	if Environment != "" {
 		l.Printf(" Env: %v\n", Environment)
 	}
+	if ConfigFile.Path != "" {
+		l.Printf(" Config File: %v\n", ConfigFile.Path)
+	}
 	l.Println("========================")
 	l.Println()
 
@@ -762,9 +769,9 @@ func PrintConfig(state *AppState) {
 	l.Printf("   Base URL: %s\n", BaseURL.String())
 	l.Println()
 
-	if !IsPublicInstance {
+	if !IsPublicInstance() {
 		l.Println(" Users:")
-		for user := range ProxyAuthPassword {
+		for user := range ProxyAuthPassword() {
 			stores := StoreAuthToken.ListStores(user)
 			preferredStore := StoreAuthToken.GetPreferredStore(user)
 			if len(stores) == 0 {
@@ -788,7 +795,7 @@ func PrintConfig(state *AppState) {
 	l.Println(" Stores:")
 	for _, store := range state.StoreNames {
 		storeConfig := ""
-		if !IsPublicInstance && StoreContentProxy.IsEnabled(string(store)) {
+		if !IsPublicInstance() && StoreContentProxy.IsEnabled(string(store)) {
 			storeConfig += "content_proxy"
 		}
 		if hasTunnel {
@@ -797,7 +804,7 @@ func PrintConfig(state *AppState) {
 					storeConfig += ","
 				}
 				storeConfig += "tunnel:api"
-				if !IsPublicInstance && StoreTunnel.GetTypeForStream(string(store)) == TUNNEL_TYPE_FORCED {
+				if !IsPublicInstance() && StoreTunnel.GetTypeForStream(string(store)) == TUNNEL_TYPE_FORCED {
 					storeConfig += "+stream"
 				}
 			}
@@ -809,8 +816,16 @@ func PrintConfig(state *AppState) {
 	}
 	l.Println()
 
-	if len(AdminPassword) == 1 {
-		for username, password := range AdminPassword {
+	if !IsPublicInstance() && ContentProxy.Cache.IsEnabled() {
+		l.Println(" Content Proxy Cache:")
+		l.Println("         size: " + util.ToSize(ContentProxy.Cache.Size))
+		l.Println("   chunk size: " + util.ToSize(ContentProxy.Cache.ChunkSize))
+		l.Println("   read ahead: " + strconv.Itoa(ContentProxy.Cache.ReadAhead))
+		l.Println()
+	}
+
+	if adminPassword := AdminPassword(); len(adminPassword) == 1 {
+		for username, password := range adminPassword {
 			if strings.HasPrefix(username, "st-") {
 				l.Println(" (Auto Generated) Admin Creds:")
 				l.Println("   " + username + ":" + password)
@@ -876,6 +891,25 @@ func PrintConfig(state *AppState) {
 	l.Println("   " + uri)
 	l.Println()
 
+	if Tracing.IsEnabled() {
+		l.Println(" Tracing:")
+		l.Println("       exporter: " + Tracing.Exporter)
+		if Tracing.FilePath != "" {
+			l.Println("           file: " + Tracing.FilePath)
+		}
+		l.Println("   sample ratio: " + strconv.FormatFloat(Tracing.SampleRatio, 'f', -1, 64))
+		l.Println()
+	}
+
+	if Metrics.IsEnabled() {
+		l.Println(" Metrics:")
+		l.Println("   path: /metrics")
+		if Metrics.Token != "" {
+			l.Println("   token: " + Metrics.Token[0:min(3, len(Metrics.Token))] + "...")
+		}
+		l.Println()
+	}
+
 	l.Println(" Features:")
 	for _, feature := range features {
 		disabled := ""
@@ -1034,5 +1068,11 @@ func PrintConfig(state *AppState) {
 	l.Println("   " + DataDir)
 	l.Println()
 
+	if withValues {
+		l.Println(" Effective Config:")
+		printConfigValues(l)
+		l.Println()
+	}
+
 	l.Print("========================\n\n")
 }
@@ -43,6 +43,29 @@ func (s *StoreContentCachedStaleTimeTestSuite) TestStoreContentCachedStaleTime()
 	s.Equal(staleTime.GetStaleTime(false, "torbox"), 8*time.Hour)
 }
 
+type WorkerScheduleTestSuite struct {
+	suite.Suite
+}
+
+func (s *WorkerScheduleTestSuite) TestWorkerSchedule() {
+	_, err := parseWorkerScheduleMap("sync-imdb:1d")
+	s.ErrorContains(err, "invalid interval")
+
+	_, err = parseWorkerScheduleMap("sync-imdb:0 3 * *")
+	s.ErrorContains(err, "expected 5 fields")
+
+	_, err = parseWorkerScheduleMap("0 3 * * *")
+	s.ErrorContains(err, "invalid")
+
+	scheduleMap, err := parseWorkerScheduleMap("sync-imdb:0 3,15 * * *, sync-dmm-hashlist:12h,manami-anime-database:@weekly")
+	s.Nil(err)
+	s.Len(scheduleMap, 3)
+	s.Equal("0 3,15 * * *", scheduleMap["sync-imdb"].String())
+	s.Equal(12*time.Hour, scheduleMap["sync-dmm-hashlist"].Interval)
+	s.Equal("@weekly", scheduleMap["manami-anime-database"].String())
+}
+
 func TestConfig(t *testing.T) {
 	suite.Run(t, new(StoreContentCachedStaleTimeTestSuite))
+	suite.Run(t, new(WorkerScheduleTestSuite))
 }
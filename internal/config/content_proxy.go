@@ -0,0 +1,56 @@
+package config
+
+import (
+	"log"
+	"path/filepath"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type contentProxyConfigCache struct {
+	Dir       string
+	Size      int64
+	ChunkSize int64
+	ReadAhead int
+}
+
+func (c contentProxyConfigCache) IsEnabled() bool {
+	return c.Size > 0
+}
+
+type contentProxyConfig struct {
+	Cache contentProxyConfigCache
+}
+
+var ContentProxy = func() contentProxyConfig {
+	conf := contentProxyConfig{}
+
+	if size := getEnv("STREMTHRU_CONTENT_PROXY_CACHE_SIZE"); size != "" && size != "0" {
+		conf.Cache.Size = util.ToBytes(size)
+		if conf.Cache.Size <= 0 {
+			log.Fatalf("invalid content proxy cache size: %s", size)
+		}
+	}
+
+	chunkSize := getEnv("STREMTHRU_CONTENT_PROXY_CACHE_CHUNK_SIZE")
+	conf.Cache.ChunkSize = util.ToBytes(chunkSize)
+	if conf.Cache.ChunkSize < 256*1024 || conf.Cache.ChunkSize > 64*1024*1024 {
+		log.Fatalf("invalid content proxy cache chunk size (%s): must be between 256KB and 64MB", chunkSize)
+	}
+
+	readAhead, err := strconv.Atoi(getEnv("STREMTHRU_CONTENT_PROXY_CACHE_READ_AHEAD"))
+	if err != nil || readAhead < 0 {
+		log.Fatalf("invalid content proxy cache read ahead: %s", getEnv("STREMTHRU_CONTENT_PROXY_CACHE_READ_AHEAD"))
+	}
+	conf.Cache.ReadAhead = readAhead
+
+	if conf.Cache.IsEnabled() {
+		if conf.Cache.Size < conf.Cache.ChunkSize*int64(1+conf.Cache.ReadAhead) {
+			log.Fatalf("content proxy cache size (%s) must fit at least %d chunks", util.ToSize(conf.Cache.Size), 1+conf.Cache.ReadAhead)
+		}
+		conf.Cache.Dir = filepath.Join(DataDir, "content-proxy-cache")
+	}
+
+	return conf
+}()
@@ -0,0 +1,429 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	llog "github.com/MunifTanjim/stremthru/internal/logger/log"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+type configKeyKind string
+
+const (
+	configKeyKindString   configKeyKind = "string"
+	configKeyKindBool     configKeyKind = "bool"
+	configKeyKindInt      configKeyKind = "int"
+	configKeyKindFloat    configKeyKind = "float"
+	configKeyKindDuration configKeyKind = "duration"
+	configKeyKindSize     configKeyKind = "size"
+	configKeyKindURI      configKeyKind = "uri"
+)
+
+type configKey struct {
+	kind    configKeyKind
+	def     string
+	secret  bool
+	envOnly bool
+	options []string
+	check   func(value string) error
+}
+
+// Every environment variable read by the config, with its default value.
+// Except the `envOnly` ones, keys can also be set in the config file. In
+// the file, a key is written in lowercase without the `STREMTHRU_` prefix,
+// and can be nested at any `_`, e.g. `integration: { trakt: { client_id: ... } }`.
+var configKeys = map[string]configKey{
+	"NO_PROXY":                                         {kind: configKeyKindString, envOnly: true},
+	"POSTHOG_API_KEY":                                  {kind: configKeyKindString, secret: true, envOnly: true},
+	"STREMTHRU__PULL__PEER_URI":                        {kind: configKeyKindURI, envOnly: true},
+	"STREMTHRU_AUTH_ADMIN":                             {kind: configKeyKindString, secret: true},
+	"STREMTHRU_BASE_URL":                               {kind: configKeyKindURI, def: "http://localhost:8080"},
+	"STREMTHRU_BUDDY_URI":                              {kind: configKeyKindURI},
+	"STREMTHRU_CONTENT_PROXY_CACHE_CHUNK_SIZE":         {kind: configKeyKindSize, def: "4MB"},
+	"STREMTHRU_CONTENT_PROXY_CACHE_READ_AHEAD":         {kind: configKeyKindInt, def: "2"},
+	"STREMTHRU_CONTENT_PROXY_CACHE_SIZE":               {kind: configKeyKindSize, def: "0"},
+	"STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT":         {kind: configKeyKindString, def: "*:0"},
+	"STREMTHRU_DATABASE_URI":                           {kind: configKeyKindURI, def: "sqlite://./data/stremthru.db"},
+	"STREMTHRU_DATA_DIR":                               {kind: configKeyKindString, def: "./data"},
+	"STREMTHRU_FEATURE":                                {kind: configKeyKindString},
+	"STREMTHRU_HTTPS_PROXY":                            {kind: configKeyKindURI},
+	"STREMTHRU_HTTP_PROXY":                             {kind: configKeyKindURI},
+	"STREMTHRU_INTEGRATION_ANILIST_LIST_STALE_TIME":    {kind: configKeyKindDuration, def: "12h"},
+	"STREMTHRU_INTEGRATION_BITMAGNET_BASE_URL":         {kind: configKeyKindURI},
+	"STREMTHRU_INTEGRATION_BITMAGNET_DATABASE_URI":     {kind: configKeyKindURI},
+	"STREMTHRU_INTEGRATION_GITHUB_TOKEN":               {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_GITHUB_USER":                {kind: configKeyKindString},
+	"STREMTHRU_INTEGRATION_KITSU_CLIENT_ID":            {kind: configKeyKindString},
+	"STREMTHRU_INTEGRATION_KITSU_CLIENT_SECRET":        {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_KITSU_EMAIL":                {kind: configKeyKindString},
+	"STREMTHRU_INTEGRATION_KITSU_PASSWORD":             {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_LETTERBOXD_CLIENT_ID":       {kind: configKeyKindString},
+	"STREMTHRU_INTEGRATION_LETTERBOXD_CLIENT_SECRET":   {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_LETTERBOXD_LIST_STALE_TIME": {kind: configKeyKindDuration, def: "24h"},
+	"STREMTHRU_INTEGRATION_LETTERBOXD_USER_AGENT":      {kind: configKeyKindString, def: "stremthru"},
+	"STREMTHRU_INTEGRATION_MDBLIST_LIST_STALE_TIME":    {kind: configKeyKindDuration, def: "12h"},
+	"STREMTHRU_INTEGRATION_TMDB_ACCESS_TOKEN":          {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_TMDB_LIST_STALE_TIME":       {kind: configKeyKindDuration, def: "12h"},
+	"STREMTHRU_INTEGRATION_TRAKT_CLIENT_ID":            {kind: configKeyKindString},
+	"STREMTHRU_INTEGRATION_TRAKT_CLIENT_SECRET":        {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_TRAKT_LIST_STALE_TIME":      {kind: configKeyKindDuration, def: "12h"},
+	"STREMTHRU_INTEGRATION_TVDB_API_KEY":               {kind: configKeyKindString, secret: true},
+	"STREMTHRU_INTEGRATION_TVDB_LIST_STALE_TIME":       {kind: configKeyKindDuration, def: "12h"},
+	"STREMTHRU_IP_CHECKER":                             {kind: configKeyKindString, def: "aws"},
+	"STREMTHRU_LANDING_PAGE":                           {kind: configKeyKindString, def: "{}"},
+	"STREMTHRU_LAZY_PEER":                              {kind: configKeyKindBool},
+	"STREMTHRU_LOG_FORMAT":                             {kind: configKeyKindString, def: "json", options: []string{"json", "text"}},
+	"STREMTHRU_LOG_LEVEL":                              {kind: configKeyKindString, def: "INFO", check: checkLogLevel},
+	"STREMTHRU_METRICS":                                {kind: configKeyKindBool},
+	"STREMTHRU_METRICS_TOKEN":                          {kind: configKeyKindString, secret: true},
+	"STREMTHRU_PEER_FLAG":                              {kind: configKeyKindString},
+	"STREMTHRU_PEER_URI":                               {kind: configKeyKindURI},
+	"STREMTHRU_PORT":                                   {kind: configKeyKindInt, def: "8080"},
+	"STREMTHRU_PROXY_AUTH":                             {kind: configKeyKindString, secret: true},
+	"STREMTHRU_REDIS_URI":                              {kind: configKeyKindURI},
+	"STREMTHRU_SHUTDOWN_TIMEOUT":                       {kind: configKeyKindDuration, def: "30s"},
+	"STREMTHRU_STORE_AUTH":                             {kind: configKeyKindString, secret: true},
+	"STREMTHRU_STORE_CLIENT_USER_AGENT":                {kind: configKeyKindString, def: "stremthru"},
+	"STREMTHRU_STORE_CONTENT_CACHED_STALE_TIME":        {kind: configKeyKindString},
+	"STREMTHRU_STORE_CONTENT_PROXY":                    {kind: configKeyKindString, def: "*:true"},
+	"STREMTHRU_STORE_TUNNEL":                           {kind: configKeyKindString, def: "*:true"},
+	"STREMTHRU_STREMIO_LIST_PUBLIC_MAX_LIST_COUNT":     {kind: configKeyKindInt, def: "10"},
+	"STREMTHRU_STREMIO_STORE_CATALOG_CACHE_TIME":       {kind: configKeyKindDuration, def: "10m"},
+	"STREMTHRU_STREMIO_STORE_CATALOG_ITEM_LIMIT":       {kind: configKeyKindInt, def: "2000"},
+	"STREMTHRU_STREMIO_TORZ_INDEXER_MAX_TIMEOUT":       {kind: configKeyKindDuration, def: "10s"},
+	"STREMTHRU_STREMIO_TORZ_LAZY_PULL":                 {kind: configKeyKindBool},
+	"STREMTHRU_STREMIO_TORZ_PUBLIC_MAX_INDEXER_COUNT":  {kind: configKeyKindInt, def: "2"},
+	"STREMTHRU_STREMIO_TORZ_PUBLIC_MAX_STORE_COUNT":    {kind: configKeyKindInt, def: "3"},
+	"STREMTHRU_STREMIO_WRAP_PUBLIC_MAX_STORE_COUNT":    {kind: configKeyKindInt, def: "3"},
+	"STREMTHRU_STREMIO_WRAP_PUBLIC_MAX_UPSTREAM_COUNT": {kind: configKeyKindInt, def: "5"},
+	"STREMTHRU_TRACING_EXPORTER":                       {kind: configKeyKindString, options: []string{"otlp", "file"}},
+	"STREMTHRU_TRACING_FILE":                           {kind: configKeyKindString},
+	"STREMTHRU_TRACING_SAMPLE_RATIO":                   {kind: configKeyKindFloat, def: "1"},
+	"STREMTHRU_TRUSTED_PROXY":                          {kind: configKeyKindString, check: checkTrustedProxy},
+	"STREMTHRU_TUNNEL":                                 {kind: configKeyKindString},
+	"STREMTHRU_VAULT_SECRET":                           {kind: configKeyKindString, secret: true},
+	"STREMTHRU_WORKER":                                 {kind: configKeyKindString},
+	"STREMTHRU_WORKER_SCHEDULE":                        {kind: configKeyKindString, check: checkWorkerSchedule},
+}
+
+func checkLogLevel(value string) error {
+	var level llog.Level
+	return level.UnmarshalText([]byte(value))
+}
+
+func checkTrustedProxy(value string) error {
+	_, err := parseTrustedProxy(value)
+	return err
+}
+
+func (k configKey) validate(value string) error {
+	switch k.kind {
+	case configKeyKindBool:
+		switch strings.ToLower(value) {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Errorf("expected bool, got %q", value)
+		}
+	case configKeyKindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("expected integer, got %q", value)
+		}
+	case configKeyKindFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("expected number, got %q", value)
+		}
+	case configKeyKindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("expected duration (e.g. 12h), got %q", value)
+		}
+	case configKeyKindSize:
+		if util.ToBytes(value) < 0 {
+			return fmt.Errorf("expected size (e.g. 4MB), got %q", value)
+		}
+	case configKeyKindURI:
+		if _, err := url.Parse(value); err != nil {
+			return fmt.Errorf("expected uri, got %q", value)
+		}
+	}
+	if len(k.options) > 0 && !slices.Contains(k.options, value) {
+		return fmt.Errorf("expected one of %s, got %q", strings.Join(k.options, ", "), value)
+	}
+	if k.check != nil {
+		if err := k.check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k configKey) redact(value string) string {
+	if value == "" {
+		return value
+	}
+	if k.secret {
+		return strings.Repeat("*", len(value))
+	}
+	if k.kind == configKeyKindURI {
+		if u, err := url.Parse(value); err == nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+type configFileValue struct {
+	value string
+	line  int
+}
+
+type configFileConfig struct {
+	Path   string
+	values map[string]configFileValue
+}
+
+func (c configFileConfig) lookup(key string) (configFileValue, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+type configFileError struct {
+	line    int
+	key     string
+	message string
+}
+
+func (e configFileError) Error() string {
+	if e.key == "" {
+		return fmt.Sprintf("line %d: %s", e.line, e.message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.line, e.key, e.message)
+}
+
+type configFileParser struct {
+	values map[string]configFileValue
+	errs   []error
+}
+
+func (p *configFileParser) fail(line int, key string, message string) {
+	p.errs = append(p.errs, configFileError{line: line, key: key, message: message})
+}
+
+func (p *configFileParser) set(path []string, line int, value string) {
+	displayKey := strings.Join(path, ".")
+	key := "STREMTHRU_" + strings.ToUpper(strings.Join(path, "_"))
+	conf, ok := configKeys[key]
+	if !ok || conf.envOnly {
+		p.fail(line, displayKey, "unknown key")
+		return
+	}
+	if existing, seen := p.values[key]; seen {
+		p.fail(line, displayKey, fmt.Sprintf("already set at line %d", existing.line))
+		return
+	}
+	if err := conf.validate(value); err != nil {
+		p.fail(line, displayKey, err.Error())
+		return
+	}
+	p.values[key] = configFileValue{value: value, line: line}
+}
+
+func (p *configFileParser) walkYAML(path []string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			p.walkYAML(path, child)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Kind != yaml.ScalarNode || keyNode.Value == "" {
+				p.fail(keyNode.Line, strings.Join(path, "."), "invalid key")
+				continue
+			}
+			p.walkYAML(append(slices.Clone(path), keyNode.Value), valueNode)
+		}
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				p.fail(item.Line, strings.Join(path, "."), "list items must be scalar values")
+				return
+			}
+			items = append(items, item.Value)
+		}
+		p.set(path, node.Line, strings.Join(items, ","))
+	case yaml.ScalarNode:
+		if len(path) == 0 {
+			p.fail(node.Line, "", "expected a mapping at top level")
+			return
+		}
+		if node.Tag == "!!null" {
+			return
+		}
+		p.set(path, node.Line, node.Value)
+	case yaml.AliasNode:
+		p.walkYAML(path, node.Alias)
+	}
+}
+
+var tomlTableHeaderRegex = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?`)
+var tomlKeyValueRegex = regexp.MustCompile(`^\s*([A-Za-z0-9_\-."' ]+?)\s*=`)
+
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(parts[i]), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Maps dotted key paths to the line they are defined on. The decoder
+// does not expose key positions, so this follows table headers and
+// `key = value` lines, which covers the keys this file can hold.
+func indexTOMLKeyLines(content string) map[string]int {
+	lines := map[string]int{}
+	table := ""
+	for i, line := range strings.Split(content, "\n") {
+		if m := tomlTableHeaderRegex.FindStringSubmatch(line); m != nil {
+			table = normalizeTOMLKey(m[1])
+			if _, seen := lines[table]; !seen {
+				lines[table] = i + 1
+			}
+			continue
+		}
+		if m := tomlKeyValueRegex.FindStringSubmatch(line); m != nil {
+			key := normalizeTOMLKey(m[1])
+			if table != "" {
+				key = table + "." + key
+			}
+			if _, seen := lines[key]; !seen {
+				lines[key] = i + 1
+			}
+		}
+	}
+	return lines
+}
+
+func (p *configFileParser) walkTOML(path []string, value any, keyLines map[string]int) {
+	line := keyLines[strings.Join(path, ".")]
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			p.walkTOML(append(slices.Clone(path), key), v[key], keyLines)
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any, []map[string]any:
+				p.fail(line, strings.Join(path, "."), "list items must be scalar values")
+				return
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		p.set(path, line, strings.Join(items, ","))
+	case []map[string]any:
+		p.fail(line, strings.Join(path, "."), "array of tables is not supported")
+	case time.Time:
+		p.set(path, line, v.Format(time.RFC3339))
+	default:
+		p.set(path, line, fmt.Sprint(v))
+	}
+}
+
+func parseConfigFile(path string, content []byte) (map[string]configFileValue, error) {
+	p := configFileParser{values: map[string]configFileValue{}}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(content, &root); err != nil {
+			return nil, err
+		}
+		p.walkYAML(nil, &root)
+	case ".toml":
+		var root map[string]any
+		if _, err := toml.Decode(string(content), &root); err != nil {
+			var perr toml.ParseError
+			if errors.As(err, &perr) {
+				return nil, configFileError{line: perr.Position.Line, message: perr.Message}
+			}
+			return nil, err
+		}
+		p.walkTOML(nil, root, indexTOMLKeyLines(string(content)))
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+
+	if len(p.errs) > 0 {
+		slices.SortStableFunc(p.errs, func(a, b error) int {
+			return a.(configFileError).line - b.(configFileError).line
+		})
+		return nil, errors.Join(p.errs...)
+	}
+	return p.values, nil
+}
+
+var ConfigFile = func() configFileConfig {
+	conf := configFileConfig{values: map[string]configFileValue{}}
+
+	path, _ := os.LookupEnv("STREMTHRU_CONFIG_FILE")
+	if path == "" {
+		return conf
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("failed to read config file: %v", err)
+	}
+
+	values, err := parseConfigFile(path, content)
+	if err != nil {
+		log.Fatalf("invalid config file (%s):\n%v", path, err)
+	}
+
+	conf.Path = path
+	conf.values = values
+	return conf
+}()
+
+func printConfigValues(l *log.Logger) {
+	keys := make([]string, 0, len(configKeys))
+	for key := range configKeys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value := getEnv(key)
+		if value == "" {
+			continue
+		}
+
+		source := "default"
+		if v, exists := os.LookupEnv(key); exists && len(v) > 0 {
+			source = "env"
+		} else if v, found := ConfigFile.lookup(key); found {
+			source = "file:" + strconv.Itoa(v.line)
+		}
+
+		l.Printf("   %s=%s (%s)\n", key, configKeys[key].redact(value), source)
+	}
+}
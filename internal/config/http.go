@@ -5,11 +5,14 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/tracing"
 )
 
 type TunnelType string
@@ -275,7 +278,7 @@ var DefaultHTTPTransport = func() *http.Transport {
 var DefaultHTTPClient = func() *http.Client {
 	transport := DefaultHTTPTransport.Clone()
 	return &http.Client{
-		Transport: transport,
+		Transport: tracing.WrapTransport(transport),
 		Timeout:   90 * time.Second,
 	}
 }()
@@ -284,7 +287,7 @@ func GetHTTPClient(tunnelType TunnelType) *http.Client {
 	transport := DefaultHTTPTransport.Clone()
 	transport.Proxy = Tunnel.GetProxy(tunnelType)
 	return &http.Client{
-		Transport: transport,
+		Transport: tracing.WrapTransport(transport),
 		Timeout:   90 * time.Second,
 	}
 }
@@ -295,11 +298,55 @@ func getHTTPClientWithProxy(proxyUrl *url.URL) *http.Client {
 		return proxyUrl, nil
 	}
 	return &http.Client{
-		Transport: transport,
+		Transport: tracing.WrapTransport(transport),
 		Timeout:   90 * time.Second,
 	}
 }
 
+type TrustedProxyList []netip.Prefix
+
+func (l TrustedProxyList) Contains(ip netip.Addr) bool {
+	for _, prefix := range l {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxy(trustedProxy string) (TrustedProxyList, error) {
+	list := TrustedProxyList{}
+	for _, value := range strings.FieldsFunc(trustedProxy, func(c rune) bool {
+		return c == ','
+	}) {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		list = append(list, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return list, nil
+}
+
+// Forwarded headers are only trusted from these peers
+var TrustedProxy = func() TrustedProxyList {
+	list, err := parseTrustedProxy(getEnv("STREMTHRU_TRUSTED_PROXY"))
+	if err != nil {
+		log.Fatalf("failed to parse trusted proxy: %v", err)
+	}
+	return list
+}()
+
 type IPResolver struct {
 	machineIP string
 
@@ -0,0 +1,22 @@
+package config
+
+import "strings"
+
+type metricsConfig struct {
+	enabled bool
+	Token   string
+}
+
+func (conf metricsConfig) IsEnabled() bool {
+	return conf.enabled
+}
+
+var Metrics = func() metricsConfig {
+	conf := metricsConfig{}
+
+	enabled := strings.ToLower(getEnv("STREMTHRU_METRICS"))
+	conf.enabled = enabled == "1" || enabled == "true"
+	conf.Token = getEnv("STREMTHRU_METRICS_TOKEN")
+
+	return conf
+}()
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Parsed from the environment and the config file, without managed users.
+var reloadableBase = func() *reloadableConfig {
+	conf, err := parseReloadableConfig(getEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return conf
+}()
+
+var reloadable = func() *atomic.Pointer[reloadableConfig] {
+	p := &atomic.Pointer[reloadableConfig]{}
+	p.Store(reloadableBase)
+	return p
+}()
+
+type userPasswordMapRef func() UserPasswordMap
+
+func (r userPasswordMapRef) GetPassword(user string) string {
+	return r().GetPassword(user)
+}
+
+type authAdminMapRef func() AuthAdminMap
+
+func (r authAdminMapRef) IsAdmin(userName string) bool {
+	return r().IsAdmin(userName)
+}
+
+type storeAuthTokenMapRef func() StoreAuthTokenMap
+
+func (r storeAuthTokenMapRef) GetToken(user, store string) string {
+	return r().GetToken(user, store)
+}
+
+func (r storeAuthTokenMapRef) GetPreferredStore(user string) string {
+	return r().GetPreferredStore(user)
+}
+
+func (r storeAuthTokenMapRef) ListStores(user string) []string {
+	return r().ListStores(user)
+}
+
+type contentProxyConnectionLimitMapRef func() ContentProxyConnectionLimitMap
+
+func (r contentProxyConnectionLimitMapRef) Get(user string) int {
+	return r().Get(user)
+}
+
+type userFeatureMapRef func() UserFeatureMap
+
+func (r userFeatureMapRef) IsEnabled(user, name string) bool {
+	return r().IsEnabled(user, name)
+}
+
+type featureConfigRef func() FeatureConfig
+
+func (r featureConfigRef) IsDisabled(name string) bool {
+	return r().IsDisabled(name)
+}
+
+func (r featureConfigRef) IsEnabled(name string) bool {
+	return r().IsEnabled(name)
+}
+
+func (r featureConfigRef) HasStremioList() bool {
+	return r().HasStremioList()
+}
+
+func (r featureConfigRef) HasTorrentInfo() bool {
+	return r().HasTorrentInfo()
+}
+
+func (r featureConfigRef) HasDMMHashlist() bool {
+	return r().HasDMMHashlist()
+}
+
+func (r featureConfigRef) HasIMDBTitle() bool {
+	return r().HasIMDBTitle()
+}
+
+func (r featureConfigRef) HasVault() bool {
+	return r().HasVault()
+}
+
+// These always read the latest loaded value, so they can be swapped by
+// `Reload` while requests are being served.
+var ProxyAuthPassword = userPasswordMapRef(func() UserPasswordMap {
+	return reloadable.Load().ProxyAuthPassword
+})
+var AuthAdmin = authAdminMapRef(func() AuthAdminMap {
+	return reloadable.Load().AuthAdmin
+})
+var AdminPassword = userPasswordMapRef(func() UserPasswordMap {
+	return reloadable.Load().AdminPassword
+})
+var StoreAuthToken = storeAuthTokenMapRef(func() StoreAuthTokenMap {
+	return reloadable.Load().StoreAuthToken
+})
+var ContentProxyConnectionLimit = contentProxyConnectionLimitMapRef(func() ContentProxyConnectionLimitMap {
+	return reloadable.Load().ContentProxyConnectionLimit
+})
+var Feature = featureConfigRef(func() FeatureConfig {
+	return reloadable.Load().Feature
+})
+var UserFeature = userFeatureMapRef(func() UserFeatureMap {
+	return reloadable.Load().UserFeature
+})
+
+var reloadMutex sync.Mutex
+
+var reloadableKeys = []string{
+	"STREMTHRU_PROXY_AUTH",
+	"STREMTHRU_AUTH_ADMIN",
+	"STREMTHRU_STORE_AUTH",
+	"STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT",
+	"STREMTHRU_FEATURE",
+}
+
+var ErrNoConfigFileToReload = errors.New("nothing to reload, STREMTHRU_CONFIG_FILE is not set")
+
+// Re-reads `STREMTHRU_PROXY_AUTH`, `STREMTHRU_AUTH_ADMIN`,
+// `STREMTHRU_STORE_AUTH`, `STREMTHRU_CONTENT_PROXY_CONNECTION_LIMIT` and
+// `STREMTHRU_FEATURE` from the config file, and swaps them in all at once,
+// keeping the managed users on top. On error, the current values are kept.
+//
+// Environment of the running process never changes, so the keys set there
+// keep their value, and are returned as warnings.
+//
+// Everything else, including workers enabled by feature flags at
+// startup, still needs a restart.
+func Reload() (warnings []string, err error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	file := ConfigFile
+	if file.Path == "" {
+		return nil, ErrNoConfigFileToReload
+	}
+
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseConfigFile(file.Path, content)
+	if err != nil {
+		return nil, err
+	}
+	file.values = values
+
+	conf, err := parseReloadableConfig(func(key string) string {
+		return resolveEnv(file, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reloadableBase = conf
+	reloadable.Store(withManagedUsers(reloadableBase, managedUsers))
+
+	for _, key := range reloadableKeys {
+		if value, exists := os.LookupEnv(key); exists && len(value) > 0 {
+			warnings = append(warnings, key+" is set in the environment, can not be reloaded")
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloadTestSuite struct {
+	suite.Suite
+	prevFile         configFileConfig
+	prevReloadable   *reloadableConfig
+	prevBase         *reloadableConfig
+	prevManagedUsers []ManagedUser
+}
+
+func (s *ReloadTestSuite) SetupTest() {
+	s.prevFile = ConfigFile
+	s.prevReloadable = reloadable.Load()
+	s.prevBase = reloadableBase
+	s.prevManagedUsers = managedUsers
+}
+
+func (s *ReloadTestSuite) TearDownTest() {
+	ConfigFile = s.prevFile
+	reloadable.Store(s.prevReloadable)
+	reloadableBase = s.prevBase
+	managedUsers = s.prevManagedUsers
+}
+
+func (s *ReloadTestSuite) writeConfigFile(content string) {
+	path := filepath.Join(s.T().TempDir(), "stremthru.yaml")
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+	ConfigFile = configFileConfig{Path: path, values: map[string]configFileValue{}}
+}
+
+func (s *ReloadTestSuite) reload() {
+	warnings, err := Reload()
+	s.Require().NoError(err)
+	s.Empty(warnings)
+}
+
+func reloadError() error {
+	_, err := Reload()
+	return err
+}
+
+func (s *ReloadTestSuite) TestReload() {
+	s.writeConfigFile(`
+proxy_auth: alice:pass1
+store_auth: alice:realdebrid:token1
+content_proxy_connection_limit: "*:2,alice:5"
+feature: -stremio_list
+`)
+	s.reload()
+	s.Equal("pass1", ProxyAuthPassword.GetPassword("alice"))
+	s.Equal("pass1", AdminPassword.GetPassword("alice"))
+	s.Equal("token1", StoreAuthToken.GetToken("alice", "realdebrid"))
+	s.Equal(5, ContentProxyConnectionLimit.Get("alice"))
+	s.Equal(2, ContentProxyConnectionLimit.Get("bob"))
+	s.False(Feature.HasStremioList())
+
+	s.Require().NoError(os.WriteFile(ConfigFile.Path, []byte(`
+proxy_auth: alice:pass2,bob:pass3
+store_auth: alice:realdebrid:token2
+`), 0644))
+	s.reload()
+	s.Equal("pass2", ProxyAuthPassword.GetPassword("alice"))
+	s.Equal("pass3", ProxyAuthPassword.GetPassword("bob"))
+	s.Equal("token2", StoreAuthToken.GetToken("alice", "realdebrid"))
+	s.True(Feature.HasStremioList())
+}
+
+func (s *ReloadTestSuite) TestReloadKeepsCurrentOnError() {
+	s.writeConfigFile("proxy_auth: alice:pass1\n")
+	s.reload()
+
+	s.Require().NoError(os.WriteFile(ConfigFile.Path, []byte("proxy_auth: alice:pass2\nstore_auth: alice:unknownstore:token\n"), 0644))
+	s.ErrorContains(reloadError(), "invalid store name")
+	s.Equal("pass1", ProxyAuthPassword.GetPassword("alice"))
+
+	s.Require().NoError(os.WriteFile(ConfigFile.Path, []byte("proxy_auth: alice:pass2\nunknown: 1\n"), 0644))
+	s.ErrorContains(reloadError(), "line 2: unknown: unknown key")
+	s.Equal("pass1", ProxyAuthPassword.GetPassword("alice"))
+}
+
+func (s *ReloadTestSuite) TestManagedUsers() {
+	s.writeConfigFile(`
+proxy_auth: alice:pass1
+store_auth: "*:realdebrid:token0,alice:realdebrid:token1"
+content_proxy_connection_limit: "*:2"
+`)
+	s.reload()
+
+	limit := 7
+	SetManagedUsers([]ManagedUser{
+		{Name: "alice", Password: "db-pass"},
+		{
+			Name:            "bob",
+			Password:        "pass2",
+			StoreTokens:     []ManagedUserStoreToken{{Store: "torbox", Token: "token2"}, {Store: "premiumize", Token: "token3"}},
+			ConnectionLimit: &limit,
+			Features:        []string{FeatureStremioStore},
+		},
+		{Name: "carol", Password: "pass3"},
+	})
+	s.Equal("pass1", ProxyAuthPassword.GetPassword("alice"))
+	s.Equal("pass2", ProxyAuthPassword.GetPassword("bob"))
+	s.Equal("", AdminPassword.GetPassword("bob"))
+	s.Equal("torbox", StoreAuthToken.GetPreferredStore("bob"))
+	s.Equal([]string{"torbox", "premiumize"}, StoreAuthToken.ListStores("bob"))
+	s.Equal("token3", StoreAuthToken.GetToken("bob", "premiumize"))
+	s.Equal("token0", StoreAuthToken.GetToken("carol", "realdebrid"))
+	s.Equal(7, ContentProxyConnectionLimit.Get("bob"))
+	s.Equal(2, ContentProxyConnectionLimit.Get("carol"))
+	s.True(UserFeature.IsEnabled("bob", FeatureStremioStore))
+	s.False(UserFeature.IsEnabled("bob", FeatureStremioTorz))
+	s.True(UserFeature.IsEnabled("carol", FeatureStremioTorz))
+
+	s.reload()
+	s.Equal("pass2", ProxyAuthPassword.GetPassword("bob"))
+	s.Equal("token1", reloadableBase.StoreAuthToken.GetToken("alice", "realdebrid"))
+	s.Empty(reloadableBase.StoreAuthToken.getStores("bob"))
+
+	SetManagedUsers(nil)
+	s.Equal("", ProxyAuthPassword.GetPassword("bob"))
+}
+
+func (s *ReloadTestSuite) TestReloadWithoutConfigFile() {
+	ConfigFile = configFileConfig{values: map[string]configFileValue{}}
+	s.ErrorIs(reloadError(), ErrNoConfigFileToReload)
+}
+
+func (s *ReloadTestSuite) TestReloadShadowedByEnv() {
+	s.writeConfigFile("proxy_auth: alice:pass1\nstore_auth: alice:realdebrid:token1\n")
+	s.T().Setenv("STREMTHRU_PROXY_AUTH", "alice:env-pass")
+
+	warnings, err := Reload()
+	s.Require().NoError(err)
+	s.Equal([]string{"STREMTHRU_PROXY_AUTH is set in the environment, can not be reloaded"}, warnings)
+	s.Equal("env-pass", ProxyAuthPassword.GetPassword("alice"))
+	s.Equal("token1", StoreAuthToken.GetToken("alice", "realdebrid"))
+}
+
+func TestReload(t *testing.T) {
+	suite.Run(t, new(ReloadTestSuite))
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log"
+	"path/filepath"
+	"strconv"
+)
+
+type tracingConfig struct {
+	Exporter    string
+	FilePath    string
+	SampleRatio float64
+}
+
+func (conf tracingConfig) IsEnabled() bool {
+	return conf.Exporter != ""
+}
+
+var Tracing = func() tracingConfig {
+	conf := tracingConfig{}
+
+	conf.Exporter = getEnv("STREMTHRU_TRACING_EXPORTER")
+	switch conf.Exporter {
+	case "", "otlp":
+	case "file":
+		conf.FilePath = getEnv("STREMTHRU_TRACING_FILE")
+		if conf.FilePath == "" {
+			conf.FilePath = filepath.Join(DataDir, "traces.jsonl")
+		}
+	default:
+		log.Fatalf("invalid tracing exporter: %s", conf.Exporter)
+	}
+
+	sampleRatio, err := strconv.ParseFloat(getEnv("STREMTHRU_TRACING_SAMPLE_RATIO"), 64)
+	if err != nil || sampleRatio < 0 || sampleRatio > 1 {
+		log.Fatalf("invalid tracing sample ratio: %s", getEnv("STREMTHRU_TRACING_SAMPLE_RATIO"))
+	}
+	conf.SampleRatio = sampleRatio
+
+	return conf
+}()
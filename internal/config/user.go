@@ -0,0 +1,88 @@
+package config
+
+import (
+	"maps"
+	"slices"
+)
+
+type ManagedUserStoreToken struct {
+	Store string
+	Token string
+}
+
+// User managed from the dashboard, layered on top of the ones from
+// `STREMTHRU_PROXY_AUTH`.
+type ManagedUser struct {
+	Name     string
+	Password string
+	// In order of preference.
+	StoreTokens []ManagedUserStoreToken
+	// `nil` falls back to the `*` limit.
+	ConnectionLimit *int
+	// Empty allows every feature.
+	Features []string
+}
+
+type UserFeatureMap map[string][]string
+
+func (m UserFeatureMap) IsEnabled(user, name string) bool {
+	features, ok := m[user]
+	if !ok || len(features) == 0 {
+		return true
+	}
+	return slices.Contains(features, name)
+}
+
+func IsValidFeature(name string) bool {
+	return slices.Contains(features, name)
+}
+
+// Users from the environment win over managed users with the same name.
+func withManagedUsers(base *reloadableConfig, users []ManagedUser) *reloadableConfig {
+	if len(users) == 0 {
+		return base
+	}
+
+	conf := *base
+	conf.ProxyAuthPassword = maps.Clone(base.ProxyAuthPassword)
+	conf.StoreAuthToken = maps.Clone(base.StoreAuthToken)
+	conf.ContentProxyConnectionLimit = maps.Clone(base.ContentProxyConnectionLimit)
+	conf.UserFeature = maps.Clone(base.UserFeature)
+	if conf.UserFeature == nil {
+		conf.UserFeature = UserFeatureMap{}
+	}
+
+	for i := range users {
+		user := &users[i]
+		if _, exists := base.ProxyAuthPassword[user.Name]; exists || user.Name == "*" {
+			continue
+		}
+		conf.ProxyAuthPassword[user.Name] = user.Password
+		if len(user.StoreTokens) > 0 {
+			delete(conf.StoreAuthToken, user.Name)
+		}
+		for _, st := range user.StoreTokens {
+			conf.StoreAuthToken.addStore(user.Name, st.Store)
+			conf.StoreAuthToken.setToken(user.Name, st.Store, st.Token)
+		}
+		if user.ConnectionLimit != nil {
+			conf.ContentProxyConnectionLimit[user.Name] = max(0, *user.ConnectionLimit)
+		}
+		if len(user.Features) > 0 {
+			conf.UserFeature[user.Name] = user.Features
+		}
+	}
+
+	return &conf
+}
+
+var managedUsers []ManagedUser
+
+// Replaces the managed users. Disabled users should be left out.
+func SetManagedUsers(users []ManagedUser) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	managedUsers = users
+	reloadable.Store(withManagedUsers(reloadableBase, managedUsers))
+}
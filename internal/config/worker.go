@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type WorkerSchedule struct {
+	Interval time.Duration
+	Cron     *util.CronSchedule
+}
+
+func (s WorkerSchedule) String() string {
+	if s.Cron != nil {
+		return s.Cron.String()
+	}
+	return s.Interval.String()
+}
+
+type workerConfig struct {
+	enabled  []string
+	disabled []string
+	schedule map[string]WorkerSchedule
+}
+
+func (conf workerConfig) IsDisabled(id string) bool {
+	if slices.Contains(conf.disabled, id) {
+		return true
+	}
+	return len(conf.enabled) > 0 && !slices.Contains(conf.enabled, id)
+}
+
+func (conf workerConfig) GetSchedule(id string) (WorkerSchedule, bool) {
+	schedule, ok := conf.schedule[id]
+	return schedule, ok
+}
+
+// Worker ids mentioned in the config.
+func (conf workerConfig) Ids() []string {
+	ids := slices.Concat(conf.enabled, conf.disabled)
+	for id := range conf.schedule {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func parseWorkerSchedule(value string) (WorkerSchedule, error) {
+	if !strings.Contains(value, " ") && !strings.HasPrefix(value, "@") {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Minute {
+			return WorkerSchedule{}, fmt.Errorf("invalid interval, expected duration of at least 1m: %s", value)
+		}
+		return WorkerSchedule{Interval: interval}, nil
+	}
+	cron, err := util.ParseCronSchedule(value)
+	if err != nil {
+		return WorkerSchedule{}, err
+	}
+	return WorkerSchedule{Cron: cron}, nil
+}
+
+// Entries are `<id>:<schedule>`, comma separated. Cron expressions can
+// contain commas themselves, so a piece without `:` belongs to the
+// previous entry.
+func parseWorkerScheduleMap(value string) (map[string]WorkerSchedule, error) {
+	entries := []string{}
+	for part := range strings.SplitSeq(value, ",") {
+		if n := len(entries); n > 0 && !strings.Contains(part, ":") {
+			entries[n-1] += "," + part
+		} else if strings.TrimSpace(part) != "" {
+			entries = append(entries, part)
+		}
+	}
+
+	scheduleMap := map[string]WorkerSchedule{}
+	for _, entry := range entries {
+		id, spec, ok := strings.Cut(entry, ":")
+		id, spec = strings.TrimSpace(id), strings.TrimSpace(spec)
+		if !ok || id == "" || spec == "" {
+			return nil, errors.New("invalid worker schedule: " + entry)
+		}
+		schedule, err := parseWorkerSchedule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid worker schedule for %s: %w", id, err)
+		}
+		scheduleMap[id] = schedule
+	}
+	return scheduleMap, nil
+}
+
+func checkWorkerSchedule(value string) error {
+	_, err := parseWorkerScheduleMap(value)
+	return err
+}
+
+var Worker = func() workerConfig {
+	conf := workerConfig{}
+
+	for _, id := range strings.FieldsFunc(getEnv("STREMTHRU_WORKER"), func(c rune) bool {
+		return c == ','
+	}) {
+		id = strings.TrimSpace(id)
+		if after, ok := strings.CutPrefix(id, "-"); ok {
+			conf.disabled = append(conf.disabled, after)
+		} else {
+			conf.enabled = append(conf.enabled, id)
+		}
+	}
+
+	schedule, err := parseWorkerScheduleMap(getEnv("STREMTHRU_WORKER_SCHEDULE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf.schedule = schedule
+
+	return conf
+}()
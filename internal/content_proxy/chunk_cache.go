@@ -0,0 +1,545 @@
+package content_proxy
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/rs/xid"
+	"github.com/zeebo/xxh3"
+	"golang.org/x/sync/singleflight"
+)
+
+var errRangeNotSupported = errors.New("upstream does not support range request")
+
+type upstreamStatusError struct {
+	statusCode int
+}
+
+func (e upstreamStatusError) Error() string {
+	return "unexpected upstream status: " + strconv.Itoa(e.statusCode)
+}
+
+var forwardedHeaderNames = []string{
+	"Content-Disposition",
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+}
+
+type linkMeta struct {
+	Size   int64             `json:"size"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// Read ahead outlives the client request, e.g. a bounded range response,
+// so it only stops on its own deadline.
+const readAheadTimeout = 2 * time.Minute
+
+type upstreamRequest struct {
+	// cancelled when the client goes away
+	ctx    context.Context
+	link   string
+	header http.Header
+	client *http.Client
+}
+
+type chunkEntry struct {
+	linkId string
+	idx    int64
+	size   int64
+}
+
+func (e *chunkEntry) key() string {
+	return e.linkId + "/" + strconv.FormatInt(e.idx, 10)
+}
+
+type fetchedChunk struct {
+	data []byte
+	meta *linkMeta
+}
+
+type ChunkCacheConfig struct {
+	Dir       string
+	Size      int64
+	ChunkSize int64
+	ReadAhead int
+}
+
+type ChunkCache struct {
+	dir       string
+	maxSize   int64
+	chunkSize int64
+	readAhead int
+
+	m                  sync.Mutex
+	lru                *list.List
+	entryByKey         map[string]*list.Element
+	chunkCountByLinkId map[string]int
+	size               int64
+
+	fetchGroup   singleflight.Group
+	readAheadSem chan struct{}
+	metaCache    *cache.LRUCache[linkMeta]
+}
+
+func getLinkId(link string) string {
+	hash := xxh3.HashString128(link).Bytes()
+	return hex.EncodeToString(hash[:])
+}
+
+func (cc *ChunkCache) linkDir(linkId string) string {
+	return filepath.Join(cc.dir, linkId)
+}
+
+func (cc *ChunkCache) chunkPath(linkId string, idx int64) string {
+	return filepath.Join(cc.linkDir(linkId), strconv.FormatInt(idx, 10)+".chunk")
+}
+
+func (cc *ChunkCache) metaPath(linkId string) string {
+	return filepath.Join(cc.linkDir(linkId), "meta.json")
+}
+
+func (cc *ChunkCache) getMeta(linkId string) *linkMeta {
+	meta := linkMeta{}
+	if cc.metaCache.Get(linkId, &meta) {
+		return &meta
+	}
+	blob, err := os.ReadFile(cc.metaPath(linkId))
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(blob, &meta); err != nil || meta.Size <= 0 {
+		return nil
+	}
+	cc.metaCache.Add(linkId, meta)
+	return &meta
+}
+
+func (cc *ChunkCache) saveMeta(linkId string, meta *linkMeta) error {
+	cc.metaCache.Add(linkId, *meta)
+	blob, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := util.EnsureDir(cc.linkDir(linkId)); err != nil {
+		return err
+	}
+	return os.WriteFile(cc.metaPath(linkId), blob, 0644)
+}
+
+func (cc *ChunkCache) hasChunk(linkId string, idx int64) bool {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	_, ok := cc.entryByKey[(&chunkEntry{linkId: linkId, idx: idx}).key()]
+	return ok
+}
+
+func (cc *ChunkCache) readChunk(linkId string, idx int64) ([]byte, bool) {
+	entry := &chunkEntry{linkId: linkId, idx: idx}
+
+	cc.m.Lock()
+	elem, ok := cc.entryByKey[entry.key()]
+	if ok {
+		cc.lru.MoveToFront(elem)
+	}
+	cc.m.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(cc.chunkPath(linkId, idx))
+	if err != nil {
+		cc.m.Lock()
+		if elem, ok := cc.entryByKey[entry.key()]; ok {
+			cc.removeElement(elem)
+		}
+		cc.m.Unlock()
+		return nil, false
+	}
+	return data, true
+}
+
+// must be called with lock held
+func (cc *ChunkCache) removeElement(elem *list.Element) {
+	entry := cc.lru.Remove(elem).(*chunkEntry)
+	delete(cc.entryByKey, entry.key())
+	cc.size -= entry.size
+
+	if err := os.Remove(cc.chunkPath(entry.linkId, entry.idx)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warn("failed to remove chunk", "error", err, "link_id", entry.linkId, "idx", entry.idx)
+	}
+
+	cc.chunkCountByLinkId[entry.linkId]--
+	if cc.chunkCountByLinkId[entry.linkId] <= 0 {
+		delete(cc.chunkCountByLinkId, entry.linkId)
+		cc.metaCache.Remove(entry.linkId)
+		if err := os.RemoveAll(cc.linkDir(entry.linkId)); err != nil {
+			log.Warn("failed to remove link dir", "error", err, "link_id", entry.linkId)
+		}
+	}
+}
+
+func (cc *ChunkCache) addEntry(entry *chunkEntry) {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	if elem, ok := cc.entryByKey[entry.key()]; ok {
+		cc.lru.MoveToFront(elem)
+		return
+	}
+
+	cc.entryByKey[entry.key()] = cc.lru.PushFront(entry)
+	cc.chunkCountByLinkId[entry.linkId]++
+	cc.size += entry.size
+
+	for cc.size > cc.maxSize {
+		elem := cc.lru.Back()
+		if elem == nil || elem.Value.(*chunkEntry) == entry {
+			break
+		}
+		cc.removeElement(elem)
+	}
+}
+
+func (cc *ChunkCache) removeLink(linkId string) {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	for elem := cc.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*chunkEntry).linkId == linkId {
+			cc.removeElement(elem)
+		}
+		elem = next
+	}
+	cc.metaCache.Remove(linkId)
+	os.RemoveAll(cc.linkDir(linkId))
+}
+
+func (cc *ChunkCache) fetchChunk(upstream *upstreamRequest, linkId string, idx int64, meta *linkMeta) (*fetchedChunk, error) {
+	start := idx * cc.chunkSize
+	end := start + cc.chunkSize - 1
+	if meta != nil {
+		end = min(end, meta.Size-1)
+	}
+
+	req, err := http.NewRequestWithContext(upstream.ctx, http.MethodGet, upstream.link, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range upstream.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept-Encoding", "identity")
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10))
+
+	res, err := upstream.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, errRangeNotSupported
+	default:
+		return nil, upstreamStatusError{statusCode: res.StatusCode}
+	}
+
+	crStart, crEnd, size, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, errRangeNotSupported
+	}
+	if crStart != start {
+		return nil, errors.New("unexpected content range: " + res.Header.Get("Content-Range"))
+	}
+
+	if meta == nil {
+		meta = &linkMeta{Size: size, Header: map[string]string{}}
+		for _, name := range forwardedHeaderNames {
+			if value := res.Header.Get(name); value != "" {
+				meta.Header[name] = value
+			}
+		}
+		if err := cc.saveMeta(linkId, meta); err != nil {
+			log.Warn("failed to save link meta", "error", err, "link_id", linkId)
+		}
+	} else if meta.Size != size {
+		cc.removeLink(linkId)
+		return nil, errors.New("upstream content size changed")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, cc.chunkSize))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != crEnd-crStart+1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	chunkPath := cc.chunkPath(linkId, idx)
+	tmpPath := chunkPath + ".tmp-" + xid.New().String()
+	if err := util.EnsureDir(cc.linkDir(linkId)); err != nil {
+		log.Warn("failed to create link dir", "error", err, "link_id", linkId)
+	} else if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		log.Warn("failed to write chunk", "error", err, "link_id", linkId, "idx", idx)
+		os.Remove(tmpPath)
+	} else if err := os.Rename(tmpPath, chunkPath); err != nil {
+		log.Warn("failed to write chunk", "error", err, "link_id", linkId, "idx", idx)
+		os.Remove(tmpPath)
+	} else {
+		cc.addEntry(&chunkEntry{linkId: linkId, idx: idx, size: int64(len(data))})
+	}
+
+	return &fetchedChunk{data: data, meta: meta}, nil
+}
+
+func (cc *ChunkCache) getChunk(upstream *upstreamRequest, linkId string, idx int64, meta *linkMeta) (*fetchedChunk, error) {
+	if meta != nil {
+		if data, ok := cc.readChunk(linkId, idx); ok {
+			return &fetchedChunk{data: data, meta: meta}, nil
+		}
+	}
+
+	key := linkId + "/" + strconv.FormatInt(idx, 10)
+	fetch := func() (any, error) {
+		if meta != nil {
+			if data, ok := cc.readChunk(linkId, idx); ok {
+				return &fetchedChunk{data: data, meta: meta}, nil
+			}
+		}
+		return cc.fetchChunk(upstream, linkId, idx, meta)
+	}
+	v, err, isShared := cc.fetchGroup.Do(key, fetch)
+	// the fetch may have been started by another client that went away
+	if isShared && errors.Is(err, context.Canceled) && upstream.ctx.Err() == nil {
+		v, err, _ = cc.fetchGroup.Do(key, fetch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.(*fetchedChunk), nil
+}
+
+func (cc *ChunkCache) triggerReadAhead(upstream *upstreamRequest, linkId string, fromIdx int64, meta *linkMeta) {
+	lastIdx := (meta.Size - 1) / cc.chunkSize
+	for idx := fromIdx; idx < fromIdx+int64(cc.readAhead) && idx <= lastIdx; idx++ {
+		if cc.hasChunk(linkId, idx) {
+			continue
+		}
+		select {
+		case cc.readAheadSem <- struct{}{}:
+			go func() {
+				defer func() { <-cc.readAheadSem }()
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(upstream.ctx), readAheadTimeout)
+				defer cancel()
+				readAhead := *upstream
+				readAhead.ctx = ctx
+				if _, err := cc.getChunk(&readAhead, linkId, idx, meta); err != nil {
+					log.Debug("read ahead failed", "error", err, "link_id", linkId, "idx", idx)
+				}
+			}()
+		default:
+			return
+		}
+	}
+}
+
+func (cc *ChunkCache) Serve(w http.ResponseWriter, r *http.Request, link string, tunnelType config.TunnelType) (bytesWritten int64, err error) {
+	br, err := parseRange(r.Header.Get("Range"))
+	if err != nil {
+		return shared.ProxyResponse(w, r, link, tunnelType)
+	}
+
+	upstream := &upstreamRequest{
+		ctx:    r.Context(),
+		link:   link,
+		header: http.Header{},
+		client: shared.GetProxyHttpClient(tunnelType),
+	}
+	shared.CopyRequestHeaders(r.Header, upstream.header)
+	upstream.header.Del("Range")
+	upstream.header.Del("If-Range")
+
+	linkId := getLinkId(link)
+	meta := cc.getMeta(linkId)
+	if meta == nil && br.isSuffix() {
+		return shared.ProxyResponse(w, r, link, tunnelType)
+	}
+
+	firstIdx := int64(0)
+	if br != nil && !br.isSuffix() {
+		firstIdx = br.start / cc.chunkSize
+	}
+
+	// the first chunk is fetched before writing headers, so that
+	// the size is known and failures can fall back to passthrough
+	var first *fetchedChunk
+	if !br.isSuffix() && (meta == nil || firstIdx*cc.chunkSize < meta.Size) {
+		if first, err = cc.getChunk(upstream, linkId, firstIdx, meta); err != nil {
+			log.Debug("falling back to passthrough", "error", err, "link_id", linkId)
+			return shared.ProxyResponse(w, r, link, tunnelType)
+		}
+		meta = first.meta
+	}
+
+	start, end, ok := br.resolve(meta.Size)
+	if !ok {
+		w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(meta.Size, 10))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return 0, nil
+	}
+
+	if startIdx := start / cc.chunkSize; first == nil || startIdx != firstIdx {
+		firstIdx = startIdx
+		if first, err = cc.getChunk(upstream, linkId, firstIdx, meta); err != nil {
+			log.Debug("falling back to passthrough", "error", err, "link_id", linkId)
+			return shared.ProxyResponse(w, r, link, tunnelType)
+		}
+	}
+
+	for name, value := range meta.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	if br != nil {
+		w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10)+"/"+strconv.FormatInt(meta.Size, 10))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	lastIdx := end / cc.chunkSize
+	for idx := firstIdx; idx <= lastIdx; idx++ {
+		chunk := first
+		if idx != firstIdx {
+			if chunk, err = cc.getChunk(upstream, linkId, idx, meta); err != nil {
+				return bytesWritten, err
+			}
+		}
+
+		cc.triggerReadAhead(upstream, linkId, idx+1, meta)
+
+		chunkStart := idx * cc.chunkSize
+		from := max(start, chunkStart) - chunkStart
+		to := min(end-chunkStart+1, int64(len(chunk.data)))
+		if from >= to {
+			return bytesWritten, io.ErrUnexpectedEOF
+		}
+
+		n, err := w.Write(chunk.data[from:to])
+		bytesWritten += int64(n)
+		if err != nil {
+			return bytesWritten, err
+		}
+
+		if r.Context().Err() != nil {
+			return bytesWritten, r.Context().Err()
+		}
+	}
+
+	return bytesWritten, nil
+}
+
+func (cc *ChunkCache) load() error {
+	if err := util.EnsureDir(cc.dir); err != nil {
+		return err
+	}
+
+	type loadedEntry struct {
+		entry   *chunkEntry
+		modTime time.Time
+	}
+	loaded := []loadedEntry{}
+
+	err := filepath.WalkDir(cc.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if strings.Contains(name, ".tmp-") {
+			os.Remove(path)
+			return nil
+		}
+		idxStr, ok := strings.CutSuffix(name, ".chunk")
+		if !ok {
+			return nil
+		}
+		idx, err := strconv.ParseInt(idxStr, 10, 64)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, loadedEntry{
+			entry: &chunkEntry{
+				linkId: filepath.Base(filepath.Dir(path)),
+				idx:    idx,
+				size:   info.Size(),
+			},
+			modTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(loaded, func(a, b loadedEntry) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for i := range loaded {
+		cc.addEntry(loaded[i].entry)
+	}
+
+	return nil
+}
+
+func NewChunkCache(conf *ChunkCacheConfig) (*ChunkCache, error) {
+	cc := &ChunkCache{
+		dir:                conf.Dir,
+		maxSize:            conf.Size,
+		chunkSize:          conf.ChunkSize,
+		readAhead:          conf.ReadAhead,
+		lru:                list.New(),
+		entryByKey:         map[string]*list.Element{},
+		chunkCountByLinkId: map[string]int{},
+		readAheadSem:       make(chan struct{}, max(1, 2*conf.ReadAhead)),
+		metaCache: cache.NewLRUCache[linkMeta](&cache.CacheConfig{
+			Name:          "content_proxy:chunkCache:linkMeta",
+			Lifetime:      6 * time.Hour,
+			LocalCapacity: 1024,
+		}),
+	}
+	if err := cc.load(); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
@@ -0,0 +1,110 @@
+package content_proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkCache(t *testing.T) {
+	content := make([]byte, 2*1024+100)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+
+	var requestCount atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount.Add(1)
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer upstream.Close()
+
+	cc, err := NewChunkCache(&ChunkCacheConfig{
+		Dir:       t.TempDir(),
+		Size:      4 * 1024,
+		ChunkSize: 1024,
+		ReadAhead: 0,
+	})
+	assert.NoError(t, err)
+
+	serve := func(rangeHeader string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v0/proxy/token", nil)
+		if rangeHeader != "" {
+			r.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		_, err := cc.Serve(w, r, upstream.URL, config.TUNNEL_TYPE_NONE)
+		assert.NoError(t, err)
+		return w
+	}
+
+	w := serve("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "video/mp4", w.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(w.Body)
+	assert.Equal(t, content, body)
+	assert.Equal(t, int32(3), requestCount.Load())
+
+	w = serve("bytes=1000-1100")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 1000-1100/2148", w.Header().Get("Content-Range"))
+	body, _ = io.ReadAll(w.Body)
+	assert.Equal(t, content[1000:1101], body)
+
+	w = serve("bytes=-50")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	body, _ = io.ReadAll(w.Body)
+	assert.Equal(t, content[len(content)-50:], body)
+
+	assert.Equal(t, int32(3), requestCount.Load(), "should be served from cache")
+
+	w = serve("bytes=5000-")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */2148", w.Header().Get("Content-Range"))
+
+	reloaded, err := NewChunkCache(&ChunkCacheConfig{
+		Dir:       cc.dir,
+		Size:      4 * 1024,
+		ChunkSize: 1024,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), reloaded.size)
+}
+
+func TestChunkCacheReadAhead(t *testing.T) {
+	content := make([]byte, 3*1024)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer upstream.Close()
+
+	cc, err := NewChunkCache(&ChunkCacheConfig{
+		Dir:       t.TempDir(),
+		Size:      8 * 1024,
+		ChunkSize: 1024,
+		ReadAhead: 2,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/v0/proxy/token", nil)
+	r.Header.Set("Range", "bytes=0-99")
+	_, err = cc.Serve(httptest.NewRecorder(), r, upstream.URL, config.TUNNEL_TYPE_NONE)
+	assert.NoError(t, err)
+	// client goes away once the bounded range is served
+	cancel()
+
+	linkId := getLinkId(upstream.URL)
+	assert.Eventually(t, func() bool {
+		return cc.hasChunk(linkId, 1) && cc.hasChunk(linkId, 2)
+	}, time.Second, 10*time.Millisecond, "read ahead outlives the request")
+}
@@ -0,0 +1,288 @@
+package content_proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/kv"
+	"github.com/MunifTanjim/stremthru/internal/metrics"
+)
+
+var errConnectionTerminated = errors.New("connection terminated")
+
+type Connection struct {
+	Id        string
+	User      string
+	IP        string
+	Link      string
+	StartedAt time.Time
+
+	bytesSent atomic.Int64
+	cancel    context.CancelCauseFunc
+}
+
+func (c *Connection) GetBytesSent() int64 {
+	return c.bytesSent.Load()
+}
+
+func (c *Connection) GetDuration() time.Duration {
+	return time.Since(c.StartedAt)
+}
+
+func (c *Connection) toInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Id:        c.Id,
+		User:      c.User,
+		IP:        c.IP,
+		Link:      c.Link,
+		Instance:  config.InstanceId,
+		StartedAt: c.StartedAt,
+		BytesSent: c.GetBytesSent(),
+	}
+}
+
+// Connection as seen by every instance, shared through the kv store.
+type ConnectionInfo struct {
+	Id        string    `json:"id"`
+	User      string    `json:"user"`
+	IP        string    `json:"ip"`
+	Link      string    `json:"link"`
+	Instance  string    `json:"instance"`
+	StartedAt time.Time `json:"started_at"`
+	// only known for the connections of this instance
+	BytesSent int64 `json:"-"`
+}
+
+func (c *ConnectionInfo) GetDuration() time.Duration {
+	return time.Since(c.StartedAt)
+}
+
+const connectionTerminationPollInterval = 5 * time.Second
+
+// Written once when the connection starts and deleted when it ends, scoped
+// by user for `CountConnections`.
+var connectionStore = kv.NewKVStore[ConnectionInfo](&kv.KVStoreConfig{
+	Type: "cproxyconn",
+})
+
+// Termination requested for connections owned by another instance,
+// picked up by the owner on its next poll.
+var connectionTerminationStore = kv.NewKVStore[bool](&kv.KVStoreConfig{
+	Type:      "cproxy:conn:term",
+	ExpiresIn: 6 * connectionTerminationPollInterval,
+})
+
+var connectionById sync.Map
+
+var isConnectionSyncEnabled atomic.Bool
+
+// Shares the connections of this instance with the others, and terminates
+// the ones requested from other instances. Without it, connections are
+// only tracked in memory.
+func InitConnectionSync() func() {
+	isConnectionSyncEnabled.Store(true)
+
+	ticker := time.NewTicker(connectionTerminationPollInterval)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				pollConnectionTerminations()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(stop)
+	}
+}
+
+func pollConnectionTerminations() {
+	terminations, err := connectionTerminationStore.List()
+	if err != nil {
+		log.Error("failed to list connection terminations", "error", err)
+	}
+	for _, t := range terminations {
+		if value, ok := connectionById.Load(t.Key); ok {
+			terminateConnection(value.(*Connection))
+			if err := connectionTerminationStore.Del(t.Key); err != nil {
+				log.Error("failed to delete connection termination", "error", err, "id", t.Key)
+			}
+		}
+	}
+}
+
+func publishConnection(conn *Connection) {
+	if err := connectionStore.WithScope(conn.User).Set(conn.Id, conn.toInfo()); err != nil {
+		log.Error("failed to record connection", "error", err, "id", conn.Id)
+	}
+}
+
+func unpublishConnection(conn *Connection) {
+	if err := connectionStore.WithScope(conn.User).Del(conn.Id); err != nil {
+		log.Error("failed to delete connection", "error", err, "id", conn.Id)
+	}
+}
+
+// Counts the active connections of the user across every instance.
+func CountConnections(user string) (int, error) {
+	if !isConnectionSyncEnabled.Load() {
+		count := 0
+		connectionById.Range(func(key, value any) bool {
+			if value.(*Connection).User == user {
+				count++
+			}
+			return true
+		})
+		return count, nil
+	}
+	return connectionStore.WithScope(user).Count()
+}
+
+// Tracks the connection till `done` is called, the returned request
+// and response writer must be used for the proxied response.
+func TrackConnection(w http.ResponseWriter, r *http.Request, id, user, ip, link string) (http.ResponseWriter, *http.Request, func()) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	conn := &Connection{
+		Id:        id,
+		User:      user,
+		IP:        ip,
+		Link:      link,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+	}
+	connectionById.Store(id, conn)
+	metrics.IncContentProxyConnections()
+	if isConnectionSyncEnabled.Load() {
+		publishConnection(conn)
+	}
+
+	done := func() {
+		connectionById.Delete(id)
+		if isConnectionSyncEnabled.Load() {
+			unpublishConnection(conn)
+		}
+		metrics.DecContentProxyConnections()
+		cancel(nil)
+	}
+
+	return &connectionResponseWriter{ResponseWriter: w, conn: conn, ctx: ctx}, r.WithContext(ctx), done
+}
+
+func getLocalConnections() []ConnectionInfo {
+	conns := []ConnectionInfo{}
+	connectionById.Range(func(key, value any) bool {
+		conns = append(conns, value.(*Connection).toInfo())
+		return true
+	})
+	return conns
+}
+
+// Lists the connections of every instance, or only of this one when
+// connection sync is not initialized.
+func GetConnections() ([]ConnectionInfo, error) {
+	conns := []ConnectionInfo{}
+	if isConnectionSyncEnabled.Load() {
+		items, err := connectionStore.ListAll()
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			conn := items[i].Value
+			conn.Id = items[i].Key
+			if value, ok := connectionById.Load(conn.Id); ok {
+				conn = value.(*Connection).toInfo()
+			} else if conn.Instance == config.InstanceId {
+				// already closed, record not yet deleted
+				continue
+			}
+			conns = append(conns, conn)
+		}
+	} else {
+		conns = getLocalConnections()
+	}
+	slices.SortFunc(conns, func(a, b ConnectionInfo) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return conns, nil
+}
+
+func terminateConnection(conn *Connection) {
+	log.Info("terminating connection", "id", conn.Id, "user", conn.User, "ip", conn.IP)
+	conn.cancel(errConnectionTerminated)
+}
+
+// Aborts the connection, returns `false` if not found. Connection of
+// another instance is aborted by that instance on its next poll.
+func TerminateConnection(id string) (bool, error) {
+	if value, ok := connectionById.Load(id); ok {
+		terminateConnection(value.(*Connection))
+		return true, nil
+	}
+	if !isConnectionSyncEnabled.Load() {
+		return false, nil
+	}
+
+	items, err := connectionStore.ListAll()
+	if err != nil {
+		return false, err
+	}
+	idx := slices.IndexFunc(items, func(item kv.ParsedKV[ConnectionInfo]) bool {
+		return item.Key == id
+	})
+	if idx == -1 || items[idx].Value.Instance == "" || items[idx].Value.Instance == config.InstanceId {
+		return false, nil
+	}
+	conn := items[idx].Value
+	conn.Id = id
+	log.Info("requesting connection termination", "id", conn.Id, "user", conn.User, "ip", conn.IP, "instance", conn.Instance)
+	if err := connectionTerminationStore.Set(id, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Aborts all the connections of this instance, returns the count
+func TerminateConnections() int {
+	count := 0
+	connectionById.Range(func(key, value any) bool {
+		value.(*Connection).cancel(errConnectionTerminated)
+		count++
+		return true
+	})
+	return count
+}
+
+type connectionResponseWriter struct {
+	http.ResponseWriter
+	conn *Connection
+	ctx  context.Context
+}
+
+func (w *connectionResponseWriter) Write(p []byte) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, context.Cause(w.ctx)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.conn.bytesSent.Add(int64(n))
+	metrics.AddContentProxyBytes(n)
+	return n, err
+}
+
+func (w *connectionResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,40 @@
+package content_proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackConnection(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v0/proxy/token", nil)
+	w, r, done := TrackConnection(httptest.NewRecorder(), r, "conn-1", "user", "1.2.3.4", "https://example.com/video.mp4")
+
+	n, err := w.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	conns, err := GetConnections()
+	assert.NoError(t, err)
+	assert.Len(t, conns, 1)
+	assert.Equal(t, "user", conns[0].User)
+	assert.Equal(t, int64(5), conns[0].BytesSent)
+
+	ok, err := TerminateConnection("conn-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Error(t, r.Context().Err())
+
+	_, err = w.Write([]byte("world"))
+	assert.ErrorIs(t, err, errConnectionTerminated)
+
+	done()
+	conns, err = GetConnections()
+	assert.NoError(t, err)
+	assert.Len(t, conns, 0)
+	ok, err = TerminateConnection("conn-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,35 @@
+package content_proxy
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+)
+
+var getChunkCache = sync.OnceValue(func() *ChunkCache {
+	if !config.ContentProxy.Cache.IsEnabled() {
+		return nil
+	}
+	cc, err := NewChunkCache(&ChunkCacheConfig{
+		Dir:       config.ContentProxy.Cache.Dir,
+		Size:      config.ContentProxy.Cache.Size,
+		ChunkSize: config.ContentProxy.Cache.ChunkSize,
+		ReadAhead: config.ContentProxy.Cache.ReadAhead,
+	})
+	if err != nil {
+		log.Error("failed to initialize chunk cache, disabling", "error", err)
+		return nil
+	}
+	return cc
+})
+
+func ProxyResponse(w http.ResponseWriter, r *http.Request, link string, tunnelType config.TunnelType) (bytesWritten int64, err error) {
+	if shared.IsMethod(r, http.MethodGet) {
+		if cc := getChunkCache(); cc != nil {
+			return cc.Serve(w, r, link, tunnelType)
+		}
+	}
+	return shared.ProxyResponse(w, r, link, tunnelType)
+}
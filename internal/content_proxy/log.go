@@ -0,0 +1,5 @@
+package content_proxy
+
+import "github.com/MunifTanjim/stremthru/internal/logger"
+
+var log = logger.Scoped("content_proxy")
@@ -0,0 +1,98 @@
+package content_proxy
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errUnsupportedRange = errors.New("unsupported range")
+
+// single `bytes` range, `end` is inclusive and `-1` when open-ended
+type byteRange struct {
+	start  int64
+	end    int64
+	suffix int64
+}
+
+func parseRange(header string) (*byteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, errUnsupportedRange
+	}
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, errUnsupportedRange
+	}
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix <= 0 {
+			return nil, errUnsupportedRange
+		}
+		return &byteRange{start: -1, end: -1, suffix: suffix}, nil
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return nil, errUnsupportedRange
+	}
+	br := &byteRange{start: start, end: -1}
+	if endStr != "" {
+		end, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return nil, errUnsupportedRange
+		}
+		br.end = end
+	}
+	return br, nil
+}
+
+func (br *byteRange) isSuffix() bool {
+	return br != nil && br.suffix > 0
+}
+
+// resolves the range against the content size, `ok` is false if unsatisfiable
+func (br *byteRange) resolve(size int64) (start, end int64, ok bool) {
+	if br == nil {
+		return 0, size - 1, size > 0
+	}
+	if br.isSuffix() {
+		return max(0, size-br.suffix), size - 1, size > 0
+	}
+	if br.start >= size {
+		return 0, 0, false
+	}
+	end = size - 1
+	if br.end != -1 && br.end < end {
+		end = br.end
+	}
+	return br.start, end, true
+}
+
+// parses `bytes start-end/size` from `Content-Range` header
+func parseContentRange(header string) (start, end, size int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, 0, errors.New("invalid content range: " + header)
+	}
+	rangeStr, sizeStr, ok := strings.Cut(spec, "/")
+	if !ok || sizeStr == "*" {
+		return 0, 0, 0, errors.New("invalid content range: " + header)
+	}
+	startStr, endStr, ok := strings.Cut(rangeStr, "-")
+	if !ok {
+		return 0, 0, 0, errors.New("invalid content range: " + header)
+	}
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, 0, err
+	}
+	if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+		return 0, 0, 0, err
+	}
+	if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+		return 0, 0, 0, err
+	}
+	return start, end, size, nil
+}
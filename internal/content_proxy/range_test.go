@@ -0,0 +1,59 @@
+package content_proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRange(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		size   int64
+		start  int64
+		end    int64
+		ok     bool
+		err    bool
+	}{
+		{"", 100, 0, 99, true, false},
+		{"bytes=0-", 100, 0, 99, true, false},
+		{"bytes=10-19", 100, 10, 19, true, false},
+		{"bytes=90-200", 100, 90, 99, true, false},
+		{"bytes=-10", 100, 90, 99, true, false},
+		{"bytes=-200", 100, 0, 99, true, false},
+		{"bytes=100-", 100, 0, 0, false, false},
+		{"bytes=0-1,5-6", 100, 0, 0, false, true},
+		{"bytes=20-10", 100, 0, 0, false, true},
+		{"items=0-10", 100, 0, 0, false, true},
+		{"bytes=-0", 100, 0, 0, false, true},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			br, err := parseRange(tc.header)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			start, end, ok := br.resolve(tc.size)
+			assert.Equal(t, tc.ok, ok)
+			if ok {
+				assert.Equal(t, tc.start, start)
+				assert.Equal(t, tc.end, end)
+			}
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, end, size, err := parseContentRange("bytes 0-99/1000")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, int64(99), end)
+	assert.Equal(t, int64(1000), size)
+
+	_, _, _, err = parseContentRange("bytes 0-99/*")
+	assert.Error(t, err)
+
+	_, _, _, err = parseContentRange("0-99/1000")
+	assert.Error(t, err)
+}
@@ -19,11 +19,20 @@ type StoreContext struct {
 	ProxyAuthPassword string
 	ClientIP          string // optional
 
+	// For the store calls to be traced under the request
+	Context context.Context
+
 	Log *logger.Logger
 }
 
+// Request context without its cancellation, as store calls may be shared
+// with other requests or outlive it.
+func DetachContext(r *http.Request) context.Context {
+	return context.WithoutCancel(r.Context())
+}
+
 func SetStoreContext(r *http.Request) *http.Request {
-	ctx := context.WithValue(r.Context(), storeContextKey{}, &StoreContext{})
+	ctx := context.WithValue(r.Context(), storeContextKey{}, &StoreContext{Context: DetachContext(r)})
 	return r.WithContext(ctx)
 }
 
@@ -0,0 +1,249 @@
+package dash_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/audit_log"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+// Responses larger than this are not used for the diff.
+const auditLogMaxBodySize = 256 * 1024
+
+type auditLogResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *auditLogResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditLogResponseWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if w.body.Len()+len(p) <= auditLogMaxBodySize {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *auditLogResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type auditLogStateWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *auditLogStateWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *auditLogStateWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+}
+
+func (w *auditLogStateWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// Reads the current state of the target with a `GET` on the same path.
+func getAuditLogState(router http.Handler, r *http.Request) any {
+	req := r.Clone(r.Context())
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+
+	ctx := GetReqCtx(r)
+	prevError := ctx.Error
+	defer func() {
+		ctx.Error = prevError
+	}()
+
+	w := &auditLogStateWriter{header: http.Header{}}
+	router.ServeHTTP(w, req)
+	if w.statusCode != http.StatusOK || w.body.Len() > auditLogMaxBodySize {
+		return nil
+	}
+	return audit_log.ParseResponseData(w.body.Bytes())
+}
+
+func isAuditedMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Records mutating requests from signed in users in the audit log.
+func WithAuditLog(router *http.ServeMux) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := GetReqCtx(r)
+		if !isAuditedMethod(r.Method) || !ctx.IsAuthed() || strings.HasPrefix(r.URL.Path, "/auth/") {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		var before any
+		if r.Method != http.MethodPost {
+			before = getAuditLogState(router, r)
+		}
+
+		var request any
+		if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+			body, err := io.ReadAll(io.LimitReader(r.Body, auditLogMaxBodySize+1))
+			if err == nil && len(body) <= auditLogMaxBodySize {
+				if err := json.Unmarshal(body, &request); err != nil {
+					request = nil
+				}
+			}
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		rw := &auditLogResponseWriter{ResponseWriter: w}
+		router.ServeHTTP(rw, r)
+
+		item := &audit_log.AuditLog{
+			Actor:  ctx.Session.User,
+			Action: r.Method + " " + r.Pattern,
+			Target: r.URL.Path,
+			Status: rw.statusCode,
+			IP:     ctx.ClientIP,
+		}
+		if r.Pattern == "" {
+			item.Action = r.Method
+		}
+		if 200 <= rw.statusCode && rw.statusCode < 300 {
+			var after any
+			if r.Method != http.MethodDelete && rw.body.Len() < auditLogMaxBodySize {
+				after = audit_log.ParseResponseData(rw.body.Bytes())
+				after = audit_log.MergeRequest(after, request)
+			}
+			item.Changes = audit_log.Diff(before, after)
+		}
+
+		if err := audit_log.Insert(item); err != nil {
+			ctx.Log.Error("failed to insert audit log", "error", err, "action", item.Action, "target", item.Target)
+		}
+	})
+}
+
+type AuditLogResponse struct {
+	Id        string             `json:"id"`
+	Actor     string             `json:"actor"`
+	Action    string             `json:"action"`
+	Target    string             `json:"target"`
+	Status    int                `json:"status"`
+	IP        string             `json:"ip"`
+	Changes   []audit_log.Change `json:"changes"`
+	CreatedAt string             `json:"created_at"`
+}
+
+type ListAuditLogResponse struct {
+	Items      []AuditLogResponse `json:"items"`
+	TotalCount int                `json:"total_count"`
+}
+
+func toAuditLogResponse(item *audit_log.AuditLog) AuditLogResponse {
+	changes := item.Changes
+	if changes == nil {
+		changes = audit_log.Changes{}
+	}
+	return AuditLogResponse{
+		Id:        item.Id,
+		Actor:     item.Actor,
+		Action:    item.Action,
+		Target:    item.Target,
+		Status:    item.Status,
+		IP:        item.IP,
+		Changes:   changes,
+		CreatedAt: item.CAt.Format(time.RFC3339),
+	}
+}
+
+func handleGetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+
+	params := audit_log.GetItemsParams{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+		Limit:  min(util.SafeParseInt(query.Get("limit"), 50), 500),
+		Offset: util.SafeParseInt(query.Get("offset"), 0),
+	}
+
+	errs := []Error{}
+	if since := query.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err != nil {
+			errs = append(errs, Error{
+				Location: "since",
+				Message:  "invalid timestamp format (e.g., 2006-01-02T15:04:05Z)",
+			})
+		} else {
+			params.Since = t
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		if t, err := time.Parse(time.RFC3339, until); err != nil {
+			errs = append(errs, Error{
+				Location: "until",
+				Message:  "invalid timestamp format (e.g., 2006-01-02T15:04:05Z)",
+			})
+		} else {
+			params.Until = t
+		}
+	}
+	if len(errs) > 0 {
+		ErrorBadRequest(r, "").Append(errs...).Send(w, r)
+		return
+	}
+
+	items, err := audit_log.GetItems(params)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	totalCount, err := audit_log.CountItems(params)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := ListAuditLogResponse{
+		Items:      make([]AuditLogResponse, len(items)),
+		TotalCount: totalCount,
+	}
+	for i := range items {
+		data.Items[i] = toAuditLogResponse(&items[i])
+	}
+
+	SendData(w, r, 200, data)
+}
+
+func AddAuditLogEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/audit-logs", authed(handleGetAuditLogs))
+}
@@ -0,0 +1,106 @@
+package dash_api
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+)
+
+type CacheStatsResponse struct {
+	Name          string `json:"name"`
+	Backend       string `json:"backend"`
+	Lifetime      string `json:"lifetime"`
+	LocalCapacity uint32 `json:"local_capacity"`
+	LocalSize     int    `json:"local_size"`
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+}
+
+func toCacheStatsResponse(stats *cache.Stats) CacheStatsResponse {
+	return CacheStatsResponse{
+		Name:          stats.Name,
+		Backend:       stats.Backend,
+		Lifetime:      stats.Lifetime.String(),
+		LocalCapacity: stats.LocalCapacity,
+		LocalSize:     stats.LocalSize,
+		Hits:          stats.Hits,
+		Misses:        stats.Misses,
+		Evictions:     stats.Evictions,
+	}
+}
+
+func handleGetCaches(w http.ResponseWriter, r *http.Request) {
+	items := cache.GetAllStats()
+
+	data := make([]CacheStatsResponse, len(items))
+	for i := range items {
+		data[i] = toCacheStatsResponse(&items[i])
+	}
+
+	SendData(w, r, 200, data)
+}
+
+func handleGetCache(w http.ResponseWriter, r *http.Request) {
+	stats, ok := cache.GetStats(r.PathValue("name"))
+	if !ok {
+		ErrorNotFound(r, "cache not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 200, toCacheStatsResponse(stats))
+}
+
+func handleFlushCache(w http.ResponseWriter, r *http.Request) {
+	found, err := cache.Flush(r.PathValue("name"))
+	if !found {
+		ErrorNotFound(r, "cache not found").Send(w, r)
+		return
+	}
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+func handleRemoveCacheKey(w http.ResponseWriter, r *http.Request) {
+	if !cache.RemoveKey(r.PathValue("name"), r.PathValue("key")) {
+		ErrorNotFound(r, "cache not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+func AddCacheEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/caches", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetCaches(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/caches/{name}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetCache(w, r)
+		case http.MethodDelete:
+			handleFlushCache(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/caches/{name}/keys/{key...}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			handleRemoveCacheKey(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+}
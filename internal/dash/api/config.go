@@ -0,0 +1,53 @@
+package dash_api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/auth_user"
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+type ReloadConfigResponse struct {
+	ReloadedAt string   `json:"reloaded_at"`
+	Warnings   []string `json:"warnings"`
+}
+
+func handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := GetReqCtx(r)
+
+	warnings, err := config.Reload()
+	if err != nil {
+		ctx.Log.Warn("failed to reload config", "error", err)
+		ErrorBadRequest(r, "failed to reload config: "+err.Error()).Send(w, r)
+		return
+	}
+
+	if err := auth_user.Sync(); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	ctx.Log.Info("config reloaded", "warnings", warnings)
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+	SendData(w, r, 200, ReloadConfigResponse{
+		ReloadedAt: time.Now().Format(time.RFC3339),
+		Warnings:   warnings,
+	})
+}
+
+func AddConfigEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/config/reload", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handleReloadConfig(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+}
@@ -96,6 +96,15 @@ func ErrorForbidden(r *http.Request, msg string) *APIError {
 	return err
 }
 
+func ErrorConflict(r *http.Request, msg string) *APIError {
+	if msg == "" {
+		msg = "Conflict"
+	}
+	err := NewAPIError(http.StatusConflict, msg)
+	err.InjectRequest(r)
+	return err
+}
+
 func ErrorLocked(r *http.Request, msg string) *APIError {
 	if msg == "" {
 		msg = "Locked"
@@ -0,0 +1,121 @@
+package dash_api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/content_proxy"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+)
+
+type ProxyConnectionResponse struct {
+	Id        string `json:"id"`
+	User      string `json:"user"`
+	IP        string `json:"ip"`
+	Link      string `json:"link"`
+	Instance  string `json:"instance"`
+	BytesSent int64  `json:"bytes_sent"`
+	Duration  int64  `json:"duration"` // in seconds
+	StartedAt string `json:"started_at"`
+}
+
+func handleGetProxyConnections(w http.ResponseWriter, r *http.Request) {
+	conns, err := content_proxy.GetConnections()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := make([]ProxyConnectionResponse, len(conns))
+	for i, conn := range conns {
+		data[i] = ProxyConnectionResponse{
+			Id:        conn.Id,
+			User:      conn.User,
+			IP:        conn.IP,
+			Link:      conn.Link,
+			Instance:  conn.Instance,
+			BytesSent: conn.BytesSent,
+			Duration:  int64(conn.GetDuration().Seconds()),
+			StartedAt: conn.StartedAt.Format(time.RFC3339),
+		}
+	}
+
+	SendData(w, r, 200, data)
+}
+
+func handleTerminateProxyConnection(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ok, err := content_proxy.TerminateConnection(id)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !ok {
+		ErrorNotFound(r, "connection not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+type RevokeProxyLinksRequest struct {
+	User string `json:"user"`
+}
+
+func handleRevokeProxyLinks(w http.ResponseWriter, r *http.Request) {
+	request := &RevokeProxyLinksRequest{}
+	if err := ReadRequestBodyJSON(r, request); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if request.User == "" {
+		ErrorBadRequest(r, "").Append(Error{
+			Location: "user",
+			Message:  "missing user",
+		}).Send(w, r)
+		return
+	}
+	if config.ProxyAuthPassword.GetPassword(request.User) == "" {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	if err := shared.RevokeProxyLinks(request.User); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+func AddProxyEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/proxy/connections", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetProxyConnections(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/proxy/connections/{id}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			handleTerminateProxyConnection(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/proxy/links/revoke", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handleRevokeProxyLinks(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+}
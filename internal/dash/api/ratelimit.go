@@ -197,6 +197,111 @@ func handleDeleteRateLimitConfig(w http.ResponseWriter, r *http.Request) {
 	SendData(w, r, 204, nil)
 }
 
+type RateLimitRouteResponse struct {
+	Route             string `json:"route"`
+	KeyBy             string `json:"key_by"`
+	RateLimitConfigId string `json:"rate_limit_config_id"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+func toRateLimitRouteResponse(item *ratelimit.RouteConfig) RateLimitRouteResponse {
+	return RateLimitRouteResponse{
+		Route:             string(item.Route),
+		KeyBy:             string(item.KeyBy),
+		RateLimitConfigId: item.RateLimitConfigId,
+		CreatedAt:         item.CAt.Format(time.RFC3339),
+		UpdatedAt:         item.UAt.Format(time.RFC3339),
+	}
+}
+
+func handleGetRateLimitRoutes(w http.ResponseWriter, r *http.Request) {
+	items, err := ratelimit.GetAllRoutes()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := make([]RateLimitRouteResponse, len(items))
+	for i := range items {
+		data[i] = toRateLimitRouteResponse(&items[i])
+	}
+
+	SendData(w, r, 200, data)
+}
+
+type SetRateLimitRouteRequest struct {
+	KeyBy             string `json:"key_by"`
+	RateLimitConfigId string `json:"rate_limit_config_id"`
+}
+
+func handleSetRateLimitRoute(w http.ResponseWriter, r *http.Request) {
+	route := ratelimit.Route(r.PathValue("route"))
+	if !route.IsValid() {
+		ErrorNotFound(r, "route not found").Send(w, r)
+		return
+	}
+
+	request := &SetRateLimitRouteRequest{}
+	if err := ReadRequestBodyJSON(r, request); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	errs := []Error{}
+	if !ratelimit.KeyBy(request.KeyBy).IsValid() {
+		errs = append(errs, Error{
+			Location: "key_by",
+			Message:  "key_by must be one of: user, ip, userdata",
+		})
+	}
+	if request.RateLimitConfigId == "" {
+		errs = append(errs, Error{
+			Location: "rate_limit_config_id",
+			Message:  "missing rate_limit_config_id",
+		})
+	} else if rlc, err := ratelimit.GetById(request.RateLimitConfigId); err != nil {
+		SendError(w, r, err)
+		return
+	} else if rlc == nil {
+		errs = append(errs, Error{
+			Location: "rate_limit_config_id",
+			Message:  "rate limit config not found",
+		})
+	}
+	if len(errs) > 0 {
+		ErrorBadRequest(r, "").Append(errs...).Send(w, r)
+		return
+	}
+
+	item, err := ratelimit.UpsertRoute(route, ratelimit.KeyBy(request.KeyBy), request.RateLimitConfigId)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 200, toRateLimitRouteResponse(item))
+}
+
+func handleDeleteRateLimitRoute(w http.ResponseWriter, r *http.Request) {
+	route := ratelimit.Route(r.PathValue("route"))
+
+	if existing, err := ratelimit.GetRoute(route); err != nil {
+		SendError(w, r, err)
+		return
+	} else if existing == nil {
+		ErrorNotFound(r, "rate limit route not found").Send(w, r)
+		return
+	}
+
+	if err := ratelimit.DeleteRoute(route); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
 func AddRateLimitEndpoints(router *http.ServeMux) {
 	authed := EnsureAuthed
 
@@ -220,4 +325,22 @@ func AddRateLimitEndpoints(router *http.ServeMux) {
 			ErrorMethodNotAllowed(r).Send(w, r)
 		}
 	}))
+	router.HandleFunc("/ratelimit/routes", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetRateLimitRoutes(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/ratelimit/routes/{route}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			handleSetRateLimitRoute(w, r)
+		case http.MethodDelete:
+			handleDeleteRateLimitRoute(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
 }
@@ -0,0 +1,466 @@
+package dash_api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/auth_user"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+type UserStoreTokenRequest struct {
+	Store string `json:"store"`
+	Token string `json:"token"`
+}
+
+type UserResponse struct {
+	Name            string   `json:"name"`
+	Disabled        bool     `json:"disabled"`
+	Stores          []string `json:"stores"`
+	ConnectionLimit *int     `json:"connection_limit"`
+	Features        []string `json:"features"`
+	CreatedAt       string   `json:"created_at"`
+	UpdatedAt       string   `json:"updated_at"`
+}
+
+func toUserResponse(item *auth_user.AuthUser) (*UserResponse, error) {
+	tokens, err := item.GetStoreTokens()
+	if err != nil {
+		return nil, err
+	}
+	stores := make([]string, len(tokens))
+	for i := range tokens {
+		stores[i] = tokens[i].Store
+	}
+
+	var connectionLimit *int
+	if item.ConnectionLimit.Valid {
+		limit := int(item.ConnectionLimit.Int64)
+		connectionLimit = &limit
+	}
+
+	return &UserResponse{
+		Name:            item.Name,
+		Disabled:        item.Disabled,
+		Stores:          stores,
+		ConnectionLimit: connectionLimit,
+		Features:        item.Features,
+		CreatedAt:       item.CAt.Format(time.RFC3339),
+		UpdatedAt:       item.UAt.Format(time.RFC3339),
+	}, nil
+}
+
+func validateUserStoreTokens(tokens []UserStoreTokenRequest) []Error {
+	errs := []Error{}
+	for i := range tokens {
+		if !store.StoreName(tokens[i].Store).IsValid() {
+			errs = append(errs, Error{
+				Location: fmt.Sprintf("store_tokens[%d].store", i),
+				Message:  "invalid store",
+			})
+		}
+		if tokens[i].Token == "" {
+			errs = append(errs, Error{
+				Location: fmt.Sprintf("store_tokens[%d].token", i),
+				Message:  "missing token",
+			})
+		}
+	}
+	return errs
+}
+
+func validateUserFeatures(features []string) []Error {
+	errs := []Error{}
+	for i, name := range features {
+		if !config.IsValidFeature(name) {
+			errs = append(errs, Error{
+				Location: fmt.Sprintf("features[%d]", i),
+				Message:  "invalid feature",
+			})
+		}
+	}
+	return errs
+}
+
+func toStoreTokens(tokens []UserStoreTokenRequest) []auth_user.StoreToken {
+	result := make([]auth_user.StoreToken, len(tokens))
+	for i := range tokens {
+		result[i] = auth_user.StoreToken{
+			Store: tokens[i].Store,
+			Token: tokens[i].Token,
+		}
+	}
+	return result
+}
+
+func handleGetUsers(w http.ResponseWriter, r *http.Request) {
+	items, err := auth_user.GetAll()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := make([]UserResponse, len(items))
+	for i := range items {
+		res, err := toUserResponse(&items[i])
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		data[i] = *res
+	}
+
+	SendData(w, r, 200, data)
+}
+
+type CreateUserRequest struct {
+	Name            string                  `json:"name"`
+	Password        string                  `json:"password"`
+	StoreTokens     []UserStoreTokenRequest `json:"store_tokens"`
+	ConnectionLimit *int                    `json:"connection_limit"`
+	Features        []string                `json:"features"`
+}
+
+func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	request := &CreateUserRequest{}
+	if err := ReadRequestBodyJSON(r, request); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	errs := []Error{}
+	if request.Name == "" {
+		errs = append(errs, Error{
+			Location: "name",
+			Message:  "missing name",
+		})
+	} else if request.Name == "*" || strings.ContainsAny(request.Name, ":, ") {
+		errs = append(errs, Error{
+			Location: "name",
+			Message:  "invalid name",
+		})
+	}
+	if request.Password == "" {
+		errs = append(errs, Error{
+			Location: "password",
+			Message:  "missing password",
+		})
+	}
+	if request.ConnectionLimit != nil && *request.ConnectionLimit < 0 {
+		errs = append(errs, Error{
+			Location: "connection_limit",
+			Message:  "connection_limit must not be negative",
+		})
+	}
+	errs = append(errs, validateUserStoreTokens(request.StoreTokens)...)
+	errs = append(errs, validateUserFeatures(request.Features)...)
+	if len(errs) > 0 {
+		ErrorBadRequest(r, "").Append(errs...).Send(w, r)
+		return
+	}
+
+	if existing, err := auth_user.GetByName(request.Name); err != nil {
+		SendError(w, r, err)
+		return
+	} else if existing != nil || config.ProxyAuthPassword.GetPassword(request.Name) != "" {
+		ErrorBadRequest(r, "").Append(Error{
+			Location: "name",
+			Message:  "user already exists",
+		}).Send(w, r)
+		return
+	}
+
+	user, err := auth_user.NewAuthUser(request.Name, request.Password)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if err := user.SetStoreTokens(toStoreTokens(request.StoreTokens)); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if request.ConnectionLimit != nil {
+		user.ConnectionLimit.Int64 = int64(*request.ConnectionLimit)
+		user.ConnectionLimit.Valid = true
+	}
+	if len(request.Features) > 0 {
+		user.Features = db.CommaSeperatedString(request.Features)
+	}
+
+	if err := user.Upsert(); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	res, err := toUserResponse(user)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendData(w, r, 201, res)
+}
+
+func handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := auth_user.GetByName(r.PathValue("name"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if user == nil {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	res, err := toUserResponse(user)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendData(w, r, 200, res)
+}
+
+type UpdateUserRequest struct {
+	Password    *string                  `json:"password"`
+	Disabled    *bool                    `json:"disabled"`
+	StoreTokens *[]UserStoreTokenRequest `json:"store_tokens"`
+	// -1 resets to the default limit
+	ConnectionLimit *int      `json:"connection_limit"`
+	Features        *[]string `json:"features"`
+}
+
+func handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	request := &UpdateUserRequest{}
+	if err := ReadRequestBodyJSON(r, request); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	errs := []Error{}
+	if request.Password != nil && *request.Password == "" {
+		errs = append(errs, Error{
+			Location: "password",
+			Message:  "missing password",
+		})
+	}
+	if request.ConnectionLimit != nil && *request.ConnectionLimit < -1 {
+		errs = append(errs, Error{
+			Location: "connection_limit",
+			Message:  "connection_limit must be -1 or more",
+		})
+	}
+	if request.StoreTokens != nil {
+		errs = append(errs, validateUserStoreTokens(*request.StoreTokens)...)
+	}
+	if request.Features != nil {
+		errs = append(errs, validateUserFeatures(*request.Features)...)
+	}
+	if len(errs) > 0 {
+		ErrorBadRequest(r, "").Append(errs...).Send(w, r)
+		return
+	}
+
+	user, err := auth_user.GetByName(r.PathValue("name"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if user == nil {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	if request.Password != nil {
+		if err := user.SetPassword(*request.Password); err != nil {
+			SendError(w, r, err)
+			return
+		}
+	}
+	if request.Disabled != nil {
+		user.Disabled = *request.Disabled
+	}
+	if request.StoreTokens != nil {
+		if err := user.SetStoreTokens(toStoreTokens(*request.StoreTokens)); err != nil {
+			SendError(w, r, err)
+			return
+		}
+	}
+	if request.ConnectionLimit != nil {
+		user.ConnectionLimit.Int64 = int64(*request.ConnectionLimit)
+		user.ConnectionLimit.Valid = *request.ConnectionLimit >= 0
+	}
+	if request.Features != nil {
+		user.Features = db.CommaSeperatedString(*request.Features)
+	}
+
+	if err := user.Upsert(); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	res, err := toUserResponse(user)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendData(w, r, 200, res)
+}
+
+func handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	existing, err := auth_user.GetByName(name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if existing == nil {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	if err := auth_user.Delete(name); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+type UserAPIKeyResponse struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Key       string `json:"key,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toUserAPIKeyResponse(item *auth_user.APIKey) UserAPIKeyResponse {
+	return UserAPIKeyResponse{
+		Id:        item.Id,
+		Name:      item.Name,
+		CreatedAt: item.CAt.Format(time.RFC3339),
+	}
+}
+
+func handleGetUserAPIKeys(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	user, err := auth_user.GetByName(name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if user == nil {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	items, err := auth_user.GetAPIKeysByUserName(name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := make([]UserAPIKeyResponse, len(items))
+	for i := range items {
+		data[i] = toUserAPIKeyResponse(&items[i])
+	}
+
+	SendData(w, r, 200, data)
+}
+
+type CreateUserAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+func handleCreateUserAPIKey(w http.ResponseWriter, r *http.Request) {
+	request := &CreateUserAPIKeyRequest{}
+	if err := ReadRequestBodyJSON(r, request); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	name := r.PathValue("name")
+
+	user, err := auth_user.GetByName(name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if user == nil {
+		ErrorNotFound(r, "user not found").Send(w, r)
+		return
+	}
+
+	item, key, err := auth_user.CreateAPIKey(name, request.Name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	res := toUserAPIKeyResponse(item)
+	res.Key = key
+	SendData(w, r, 201, res)
+}
+
+func handleRevokeUserAPIKey(w http.ResponseWriter, r *http.Request) {
+	revoked, err := auth_user.RevokeAPIKey(r.PathValue("name"), r.PathValue("id"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !revoked {
+		ErrorNotFound(r, "api key not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+func AddUserEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/users", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetUsers(w, r)
+		case http.MethodPost:
+			handleCreateUser(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/users/{name}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetUser(w, r)
+		case http.MethodPatch:
+			handleUpdateUser(w, r)
+		case http.MethodDelete:
+			handleDeleteUser(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/users/{name}/api-keys", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetUserAPIKeys(w, r)
+		case http.MethodPost:
+			handleCreateUserAPIKey(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/users/{name}/api-keys/{id}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			handleRevokeUserAPIKey(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+}
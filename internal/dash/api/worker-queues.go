@@ -0,0 +1,128 @@
+package dash_api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/job_log"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
+)
+
+type DeadLetterResponse struct {
+	Queue     string          `json:"queue"`
+	Key       string          `json:"key"`
+	GroupKey  string          `json:"group_key,omitempty"`
+	Value     json.RawMessage `json:"value"`
+	Attempts  int             `json:"attempts"`
+	Error     string          `json:"error"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func toDeadLetterResponse(item *job_log.ParsedJobLog[worker_queue.DeadLetter]) DeadLetterResponse {
+	res := DeadLetterResponse{
+		Key:       item.Id,
+		Error:     item.Error,
+		CreatedAt: item.CreatedAt,
+	}
+	if item.Data != nil {
+		res.Queue = item.Data.Queue
+		res.GroupKey = item.Data.GroupKey
+		res.Value = item.Data.GetRedactedValue()
+		res.Attempts = item.Data.Attempts
+	}
+	return res
+}
+
+func handleGetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	items, err := worker_queue.GetDeadLetters()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	queue := r.URL.Query().Get("queue")
+	data := make([]DeadLetterResponse, 0, len(items))
+	for i := range items {
+		item := toDeadLetterResponse(&items[i])
+		if queue != "" && item.Queue != queue {
+			continue
+		}
+		data = append(data, item)
+	}
+
+	SendData(w, r, 200, data)
+}
+
+func handleGetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	item, err := worker_queue.GetDeadLetter(r.PathValue("queue"), r.PathValue("key"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if item == nil {
+		ErrorNotFound(r, "dead letter not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 200, toDeadLetterResponse(item))
+}
+
+func handleDeleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	found, err := worker_queue.DeleteDeadLetter(r.PathValue("queue"), r.PathValue("key"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !found {
+		ErrorNotFound(r, "dead letter not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 204, nil)
+}
+
+func handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	found, err := worker_queue.ReplayDeadLetter(r.PathValue("queue"), r.PathValue("key"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !found {
+		ErrorNotFound(r, "dead letter not found").Send(w, r)
+		return
+	}
+
+	SendData(w, r, 202, nil)
+}
+
+func AddWorkerQueueEndpoints(router *http.ServeMux) {
+	authed := EnsureAuthed
+
+	router.HandleFunc("/worker-queues/dead-letters", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetDeadLetters(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/worker-queues/{queue}/dead-letters/{key}", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetDeadLetter(w, r)
+		case http.MethodDelete:
+			handleDeleteDeadLetter(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+	router.HandleFunc("/worker-queues/{queue}/dead-letters/{key}/replay", authed(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handleReplayDeadLetter(w, r)
+		default:
+			ErrorMethodNotAllowed(r).Send(w, r)
+		}
+	}))
+}
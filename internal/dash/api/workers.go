@@ -1,7 +1,10 @@
 package dash_api
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"net/http"
 	"path/filepath"
@@ -21,7 +24,11 @@ type WorkerDetails struct {
 	Id           string        `json:"id"`
 	Title        string        `json:"title"`
 	Interval     time.Duration `json:"interval"`
+	Schedule     string        `json:"schedule"`
+	Cancellable  bool          `json:"cancellable"`
+	NextRunAt    *time.Time    `json:"next_run_at"`
 	HasFailedJob bool          `json:"has_failed_job"`
+	IsPaused     bool          `json:"is_paused"`
 }
 
 func handleGetWorkersDetails(w http.ResponseWriter, r *http.Request) {
@@ -34,9 +41,14 @@ func handleGetWorkersDetails(w http.ResponseWriter, r *http.Request) {
 
 	for name, details := range worker.WorkerDetailsById {
 		data[name] = &WorkerDetails{
-			Id:       details.Id,
-			Title:    details.Title,
-			Interval: details.Interval,
+			Id:          details.Id,
+			Title:       details.Title,
+			Interval:    details.Interval,
+			Schedule:    details.Schedule,
+			Cancellable: details.Cancellable,
+		}
+		if nextRunAt := worker.GetNextRunAt(name); !nextRunAt.IsZero() {
+			data[name].NextRunAt = &nextRunAt
 		}
 	}
 
@@ -52,6 +64,18 @@ func handleGetWorkersDetails(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	states, err := worker.GetStates()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	for workerName, state := range states {
+		if workerResp, ok := data[workerName]; ok {
+			workerResp.IsPaused = state.Paused
+		}
+	}
+
 	SendData(w, r, 200, data)
 }
 
@@ -245,6 +269,177 @@ func handleWorkerTemporaryFiles(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type WorkerStateResponse struct {
+	Id       string `json:"id"`
+	IsPaused bool   `json:"is_paused"`
+}
+
+func handleWorkerAction(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodPost) {
+		ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	name := r.PathValue("id")
+	if _, ok := worker.WorkerDetailsById[name]; !ok {
+		ErrorBadRequest(r, "invalid worker id").Send(w, r)
+		return
+	}
+
+	var state *worker.WorkerState
+	var err error
+	switch action := r.PathValue("action"); action {
+	case "run":
+		err = worker.Run(name)
+	case "cancel":
+		err = worker.Cancel(name)
+	case "pause":
+		state, err = worker.SetPaused(name, true)
+	case "resume":
+		state, err = worker.SetPaused(name, false)
+	default:
+		ErrorNotFound(r, "unknown action: "+action).Send(w, r)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, worker.ErrWorkerNotScheduled), errors.Is(err, worker.ErrWorkerNotCancellable):
+			ErrorBadRequest(r, err.Error()).Send(w, r)
+		case errors.Is(err, worker.ErrWorkerRunning), errors.Is(err, worker.ErrWorkerNotRunning):
+			ErrorConflict(r, err.Error()).Send(w, r)
+		default:
+			SendError(w, r, err)
+		}
+		return
+	}
+
+	if state == nil {
+		SendData(w, r, 202, nil)
+		return
+	}
+
+	SendData(w, r, 200, WorkerStateResponse{
+		Id:       name,
+		IsPaused: state.Paused,
+	})
+}
+
+type WorkerProgressResponse struct {
+	JobId     string              `json:"job_id"`
+	Status    string              `json:"status"`
+	Error     string              `json:"error,omitempty"`
+	Progress  *worker.JobProgress `json:"progress"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+func getWorkerProgress(name string) (*WorkerProgressResponse, error) {
+	job, err := worker.GetLastJob(name)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	return &WorkerProgressResponse{
+		JobId:     job.Id,
+		Status:    job.Status,
+		Error:     job.Error,
+		Progress:  job.Data,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}, nil
+}
+
+func handleGetWorkerProgress(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	name := r.PathValue("id")
+	if _, ok := worker.WorkerDetailsById[name]; !ok {
+		ErrorBadRequest(r, "invalid worker id").Send(w, r)
+		return
+	}
+
+	data, err := getWorkerProgress(name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendData(w, r, 200, data)
+}
+
+const (
+	workerProgressStreamInterval     = 1 * time.Second
+	workerProgressStreamPingInterval = 15 * time.Second
+)
+
+// Server-Sent Events stream of the last job of the worker. A `progress`
+// event is sent whenever it changes, `data` being same as the response
+// of the non-streaming endpoint.
+func handleStreamWorkerProgress(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	name := r.PathValue("id")
+	if _, ok := worker.WorkerDetailsById[name]; !ok {
+		ErrorBadRequest(r, "invalid worker id").Send(w, r)
+		return
+	}
+
+	ctx := GetReqCtx(r)
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	if err := rc.Flush(); err != nil {
+		ctx.Log.Error("failed to flush worker progress stream", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(workerProgressStreamInterval)
+	defer ticker.Stop()
+
+	var lastSent []byte
+	lastWriteAt := time.Time{}
+	for {
+		data, err := getWorkerProgress(name)
+		if err != nil {
+			ctx.Log.Error("failed to get worker progress", "error", err, "worker", name)
+		} else if payload, err := json.Marshal(data); err != nil {
+			ctx.Log.Error("failed to encode worker progress", "error", err, "worker", name)
+		} else if !bytes.Equal(payload, lastSent) {
+			if _, err := fmt.Fprintf(w, "event: progress\ndata: %s\n\n", payload); err != nil {
+				return
+			}
+			lastSent = payload
+			lastWriteAt = time.Now()
+		}
+
+		if util.HasDurationPassedSince(lastWriteAt, workerProgressStreamPingInterval) {
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			lastWriteAt = time.Now()
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func AddWorkerEndpoints(router *http.ServeMux) {
 	authed := EnsureAuthed
 
@@ -252,4 +447,7 @@ func AddWorkerEndpoints(router *http.ServeMux) {
 	router.HandleFunc("/workers/{id}/job-logs", authed(handleWorkerJobLogs))
 	router.HandleFunc("/workers/{id}/job-logs/{jobId}", authed(handleWorkerJobLog))
 	router.HandleFunc("/workers/{id}/temporary-files", authed(handleWorkerTemporaryFiles))
+	router.HandleFunc("/workers/{id}/progress", authed(handleGetWorkerProgress))
+	router.HandleFunc("/workers/{id}/progress/stream", authed(handleStreamWorkerProgress))
+	router.HandleFunc("/workers/{id}/{action}", authed(handleWorkerAction))
 }
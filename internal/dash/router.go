@@ -34,10 +34,16 @@ func AddEndpoints(mux *http.ServeMux) {
 
 	dash_api.AddIMDBEndpoints(router)
 	dash_api.AddWorkerEndpoints(router)
+	dash_api.AddWorkerQueueEndpoints(router)
 	dash_api.AddTorznabIndexerSyncInfoEndpoints(router)
 	dash_api.AddRateLimitEndpoints(router)
+	dash_api.AddProxyEndpoints(router)
+	dash_api.AddConfigEndpoints(router)
+	dash_api.AddAuditLogEndpoints(router)
+	dash_api.AddCacheEndpoints(router)
 
 	if config.Feature.HasVault() {
+		dash_api.AddUserEndpoints(router)
 		dash_api.AddVaultStremioEndpoints(router)
 		dash_api.AddVaultTraktEndpoints(router)
 		dash_api.AddVaultTorznabEndpoints(router)
@@ -47,7 +53,7 @@ func AddEndpoints(mux *http.ServeMux) {
 		}
 	}
 
-	mux.Handle("/dash/api/", http.StripPrefix("/dash/api", dash_api.WithMiddleware(commonMiddleware)(router.ServeHTTP)))
+	mux.Handle("/dash/api/", http.StripPrefix("/dash/api", dash_api.WithMiddleware(commonMiddleware)(dash_api.WithAuditLog(router))))
 
 	switch config.Environment {
 	case config.EnvDev:
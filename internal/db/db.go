@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/url"
@@ -26,6 +27,10 @@ var FnJSONGroupArray string
 var FnJSONObject string
 var NewAdvisoryLock func(names ...string) AdvisoryLock
 
+func sqliteDSNModifier(u *url.URL, q *url.Values) {
+	u.Scheme = "file"
+}
+
 var connUri, dsnModifiers = func() (ConnectionURI, []DSNModifier) {
 	uri, err := ParseConnectionURI(config.DatabaseURI)
 	if err != nil {
@@ -44,9 +49,7 @@ var connUri, dsnModifiers = func() (ConnectionURI, []DSNModifier) {
 		FnJSONObject = "json_object"
 		NewAdvisoryLock = sqliteNewAdvisoryLock
 
-		dsnModifiers = append(dsnModifiers, func(u *url.URL, q *url.Values) {
-			u.Scheme = "file"
-		})
+		dsnModifiers = append(dsnModifiers, sqliteDSNModifier)
 	case DBDialectPostgres:
 		BooleanFalse = "false"
 		BooleanTrue = "true"
@@ -95,12 +98,44 @@ var getExec = func(db Executor) dbExec {
 
 var Exec = getExec(db)
 
+type dbExecContext func(ctx context.Context, query string, args ...any) (sql.Result, error)
+
+// Same as `getExec`, but stops retrying once `ctx` is done
+var getExecContext = func(db *DB) dbExecContext {
+	if Dialect == DBDialectPostgres {
+		return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+			return db.ExecContext(ctx, adaptQuery(query), args...)
+		}
+	}
+
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		retryLeft := 2
+		r, err := db.ExecContext(ctx, query, args...)
+		for err != nil && retryLeft > 0 {
+			if e, ok := err.(sqlite3.Error); ok && e.Code == sqlite3.ErrBusy {
+				select {
+				case <-ctx.Done():
+					return r, err
+				case <-time.After(2 * time.Second):
+				}
+				r, err = db.ExecContext(ctx, query, args...)
+				retryLeft--
+			} else {
+				retryLeft = 0
+			}
+		}
+		return r, err
+	}
+}
+
+var execContext = getExecContext(db)
+
 func Query(query string, args ...any) (*sql.Rows, error) {
-	return db.Query(adaptQuery(query), args...)
+	return QueryContext(context.Background(), query, args...)
 }
 
 func QueryRow(query string, args ...any) *sql.Row {
-	return db.QueryRow(adaptQuery(query), args...)
+	return QueryRowContext(context.Background(), query, args...)
 }
 
 type dbExecutor struct{}
@@ -168,6 +203,15 @@ func Ping() {
 	}
 }
 
+// Same as `Ping`, but returns the error instead of exiting.
+func PingContext(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	one := 0
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
 func Open() *DB {
 	database, err := sql.Open(connUri.DriverName, connUri.DSN(dsnModifiers...))
 	if err != nil {
@@ -181,6 +225,16 @@ func Open() *DB {
 	return db
 }
 
+// Opens a database other than the configured one. Helpers in this package
+// are not usable with it, queries need to be written for its dialect.
+func OpenURI(uri ConnectionURI) (*sql.DB, error) {
+	mods := []DSNModifier{}
+	if uri.Dialect == DBDialectSQLite {
+		mods = append(mods, sqliteDSNModifier)
+	}
+	return sql.Open(uri.DriverName, uri.DSN(mods...))
+}
+
 func Close() error {
 	return db.Close()
 }
@@ -0,0 +1,131 @@
+package db_copy
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+type columnKind int
+
+const (
+	columnKindOther columnKind = iota
+	columnKindTime
+	columnKindDate
+	columnKindBool
+	columnKindJSON
+	columnKindBytes
+)
+
+type column struct {
+	name      string
+	kind      columnKind
+	generated bool
+	serial    bool
+}
+
+type conn struct {
+	*sql.DB
+	dialect db.DBDialect
+}
+
+func (c *conn) adaptQuery(query string) string {
+	if c.dialect != db.DBDialectPostgres {
+		return query
+	}
+
+	var q strings.Builder
+	pos := 1
+	for _, char := range query {
+		if char == '?' {
+			q.WriteRune('$')
+			q.WriteString(strconv.Itoa(pos))
+			pos++
+		} else {
+			q.WriteRune(char)
+		}
+	}
+	return q.String()
+}
+
+func (c *conn) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := c.Query(c.adaptQuery(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// Regular tables, without the virtual and shadow tables of sqlite.
+func (c *conn) listTables() ([]string, error) {
+	if c.dialect == db.DBDialectPostgres {
+		return c.queryStrings(`SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+	}
+	return c.queryStrings(`SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+}
+
+func toColumnKind(dataType string) columnKind {
+	switch strings.ToLower(dataType) {
+	case "timestamp with time zone", "timestamp without time zone", "timestamp", "datetime":
+		return columnKindTime
+	case "date":
+		return columnKindDate
+	case "boolean", "bool":
+		return columnKindBool
+	case "json", "jsonb":
+		return columnKindJSON
+	case "bytea", "blob":
+		return columnKindBytes
+	default:
+		return columnKindOther
+	}
+}
+
+func (c *conn) listColumns(table string) ([]column, error) {
+	query := `SELECT name, type, hidden IN (2, 3), 0 FROM pragma_table_xinfo(?) ORDER BY cid`
+	if c.dialect == db.DBDialectPostgres {
+		query = `SELECT column_name, data_type, is_generated = 'ALWAYS', COALESCE(column_default, '') LIKE 'nextval(%' FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? ORDER BY ordinal_position`
+	}
+	rows, err := c.Query(c.adaptQuery(query), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []column{}
+	for rows.Next() {
+		col := column{}
+		dataType := ""
+		if err := rows.Scan(&col.name, &dataType, &col.generated, &col.serial); err != nil {
+			return nil, err
+		}
+		col.kind = toColumnKind(dataType)
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+func (c *conn) listPrimaryKey(table string) ([]string, error) {
+	if c.dialect == db.DBDialectPostgres {
+		return c.queryStrings(`SELECT kcu.column_name FROM information_schema.table_constraints tc JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema AND kcu.table_name = tc.table_name WHERE tc.table_schema = current_schema() AND tc.table_name = ? AND tc.constraint_type = 'PRIMARY KEY' ORDER BY kcu.ordinal_position`, table)
+	}
+	return c.queryStrings(`SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+}
+
+func (c *conn) count(table string) (int64, error) {
+	var count int64
+	err := c.QueryRow(`SELECT COUNT(*) FROM "` + table + `"`).Scan(&count)
+	return count, err
+}
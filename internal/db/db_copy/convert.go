@@ -0,0 +1,90 @@
+package db_copy
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case int64:
+		return time.Unix(v, 0).UTC(), true
+	case string:
+		if v == "" {
+			return time.Time{}, true
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Converts a value read from the source to what the target column of
+// `kind` expects, same as the types in `db` would write it.
+func convertValue(value any, kind columnKind, dialect db.DBDialect) any {
+	switch kind {
+	case columnKindTime, columnKindDate:
+		t, ok := toTime(value)
+		if !ok {
+			return value
+		}
+		if t.IsZero() {
+			return nil
+		}
+		if kind == columnKindDate {
+			if dialect == db.DBDialectSQLite {
+				return t.Format(time.DateOnly)
+			}
+			return t
+		}
+		if dialect == db.DBDialectSQLite {
+			return t.Unix()
+		}
+		return t.UTC()
+	case columnKindBool:
+		if v, ok := value.(int64); ok {
+			return v != 0
+		}
+		return value
+	case columnKindBytes:
+		return value
+	}
+
+	// sqlite would store bytes as blob, e.g. json from postgres
+	if v, ok := value.([]byte); ok {
+		return string(v)
+	}
+	return value
+}
+
+// Converts a key read from the source so that it can be compared with
+// the column again, after being saved as JSON.
+func normalizeKey(value any, dialect db.DBDialect) any {
+	switch v := value.(type) {
+	case time.Time:
+		if dialect == db.DBDialectSQLite {
+			return v.Unix()
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+		return v.String()
+	}
+	return value
+}
@@ -0,0 +1,36 @@
+package db_copy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertValue(t *testing.T) {
+	ts := time.Date(2026, time.January, 14, 10, 30, 15, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name    string
+		value   any
+		kind    columnKind
+		dialect db.DBDialect
+		result  any
+	}{
+		{"time to sqlite", ts, columnKindTime, db.DBDialectSQLite, ts.Unix()},
+		{"unix to postgres", ts.Unix(), columnKindTime, db.DBDialectPostgres, ts},
+		{"text time to postgres", "2026-01-14 10:30:15", columnKindTime, db.DBDialectPostgres, ts},
+		{"zero time", time.Time{}, columnKindTime, db.DBDialectPostgres, nil},
+		{"null time", nil, columnKindTime, db.DBDialectPostgres, nil},
+		{"date to sqlite", ts, columnKindDate, db.DBDialectSQLite, "2026-01-14"},
+		{"empty date", time.Time{}, columnKindDate, db.DBDialectPostgres, nil},
+		{"int to bool", int64(1), columnKindBool, db.DBDialectPostgres, true},
+		{"bool to sqlite", false, columnKindBool, db.DBDialectSQLite, false},
+		{"json bytes to sqlite", []byte(`{"a":1}`), columnKindJSON, db.DBDialectSQLite, `{"a":1}`},
+		{"bytes", []byte("a"), columnKindBytes, db.DBDialectSQLite, []byte("a")},
+		{"text", "a", columnKindOther, db.DBDialectPostgres, "a"},
+	} {
+		assert.Equal(t, tc.result, convertValue(tc.value, tc.kind, tc.dialect), tc.name)
+	}
+}
@@ -0,0 +1,366 @@
+package db_copy
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+// Both sqlite (32766) and postgres (65535) allow at least these many
+// parameters in a query.
+const maxQueryParams = 30000
+
+type Config struct {
+	From       db.ConnectionURI
+	To         db.ConnectionURI
+	BatchSize  int
+	SkipTables []string
+	// Progress is saved here after each batch.
+	StatePath string
+}
+
+type TableCount struct {
+	Table  string
+	Source int64
+	Target int64
+}
+
+func (tc TableCount) IsMatch() bool {
+	return tc.Source == tc.Target
+}
+
+type tableSpec struct {
+	name    string
+	columns []column
+	pk      []string
+}
+
+type copier struct {
+	source    *conn
+	target    *conn
+	batchSize int
+	state     *state
+}
+
+func open(uri db.ConnectionURI) (*conn, error) {
+	database, err := db.OpenURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Ping(); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return &conn{DB: database, dialect: uri.Dialect}, nil
+}
+
+// Copies the rows of every table present in both databases, in batches
+// ordered by primary key. Rows are upserted and progress is saved after
+// each batch, so an interrupted copy resumes where it stopped. Both
+// databases should be migrated to the same schema version, and the source
+// should not be written to meanwhile.
+func Copy(conf *Config) ([]TableCount, error) {
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 5000
+	}
+
+	source, err := open(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source: %w", err)
+	}
+	defer source.Close()
+
+	target, err := open(conf.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open target: %w", err)
+	}
+	defer target.Close()
+
+	s, err := loadState(conf.StatePath, conf.From.Redacted(), conf.To.Redacted())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &copier{
+		source:    source,
+		target:    target,
+		batchSize: conf.BatchSize,
+		state:     s,
+	}
+
+	tables, err := c.prepareTables(conf.SkipTables)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tables {
+		if err := c.copyTable(&tables[i]); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", tables[i].name, err)
+		}
+	}
+
+	if err := c.finalize(tables); err != nil {
+		return nil, err
+	}
+
+	counts := make([]TableCount, 0, len(tables))
+	isMatch := true
+	for i := range tables {
+		tc := TableCount{Table: tables[i].name}
+		if tc.Source, err = source.count(tc.Table); err != nil {
+			return nil, err
+		}
+		if tc.Target, err = target.count(tc.Table); err != nil {
+			return nil, err
+		}
+		isMatch = isMatch && tc.IsMatch()
+		counts = append(counts, tc)
+	}
+
+	if isMatch {
+		if err := s.remove(); err != nil {
+			log.Warn("failed to remove state file", "error", err, "path", s.path)
+		}
+	}
+
+	return counts, nil
+}
+
+func (c *copier) prepareTables(skipTables []string) ([]tableSpec, error) {
+	sourceTables, err := c.source.listTables()
+	if err != nil {
+		return nil, err
+	}
+	targetTables, err := c.target.listTables()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []tableSpec{}
+	for _, name := range sourceTables {
+		if slices.Contains(skipTables, name) {
+			continue
+		}
+		if !slices.Contains(targetTables, name) {
+			log.Warn("table missing in target, skipping", "table", name)
+			continue
+		}
+
+		sourceColumns, err := c.source.listColumns(name)
+		if err != nil {
+			return nil, err
+		}
+		targetColumns, err := c.target.listColumns(name)
+		if err != nil {
+			return nil, err
+		}
+
+		t := tableSpec{name: name}
+		for _, col := range targetColumns {
+			if col.generated {
+				continue
+			}
+			if slices.ContainsFunc(sourceColumns, func(sc column) bool {
+				return sc.name == col.name
+			}) {
+				t.columns = append(t.columns, col)
+			}
+		}
+		for _, col := range sourceColumns {
+			if !slices.ContainsFunc(t.columns, func(tc column) bool {
+				return tc.name == col.name
+			}) && !col.generated {
+				log.Warn("column missing in target, skipping", "table", name, "column", col.name)
+			}
+		}
+
+		if t.pk, err = c.target.listPrimaryKey(name); err != nil {
+			return nil, err
+		}
+		if len(t.pk) == 0 {
+			return nil, errors.New("missing primary key in table: " + name)
+		}
+		for _, key := range t.pk {
+			if !slices.ContainsFunc(t.columns, func(col column) bool {
+				return col.name == key
+			}) {
+				return nil, fmt.Errorf("primary key column %s missing in source table: %s", key, name)
+			}
+		}
+
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func (c *copier) copyTable(t *tableSpec) error {
+	ts := c.state.get(t.name)
+	if ts.Done {
+		log.Info("already copied, skipping", "table", t.name, "count", ts.Copied)
+		return nil
+	}
+
+	columnNames := make([]string, len(t.columns))
+	for i := range t.columns {
+		columnNames[i] = t.columns[i].name
+	}
+	keyIndices := make([]int, len(t.pk))
+	for i, key := range t.pk {
+		keyIndices[i] = slices.Index(columnNames, key)
+	}
+
+	selectColumns := db.JoinColumnNames(columnNames...)
+	orderColumns := db.JoinColumnNames(t.pk...)
+	query := fmt.Sprintf(`SELECT %s FROM "%s" ORDER BY %s LIMIT %d`, selectColumns, t.name, orderColumns, c.batchSize)
+	queryAfter := fmt.Sprintf(`SELECT %s FROM "%s" WHERE (%s) > (%s) ORDER BY %s LIMIT %d`, selectColumns, t.name, orderColumns, util.RepeatJoin("?", len(t.pk), ","), orderColumns, c.batchSize)
+
+	rowsPerInsert := max(1, min(c.batchSize, maxQueryParams/len(columnNames)))
+
+	log.Info("copying", "table", t.name, "resumed_at", ts.Copied)
+	for {
+		var items [][]any
+		var err error
+		if len(ts.After) == 0 {
+			items, err = c.readBatch(query)
+		} else {
+			items, err = c.readBatch(c.source.adaptQuery(queryAfter), ts.After...)
+		}
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		lastItem := items[len(items)-1]
+		after := make([]any, len(keyIndices))
+		for i, idx := range keyIndices {
+			after[i] = normalizeKey(lastItem[idx], c.source.dialect)
+		}
+
+		for _, item := range items {
+			for i := range item {
+				item[i] = convertValue(item[i], t.columns[i].kind, c.target.dialect)
+			}
+		}
+
+		if err := c.writeBatch(t, columnNames, items, rowsPerInsert); err != nil {
+			return err
+		}
+
+		ts.After = after
+		ts.Copied += int64(len(items))
+		if err := c.state.save(); err != nil {
+			return err
+		}
+		log.Info("copied rows", "table", t.name, "count", ts.Copied)
+
+		if len(items) < c.batchSize {
+			break
+		}
+	}
+
+	ts.Done = true
+	return c.state.save()
+}
+
+func (c *copier) readBatch(query string, args ...any) ([][]any, error) {
+	rows, err := c.source.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	items := [][]any{}
+	for rows.Next() {
+		item := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range item {
+			dest[i] = &item[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (c *copier) writeBatch(t *tableSpec, columnNames []string, items [][]any, rowsPerInsert int) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO "` + t.name + `" (` + db.JoinColumnNames(columnNames...) + `) VALUES `)
+	prefix := query.String()
+
+	onConflict := ` ON CONFLICT (` + db.JoinColumnNames(t.pk...) + `) DO `
+	updates := []string{}
+	for _, name := range columnNames {
+		if !slices.Contains(t.pk, name) {
+			updates = append(updates, `"`+name+`" = EXCLUDED."`+name+`"`)
+		}
+	}
+	if len(updates) == 0 {
+		onConflict += `NOTHING`
+	} else {
+		onConflict += `UPDATE SET ` + strings.Join(updates, ", ")
+	}
+
+	placeholder := "(" + util.RepeatJoin("?", len(columnNames), ",") + ")"
+
+	tx, err := c.target.Begin()
+	if err != nil {
+		return err
+	}
+	for chunk := range slices.Chunk(items, rowsPerInsert) {
+		args := make([]any, 0, len(chunk)*len(columnNames))
+		for _, item := range chunk {
+			args = append(args, item...)
+		}
+		q := prefix + util.RepeatJoin(placeholder, len(chunk), ",") + onConflict
+		if _, err := tx.Exec(c.target.adaptQuery(q), args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Brings the target in sync with the copied rows, where inserting them
+// is not enough.
+func (c *copier) finalize(tables []tableSpec) error {
+	switch c.target.dialect {
+	case db.DBDialectPostgres:
+		for i := range tables {
+			t := &tables[i]
+			for _, col := range t.columns {
+				if !col.serial {
+					continue
+				}
+				query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX("%s"), 0) + 1, false) FROM "%s"`, col.name, t.name)
+				if _, err := c.target.Exec(query, `"`+t.name+`"`, col.name); err != nil {
+					return fmt.Errorf("failed to reset sequence for %s.%s: %w", t.name, col.name, err)
+				}
+			}
+		}
+	case db.DBDialectSQLite:
+		ftsTables, err := c.target.queryStrings(`SELECT name FROM sqlite_master WHERE type = 'table' AND sql LIKE 'CREATE VIRTUAL TABLE%USING fts5%'`)
+		if err != nil {
+			return err
+		}
+		for _, name := range ftsTables {
+			log.Info("rebuilding fts", "table", name)
+			if _, err := c.target.Exec(fmt.Sprintf(`INSERT INTO "%s"("%s") VALUES('rebuild')`, name, name)); err != nil {
+				return fmt.Errorf("failed to rebuild fts for %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package db_copy
+
+import "github.com/MunifTanjim/stremthru/internal/logger"
+
+var log = logger.Scoped("db_copy")
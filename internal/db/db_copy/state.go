@@ -0,0 +1,90 @@
+package db_copy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type tableState struct {
+	After  []any `json:"after,omitempty"`
+	Copied int64 `json:"copied"`
+	Done   bool  `json:"done"`
+}
+
+type state struct {
+	From   string                 `json:"from"`
+	To     string                 `json:"to"`
+	Tables map[string]*tableState `json:"tables"`
+
+	path string
+}
+
+func loadState(path, from, to string) (*state, error) {
+	s := &state{From: from, To: to, Tables: map[string]*tableState{}, path: path}
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	saved := state{}
+	decoder := json.NewDecoder(bytes.NewReader(blob))
+	decoder.UseNumber()
+	if err := decoder.Decode(&saved); err != nil {
+		return nil, errors.New("invalid state file " + path + ": " + err.Error())
+	}
+	if saved.From != from || saved.To != to {
+		return nil, errors.New("state file " + path + " belongs to a copy from " + saved.From + " to " + saved.To)
+	}
+	for table, ts := range saved.Tables {
+		if ts == nil {
+			continue
+		}
+		for i := range ts.After {
+			ts.After[i] = normalizeKey(ts.After[i], "")
+		}
+		s.Tables[table] = ts
+	}
+	return s, nil
+}
+
+func (s *state) get(table string) *tableState {
+	ts, ok := s.Tables[table]
+	if !ok {
+		ts = &tableState{}
+		s.Tables[table] = ts
+	}
+	return ts
+}
+
+func (s *state) save() error {
+	blob, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := util.EnsureDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, blob, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+func (s *state) remove() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package db
+
+import "fmt"
+
+var schemaVersionTableName string
+var expectedSchemaVersion int64
+
+// Records the migration table and the latest known migration, for
+// `GetSchemaVersion`.
+func SetExpectedSchemaVersion(tableName string, version int64) {
+	schemaVersionTableName = tableName
+	expectedSchemaVersion = version
+}
+
+// Returns the latest applied migration, and the one this build expects.
+func GetSchemaVersion() (current int64, expected int64, err error) {
+	if schemaVersionTableName == "" {
+		return 0, 0, fmt.Errorf("schema version is not known")
+	}
+	row := QueryRow(fmt.Sprintf(`SELECT COALESCE(MAX(version_id), 0) FROM %s WHERE is_applied = %s`, schemaVersionTableName, BooleanTrue))
+	if err := row.Scan(&current); err != nil {
+		return 0, expectedSchemaVersion, err
+	}
+	return current, expectedSchemaVersion, nil
+}
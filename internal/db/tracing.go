@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		// skip preparing the attributes, span is not started anyway
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+	return tracing.StartChild(ctx, "db "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", string(Dialect)),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", query),
+	))
+}
+
+// Same as `Exec`, cancelled with `ctx` and traced as a child span of it
+func ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := execContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+// Same as `Query`, cancelled with `ctx` and traced as a child span of it
+func QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := db.QueryContext(ctx, adaptQuery(query), args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+// Same as `QueryRow`, cancelled with `ctx` and traced as a child span of it
+func QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := db.QueryRowContext(ctx, adaptQuery(query), args...)
+	tracing.End(span, row.Err())
+	return row
+}
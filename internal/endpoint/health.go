@@ -130,5 +130,6 @@ func handleHealthDebug(w http.ResponseWriter, r *http.Request) {
 
 func AddHealthEndpoints(mux *http.ServeMux) {
 	mux.HandleFunc("/v0/health", handleHealth)
+	mux.HandleFunc("/v0/health/ready", handleHealthReady)
 	mux.HandleFunc("/v0/health/__debug__", StoreMiddleware(ProxyAuthContext, StoreContext)(handleHealthDebug))
 }
@@ -0,0 +1,285 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/buddy"
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/redis"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/worker"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+const readyCheckTimeout = 5 * time.Second
+
+type ReadyStatus string
+
+const (
+	ReadyStatusOk       ReadyStatus = "ok"
+	ReadyStatusDegraded ReadyStatus = "degraded"
+	ReadyStatusFail     ReadyStatus = "fail"
+)
+
+type ReadyComponent struct {
+	Status ReadyStatus `json:"status"`
+	// Failing critical components make the instance not ready.
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+type ReadyData struct {
+	Status     ReadyStatus                `json:"status"`
+	Time       string                     `json:"time"`
+	Components map[string]*ReadyComponent `json:"components"`
+}
+
+type readyCheck struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) (details any, err error)
+}
+
+func (c readyCheck) exec() *ReadyComponent {
+	ctx, cancel := context.WithTimeout(context.Background(), readyCheckTimeout)
+	defer cancel()
+
+	type result struct {
+		details any
+		err     error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		details, err := c.run(ctx)
+		done <- result{details, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = errors.New("timed out")
+	}
+
+	component := &ReadyComponent{
+		Status:    ReadyStatusOk,
+		Critical:  c.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Details:   res.details,
+	}
+	if res.err != nil {
+		component.Status = ReadyStatusFail
+		component.Error = res.err.Error()
+	}
+	return component
+}
+
+func checkReadyDatabase(ctx context.Context) (any, error) {
+	return nil, db.PingContext(ctx)
+}
+
+type readySchemaDetails struct {
+	Current  int64 `json:"current"`
+	Expected int64 `json:"expected"`
+}
+
+func checkReadySchema(ctx context.Context) (any, error) {
+	current, expected, err := db.GetSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	details := readySchemaDetails{Current: current, Expected: expected}
+	if current < expected {
+		return details, errors.New("pending migrations")
+	}
+	return details, nil
+}
+
+func checkReadyRedis(ctx context.Context) (any, error) {
+	return nil, redis.GetClient().Ping(ctx).Err()
+}
+
+func checkReadyUpstream(baseURL string) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/v0/health", nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := buddy.DefaultHTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status: %d", res.StatusCode)
+		}
+		return nil, nil
+	}
+}
+
+type readyStoreResult struct {
+	Err string
+}
+
+var readyStoreCache = cache.NewLRUCache[readyStoreResult](&cache.CacheConfig{
+	Lifetime:      5 * time.Minute,
+	Name:          "health_ready_store",
+	LocalCapacity: 256,
+})
+
+func checkReadyStoreToken(ctx context.Context, storeName, token string) error {
+	hash := sha256.Sum256([]byte(token))
+	cacheKey := storeName + ":" + hex.EncodeToString(hash[:])
+
+	result := readyStoreResult{}
+	if !readyStoreCache.Get(cacheKey, &result) {
+		params := &store.GetUserParams{}
+		params.Context = ctx
+		params.APIKey = token
+		if _, err := shared.GetStore(storeName).GetUser(params); err != nil {
+			if ctx.Err() != nil {
+				// timed out, not a result of the store
+				return err
+			}
+			result.Err = err.Error()
+		}
+		readyStoreCache.Add(cacheKey, result)
+	}
+	if result.Err != "" {
+		return errors.New(result.Err)
+	}
+	return nil
+}
+
+type readyStoreDetails struct {
+	Tokens  int `json:"tokens"`
+	Failing int `json:"failing"`
+}
+
+func checkReadyStore(storeName string, tokens []string) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		details := readyStoreDetails{Tokens: len(tokens)}
+		errs := []error{}
+		for _, token := range tokens {
+			if err := checkReadyStoreToken(ctx, storeName, token); err != nil {
+				details.Failing++
+				errs = append(errs, err)
+			}
+		}
+		return details, errors.Join(errs...)
+	}
+}
+
+type readyWorkerDetails struct {
+	Running []string `json:"running"`
+	Stale   []string `json:"stale,omitempty"`
+}
+
+func checkReadyWorkers(ctx context.Context) (any, error) {
+	details := readyWorkerDetails{Running: []string{}}
+	for _, w := range worker.GetRunningWorkerHealth() {
+		details.Running = append(details.Running, w.Id)
+		if w.IsStale {
+			details.Stale = append(details.Stale, w.Id)
+		}
+	}
+	slices.Sort(details.Running)
+	slices.Sort(details.Stale)
+	if len(details.Stale) > 0 {
+		return details, errors.New("heartbeat overdue: " + strings.Join(details.Stale, ", "))
+	}
+	return details, nil
+}
+
+func getReadyChecks() []readyCheck {
+	checks := []readyCheck{
+		{name: "database", critical: true, run: checkReadyDatabase},
+		{name: "database_schema", critical: true, run: checkReadySchema},
+	}
+	if redis.IsAvailable() {
+		checks = append(checks, readyCheck{name: "redis", critical: true, run: checkReadyRedis})
+	}
+	if config.HasBuddy {
+		checks = append(checks, readyCheck{name: "buddy", run: checkReadyUpstream(config.BuddyURL)})
+	}
+	if config.HasPeer {
+		checks = append(checks, readyCheck{name: "peer", run: checkReadyUpstream(config.PeerURL)})
+	}
+	tokensByStore := map[string][]string{}
+	for _, tokenByStore := range config.StoreAuthToken() {
+		for storeName, token := range tokenByStore {
+			if storeName == "*" || token == "" || slices.Contains(tokensByStore[storeName], token) {
+				continue
+			}
+			tokensByStore[storeName] = append(tokensByStore[storeName], token)
+		}
+	}
+	for storeName, tokens := range tokensByStore {
+		checks = append(checks, readyCheck{
+			name: "store:" + storeName,
+			run:  checkReadyStore(storeName, tokens),
+		})
+	}
+	checks = append(checks, readyCheck{name: "workers", run: checkReadyWorkers})
+	return checks
+}
+
+func handleHealthReady(w http.ResponseWriter, r *http.Request) {
+	isAuthorized, _, _ := getProxyAuthorization(r, false)
+	checks := getReadyChecks()
+
+	data := &ReadyData{
+		Status:     ReadyStatusOk,
+		Time:       time.Now().Format(time.RFC3339),
+		Components: make(map[string]*ReadyComponent, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Go(func() {
+			component := check.exec()
+			mu.Lock()
+			defer mu.Unlock()
+			data.Components[check.name] = component
+		})
+	}
+	wg.Wait()
+
+	for _, component := range data.Components {
+		// errors can carry upstream details, only for authorized callers
+		if !isAuthorized {
+			component.Error = ""
+			component.Details = nil
+		}
+		if component.Status == ReadyStatusOk {
+			continue
+		}
+		if component.Critical {
+			data.Status = ReadyStatusFail
+		} else if data.Status == ReadyStatusOk {
+			data.Status = ReadyStatusDegraded
+		}
+	}
+
+	statusCode := http.StatusOK
+	if data.Status == ReadyStatusFail {
+		statusCode = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	SendResponse(w, r, statusCode, data, nil)
+}
@@ -0,0 +1,22 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/metrics"
+	"github.com/MunifTanjim/stremthru/internal/server"
+)
+
+func AddMetricsEndpoints(mux *http.ServeMux) {
+	if !metrics.IsEnabled {
+		return
+	}
+
+	handler := metrics.Handler()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		ctx := server.GetReqCtx(r)
+		ctx.NoRequestLog = true
+
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/auth_user"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/ratelimit"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	"github.com/MunifTanjim/stremthru/store"
@@ -33,12 +35,35 @@ func extractProxyAuthToken(r *http.Request, readQuery bool) (token string, hasTo
 	if token == "" && readQuery {
 		token = r.URL.Query().Get("token")
 	}
-	token = strings.TrimPrefix(token, "Basic ")
+	if t, ok := strings.CutPrefix(token, "Bearer "); ok {
+		token = t
+	} else {
+		token = strings.TrimPrefix(token, "Basic ")
+	}
 	return token, token != ""
 }
 
+func getAPIKeyAuthorization(r *http.Request, key string) (isAuthorized bool, user, pass string) {
+	user, err := auth_user.GetUserNameByAPIKey(key)
+	if err != nil {
+		server.GetReqCtx(r).Log.Error("failed to lookup api key", "error", err)
+		return false, "", ""
+	}
+	if user == "" {
+		return false, "", ""
+	}
+	pass = config.ProxyAuthPassword.GetPassword(user)
+	if pass == "" {
+		return false, "", ""
+	}
+	return true, user, pass
+}
+
 func getProxyAuthorization(r *http.Request, readQuery bool) (isAuthorized bool, user, pass string) {
 	token, hasToken := extractProxyAuthToken(r, readQuery)
+	if auth_user.IsAPIKey(token) {
+		return getAPIKeyAuthorization(r, token)
+	}
 	auth, err := core.ParseBasicAuth(token)
 	isAuthorized = hasToken && err == nil && config.ProxyAuthPassword.GetPassword(auth.Username) == auth.Password
 	user = auth.Username
@@ -54,6 +79,29 @@ func ProxyAuthContext(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+// Expects `ProxyAuthContext` to run before it.
+func RateLimited(route ratelimit.Route) shared.MiddlewareFunc {
+	return rateLimited(route, func(w http.ResponseWriter, r *http.Request) {
+		shared.ErrorTooManyRequests(r).Send(w, r)
+	})
+}
+
+func rateLimited(route ratelimit.Route, sendLimited http.HandlerFunc) shared.MiddlewareFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := ratelimit.RouteKey{IP: shared.GetPeerIP(r)}
+			if ctx := context.GetStoreContext(r); ctx.IsProxyAuthorized {
+				key.User = ctx.ProxyAuthUser
+			}
+			if !ratelimit.CheckRoute(w, r, route, key) {
+				sendLimited(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func getStoreName(r *http.Request) (store.StoreName, *core.StoreError) {
 	name := r.Header.Get("X-StremThru-Store-Name")
 	if name == "" {
@@ -0,0 +1,515 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/meta"
+	meta_type "github.com/MunifTanjim/stremthru/internal/meta/type"
+	"github.com/MunifTanjim/stremthru/internal/openapi"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+var spec = openapi.Spec
+
+func init() {
+	openapi.RegisterEnum(
+		store.MagnetStatusCached,
+		store.MagnetStatusQueued,
+		store.MagnetStatusDownloading,
+		store.MagnetStatusProcessing,
+		store.MagnetStatusDownloaded,
+		store.MagnetStatusUploading,
+		store.MagnetStatusFailed,
+		store.MagnetStatusInvalid,
+		store.MagnetStatusUnknown,
+	)
+	openapi.RegisterEnum(
+		store.UserSubscriptionStatusPremium,
+		store.UserSubscriptionStatusTrial,
+		store.UserSubscriptionStatusExpired,
+	)
+	openapi.RegisterEnum(
+		core.ErrorTypeAPI,
+		core.ErrorTypeStore,
+		core.ErrorTypeUpstream,
+		core.ErrorTypeUnknown,
+	)
+	openapi.RegisterEnum(
+		ReadyStatusOk,
+		ReadyStatusDegraded,
+		ReadyStatusFail,
+	)
+}
+
+const (
+	securityProxyAuth = "proxyAuth"
+	securityStoreAuth = "storeAuth"
+)
+
+func dataResponse(description string, v any) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: openapi.JSONContent(&openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"data": spec.SchemaOf(v),
+			},
+		}),
+	}
+}
+
+func errorResponse() *openapi.Response {
+	return &openapi.Response{
+		Description: "Error",
+		Content: openapi.JSONContent(&openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"error": spec.SchemaOf(core.Error{}),
+			},
+		}),
+	}
+}
+
+func jsonRequestBody(v any) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content:  openapi.JSONContent(spec.SchemaOf(v)),
+	}
+}
+
+func pathParam(name, description string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          openapi.ParameterInPath,
+		Description: description,
+		Required:    true,
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+}
+
+func queryParam(name, typ, description string, required bool) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          openapi.ParameterInQuery,
+		Description: description,
+		Required:    required,
+		Schema:      &openapi.Schema{Type: typ},
+	}
+}
+
+func addOperation(method, path string, op *openapi.Operation) {
+	if op.Responses == nil {
+		op.Responses = map[string]*openapi.Response{}
+	}
+	if _, ok := op.Responses["default"]; !ok {
+		op.Responses["default"] = errorResponse()
+	}
+	spec.AddOperation(method, path, op)
+}
+
+func addStoreOperations() {
+	storeSecurity := []openapi.SecurityRequirement{
+		{securityProxyAuth: {}},
+		{securityStoreAuth: {}},
+	}
+	storeNameParam := &openapi.Parameter{
+		Name:        "X-StremThru-Store-Name",
+		In:          openapi.ParameterInHeader,
+		Description: "Store name, defaults to the first store configured for the user",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	magnetIdParam := pathParam("magnetId", "Magnet id")
+
+	addOperation(http.MethodGet, "/v0/store/user", &openapi.Operation{
+		OperationId: "getStoreUser",
+		Summary:     "Get information about authenticated user",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{storeNameParam},
+		Security:    storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("User", store.User{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/store/magnets", &openapi.Operation{
+		OperationId: "listStoreMagnets",
+		Summary:     "List magnets on user's account",
+		Tags:        []string{"store"},
+		Parameters: []*openapi.Parameter{
+			storeNameParam,
+			queryParam("limit", "integer", "min `1`, max `500`, default `100`", false),
+			queryParam("offset", "integer", "min `0`, default `0`", false),
+		},
+		Security: storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Magnets", store.ListMagnetsData{}),
+		},
+	})
+
+	addMagnetBody := jsonRequestBody(AddMagnetPayload{})
+	addMagnetBody.Description = "Either `magnet` or `torrent` link, or a torrent file"
+	addMagnetBody.Content["multipart/form-data"] = &openapi.MediaType{
+		Schema: &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"torrent": {Type: "string", Format: "binary"},
+			},
+			Required: []string{"torrent"},
+		},
+	}
+	addOperation(http.MethodPost, "/v0/store/magnets", &openapi.Operation{
+		OperationId: "addStoreMagnet",
+		Summary:     "Add magnet link for download",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{storeNameParam},
+		RequestBody: addMagnetBody,
+		Security:    storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"201": dataResponse("Added magnet", store.AddMagnetData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/store/magnets/check", &openapi.Operation{
+		OperationId: "checkStoreMagnets",
+		Summary:     "Check magnet links",
+		Tags:        []string{"store"},
+		Parameters: []*openapi.Parameter{
+			storeNameParam,
+			queryParam("magnet", "string", "Comma seperated magnet links (min `1`, max `500`)", true),
+			queryParam("sid", "string", "Stremio stream id", false),
+			queryParam("local_only", "string", "Only check local cache, if present", false),
+		},
+		Security: storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Magnets", store.CheckMagnetData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/store/magnets/{magnetId}", &openapi.Operation{
+		OperationId: "getStoreMagnet",
+		Summary:     "Get magnet on user's account",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{storeNameParam, magnetIdParam},
+		Security:    storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Magnet", store.GetMagnetData{}),
+		},
+	})
+
+	addOperation(http.MethodDelete, "/v0/store/magnets/{magnetId}", &openapi.Operation{
+		OperationId: "removeStoreMagnet",
+		Summary:     "Remove magnet from user's account",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{storeNameParam, magnetIdParam},
+		Security:    storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Removed magnet", store.RemoveMagnetData{}),
+		},
+	})
+
+	addOperation(http.MethodPost, "/v0/store/link/generate", &openapi.Operation{
+		OperationId: "generateStoreLink",
+		Summary:     "Generate direct link for a file link",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{storeNameParam},
+		RequestBody: jsonRequestBody(GenerateLinkPayload{}),
+		Security:    storeSecurity,
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Link", store.GenerateLinkData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/store/_/static/{video}", &openapi.Operation{
+		OperationId: "getStoreStaticVideo",
+		Summary:     "Get static video, e.g. shown for store errors",
+		Tags:        []string{"store"},
+		Parameters:  []*openapi.Parameter{pathParam("video", "Video name, e.g. `download_failed`")},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Video", Content: map[string]*openapi.MediaType{"video/mp4": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}},
+			"206": {Description: "Partial video"},
+			"404": {Description: "Not found"},
+		},
+	})
+}
+
+func addProxyOperations() {
+	proxifyParams := []*openapi.Parameter{
+		queryParam("url", "string", "URL to proxify, can be repeated", true),
+		queryParam("exp", "string", "Expiration time duration", false),
+		queryParam("bind_ip", "string", "IP address or CIDR subnet the proxified link is restricted to, `auto` for the requester's IP", false),
+		queryParam("req_headers", "string", "Headers to add to the request, `req_headers[i]` for the `url` at position `i`", false),
+		queryParam("filename", "string", "Filename, `filename[i]` for the `url` at position `i`", false),
+		queryParam("token", "string", "Token to use for authorization, the proxified link is not encrypted", false),
+	}
+	formSchema := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{}}
+	for _, param := range proxifyParams {
+		formSchema.Properties[param.Name] = &openapi.Schema{Type: "string", Description: param.Description}
+	}
+	formSchema.Required = []string{"url"}
+
+	addOperation(http.MethodGet, "/v0/proxy", &openapi.Operation{
+		OperationId: "proxifyLinks",
+		Summary:     "Proxify links",
+		Tags:        []string{"proxy"},
+		Parameters: append(proxifyParams,
+			queryParam("redirect", "string", "Redirect to proxified url, valid for single `url`", false),
+		),
+		Security: []openapi.SecurityRequirement{{securityProxyAuth: {}}},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Proxified links", proxifyLinksData{}),
+			"302": {Description: "Redirect to proxified link"},
+		},
+	})
+
+	addOperation(http.MethodPost, "/v0/proxy", &openapi.Operation{
+		OperationId: "proxifyLinksWithForm",
+		Summary:     "Proxify links",
+		Tags:        []string{"proxy"},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/x-www-form-urlencoded": {Schema: formSchema},
+			},
+		},
+		Security: []openapi.SecurityRequirement{{securityProxyAuth: {}}},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Proxified links", proxifyLinksData{}),
+		},
+	})
+
+	for _, path := range []string{"/v0/proxy/{token}", "/v0/proxy/{token}/{filename}"} {
+		params := []*openapi.Parameter{pathParam("token", "Proxified link token")}
+		operationId := "accessProxifiedLink"
+		if path != "/v0/proxy/{token}" {
+			params = append(params, pathParam("filename", "Filename"))
+			operationId = "accessProxifiedLinkWithFilename"
+		}
+		addOperation(http.MethodGet, path, &openapi.Operation{
+			OperationId: operationId,
+			Summary:     "Access proxified link",
+			Tags:        []string{"proxy"},
+			Parameters:  params,
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Proxied content"},
+				"206": {Description: "Proxied partial content"},
+			},
+		})
+	}
+}
+
+func addTorrentOperations() {
+	addOperation(http.MethodGet, "/v0/torrents", &openapi.Operation{
+		OperationId: "listTorrents",
+		Summary:     "List torrents for a stremio id",
+		Tags:        []string{"torrents"},
+		Parameters: []*openapi.Parameter{
+			queryParam("sid", "string", "Stremio id, e.g. `tt0110912` or `anidb:...`", true),
+			queryParam("local_only", "string", "Skip the peer, if present", false),
+			queryParam("no_missing_size", "string", "Skip torrents with unknown size, if present", false),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Torrents", torrent_info.ListTorrentsData{}),
+		},
+	})
+
+	addOperation(http.MethodPost, "/v0/torrents", &openapi.Operation{
+		OperationId: "recordTorrents",
+		Summary:     "Record torrents, for peers",
+		Tags:        []string{"torrents"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "X-StremThru-Peer-Token",
+			In:       openapi.ParameterInHeader,
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}},
+		RequestBody: jsonRequestBody(RecordTorrentsPayload{}),
+		Responses: map[string]*openapi.Response{
+			"204": {Description: "Recorded"},
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/torrents/stats", &openapi.Operation{
+		OperationId: "getTorrentStats",
+		Summary:     "Get torrent stats",
+		Tags:        []string{"torrents"},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Stats", torrent_info.Stats{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/torznab/api", &openapi.Operation{
+		OperationId: "torznab",
+		Summary:     "Torznab API",
+		Tags:        []string{"torznab"},
+		Parameters: []*openapi.Parameter{
+			{
+				Name:        "t",
+				In:          openapi.ParameterInQuery,
+				Description: "Function, redirects to `caps` if missing",
+				Schema:      &openapi.Schema{Type: "string", Enum: []any{"caps", "search", "tvsearch", "movie"}},
+			},
+			{
+				Name:        "o",
+				In:          openapi.ParameterInQuery,
+				Description: "Output format",
+				Schema:      &openapi.Schema{Type: "string", Enum: []any{"xml", "json"}},
+			},
+			queryParam("q", "string", "Search query", false),
+			queryParam("imdbid", "string", "IMDB id", false),
+			queryParam("year", "integer", "Year", false),
+			queryParam("season", "integer", "Season", false),
+			queryParam("ep", "integer", "Episode", false),
+			queryParam("cat", "string", "Comma seperated categories", false),
+			queryParam("limit", "integer", "Limit", false),
+			queryParam("offset", "integer", "Offset", false),
+		},
+		Security: []openapi.SecurityRequirement{{}, {securityProxyAuth: {}}},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Torznab response, errors are also sent with `200`",
+				Content: map[string]*openapi.MediaType{
+					"application/xml":  {},
+					"application/json": {},
+				},
+			},
+		},
+	})
+}
+
+func addMetaOperations() {
+	addOperation(http.MethodGet, "/v0/meta/id-map/{idType}/{id}", &openapi.Operation{
+		OperationId: "getMetaIdMap",
+		Summary:     "Get ID mapping for a given ID",
+		Tags:        []string{"meta"},
+		Parameters: []*openapi.Parameter{
+			{
+				Name:     "idType",
+				In:       openapi.ParameterInPath,
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string", Enum: []any{meta_type.IdTypeMovie, meta_type.IdTypeShow}},
+			},
+			pathParam("id", "IMDB ID, e.g. `tt0110912`"),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("ID Map", meta.IdMap{}),
+		},
+	})
+}
+
+func addLetterboxdOperations() {
+	addOperation(http.MethodGet, "/v0/meta/letterboxd/lists/{list_id}", &openapi.Operation{
+		OperationId: "getMetaLetterboxdList",
+		Summary:     "Get Letterboxd list",
+		Tags:        []string{"meta"},
+		Parameters:  []*openapi.Parameter{pathParam("list_id", "List id")},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("List", meta_type.List{}),
+		},
+	})
+	addOperation(http.MethodGet, "/v0/meta/lists/letterboxd/{list_id}", &openapi.Operation{
+		OperationId: "getMetaLetterboxdListLegacy",
+		Summary:     "Get Letterboxd list",
+		Description: "Use `/v0/meta/letterboxd/lists/{list_id}` instead.",
+		Tags:        []string{"meta"},
+		Parameters:  []*openapi.Parameter{pathParam("list_id", "List id")},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("List", meta_type.List{}),
+		},
+		Deprecated: true,
+	})
+	addOperation(http.MethodGet, "/v0/meta/letterboxd/users/{user_id}/lists/watchlist", &openapi.Operation{
+		OperationId: "getMetaLetterboxdUserWatchlist",
+		Summary:     "Get Letterboxd user watchlist",
+		Tags:        []string{"meta"},
+		Parameters:  []*openapi.Parameter{pathParam("user_id", "User id")},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("List", meta_type.List{}),
+		},
+	})
+}
+
+func addHealthOperations() {
+	addOperation(http.MethodGet, "/v0/health", &openapi.Operation{
+		OperationId: "getHealth",
+		Summary:     "Liveness check",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Healthy", HealthData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/health/ready", &openapi.Operation{
+		OperationId: "getHealthReady",
+		Summary:     "Readiness check",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Ready", ReadyData{}),
+			"503": dataResponse("Not ready", ReadyData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/health/__debug__", &openapi.Operation{
+		OperationId: "getHealthDebug",
+		Summary:     "Debug info, with user, store and ip details for authorized requests",
+		Tags:        []string{"health"},
+		Security:    []openapi.SecurityRequirement{{}, {securityProxyAuth: {}}},
+		Responses: map[string]*openapi.Response{
+			"200": dataResponse("Debug info", HealthDebugData{}),
+		},
+	})
+
+	addOperation(http.MethodGet, "/v0/openapi.json", &openapi.Operation{
+		OperationId: "getOpenAPI",
+		Summary:     "OpenAPI document",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "OpenAPI document", Content: openapi.JSONContent(&openapi.Schema{Type: "object"})},
+		},
+	})
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	shared.SendJSON(w, r, 200, spec)
+}
+
+func AddOpenAPIEndpoints(mux *http.ServeMux) {
+	spec.Info.Version = config.Version
+
+	spec.AddSecurityScheme(securityProxyAuth, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-StremThru-Authorization",
+		Description: "`Basic` auth for `STREMTHRU_PROXY_AUTH`, or `Bearer` API key of a dashboard managed user",
+	})
+	spec.AddSecurityScheme(securityStoreAuth, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-StremThru-Store-Authorization",
+		Description: "Forwarded to the external store",
+	})
+
+	addHealthOperations()
+	addMetaOperations()
+	if config.Integration.Letterboxd.IsEnabled() {
+		addLetterboxdOperations()
+	}
+	addProxyOperations()
+	addStoreOperations()
+	if config.Feature.HasTorrentInfo() {
+		addTorrentOperations()
+	}
+
+	withCors := shared.Middleware(shared.EnableCORS)
+
+	mux.HandleFunc("/v0/openapi.json", withCors(handleOpenAPI))
+}
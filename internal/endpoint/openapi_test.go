@@ -0,0 +1,128 @@
+package endpoint
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getStringLit(expr ast.Expr) (string, bool) {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		value, err := strconv.Unquote(lit.Value)
+		return value, err == nil
+	}
+	return "", false
+}
+
+func getSelectorCall(node ast.Node, names ...string) (*ast.CallExpr, *ast.SelectorExpr, bool) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil, nil, false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, nil, false
+	}
+	for _, name := range names {
+		if sel.Sel.Name == name {
+			return call, sel, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Collects the `/v0` patterns registered with `Handle`/`HandleFunc`, including
+// the ones on a router mounted with `http.StripPrefix`.
+func collectV0Patterns(t *testing.T, root string) []string {
+	patterns := []string{}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			prefixByRouter := map[string]string{}
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				call, _, ok := getSelectorCall(node, "StripPrefix")
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				prefix, ok := getStringLit(call.Args[0])
+				if !ok {
+					return true
+				}
+				ast.Inspect(call.Args[1], func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Ident); ok {
+						prefixByRouter[ident.Name] = prefix
+					}
+					return true
+				})
+				return true
+			})
+
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				call, sel, ok := getSelectorCall(node, "Handle", "HandleFunc")
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				pattern, ok := getStringLit(call.Args[0])
+				if !ok {
+					return true
+				}
+				if _, p, hasMethod := strings.Cut(pattern, " "); hasMethod {
+					pattern = p
+				}
+				if router, ok := sel.X.(*ast.Ident); ok {
+					pattern = prefixByRouter[router.Name] + pattern
+				}
+				// subtree for a mounted router, its own patterns are collected
+				if strings.HasSuffix(pattern, "/") {
+					return true
+				}
+				if strings.HasPrefix(pattern, "/v0/") {
+					patterns = append(patterns, pattern)
+				}
+				return true
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patterns
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	addHealthOperations()
+	addMetaOperations()
+	addLetterboxdOperations()
+	addProxyOperations()
+	addStoreOperations()
+	addTorrentOperations()
+
+	patterns := collectV0Patterns(t, "..")
+	assert.Contains(t, patterns, "/v0/store/user")
+	assert.Contains(t, patterns, "/v0/meta/id-map/{idType}/{id}")
+
+	for _, pattern := range patterns {
+		_, ok := spec.Paths[pattern]
+		assert.True(t, ok, "undocumented route: %s", pattern)
+	}
+}
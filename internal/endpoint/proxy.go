@@ -8,6 +8,7 @@ import (
 
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/content_proxy"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	store_video "github.com/MunifTanjim/stremthru/internal/store/video"
@@ -30,7 +31,7 @@ func handleProxyLinkAccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, link, headers, tunnelType, err := shared.UnwrapProxyLinkToken(encodedToken)
+	user, link, headers, tunnelType, err := shared.UnwrapProxyLinkToken(r, encodedToken)
 	if err != nil {
 		SendError(w, r, err)
 		return
@@ -43,10 +44,8 @@ func handleProxyLinkAccess(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if isGetReq && user != "" {
-		cpStore := contentProxyConnectionStore.WithScope(user)
-
 		if limit := config.ContentProxyConnectionLimit.Get(user); limit > 0 {
-			activeConnectionCount, err := cpStore.Count()
+			activeConnectionCount, err := content_proxy.CountConnections(user)
 			if err != nil {
 				ctx.Log.Error("[proxy] failed to count connections", "error", err)
 			} else if activeConnectionCount >= limit {
@@ -54,14 +53,15 @@ func handleProxyLinkAccess(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
+	}
 
-		if err := cpStore.Set(ctx.RequestId, contentProxyConnection{IP: core.GetRequestIP(r), Link: link}); err != nil {
-			ctx.Log.Error("[proxy] failed to record connection", "error", err)
-		} else {
-			defer cpStore.Del(ctx.RequestId)
-		}
+	if isGetReq {
+		var done func()
+		w, r, done = content_proxy.TrackConnection(w, r, ctx.RequestId, user, core.GetRequestIP(r), link)
+		defer done()
 	}
-	bytesWritten, err := shared.ProxyResponse(w, r, link, tunnelType)
+
+	bytesWritten, err := content_proxy.ProxyResponse(w, r, link, tunnelType)
 	ctx.Log.Info("[proxy] connection closed", "user", user, "size", util.ToSize(bytesWritten), "error", err)
 }
 
@@ -127,6 +127,16 @@ func handleProxifyLinks(w http.ResponseWriter, r *http.Request) {
 		expiresIn = exp
 	}
 
+	bindIP := r.Form.Get("bind_ip")
+	if bindIP == "auto" {
+		bindIP = shared.GetPeerIP(r)
+	}
+	bindIP, err = shared.ParseProxyLinkClientIP(bindIP)
+	if err != nil {
+		shared.ErrorBadRequest(r, "invalid bind_ip").Send(w, r)
+		return
+	}
+
 	shouldEncrypt := r.URL.Query().Get("token") == ""
 	if !shouldEncrypt {
 		ctx.RedactURLQueryParams(r, "token")
@@ -152,7 +162,7 @@ func handleProxifyLinks(w http.ResponseWriter, r *http.Request) {
 			reqHeadersByBlob[reqHeadersBlob] = reqHeaders
 		}
 		filename := r.Form.Get("filename[" + idx + "]")
-		proxyLink, err := shared.CreateProxyLink(r, link, reqHeaders, config.TUNNEL_TYPE_AUTO, expiresIn, user, password, shouldEncrypt, filename)
+		proxyLink, err := shared.CreateProxyLink(r, link, reqHeaders, config.TUNNEL_TYPE_AUTO, expiresIn, bindIP, user, password, shouldEncrypt, filename)
 		if err != nil {
 			SendError(w, r, err)
 			return
@@ -8,8 +8,8 @@ import (
 
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/context"
-	"github.com/MunifTanjim/stremthru/internal/kv"
 	"github.com/MunifTanjim/stremthru/internal/peer_token"
+	"github.com/MunifTanjim/stremthru/internal/ratelimit"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	store_util "github.com/MunifTanjim/stremthru/internal/store/util"
@@ -21,6 +21,7 @@ import (
 func getUser(ctx *context.StoreContext) (*store.User, error) {
 	params := &store.GetUserParams{}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	return ctx.Store.GetUser(params)
 }
 
@@ -43,6 +44,7 @@ type AddMagnetPayload struct {
 func checkMagnet(ctx *context.StoreContext, magnets []string, sid string, localOnly bool) (*store.CheckMagnetData, error) {
 	params := &store.CheckMagnetParams{}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	params.Magnets = magnets
 	params.SId = sid
 	params.LocalOnly = localOnly
@@ -168,6 +170,7 @@ func listMagnets(ctx *context.StoreContext, r *http.Request) (*store.ListMagnets
 		ClientIP: ctx.ClientIP,
 	}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	data, err := ctx.Store.ListMagnets(params)
 
 	if err == nil {
@@ -199,6 +202,7 @@ func handleStoreMagnetsList(w http.ResponseWriter, r *http.Request) {
 func addMagnet(ctx *context.StoreContext, magnet string, torrent *multipart.FileHeader) (*store.AddMagnetData, error) {
 	params := &store.AddMagnetParams{}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	params.Magnet = magnet
 	if ctx.ClientIP != "" {
 		params.ClientIP = ctx.ClientIP
@@ -307,6 +311,7 @@ func handleStoreMagnets(w http.ResponseWriter, r *http.Request) {
 func getMagnet(ctx *context.StoreContext, magnetId string) (*store.GetMagnetData, error) {
 	params := &store.GetMagnetParams{}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	params.Id = magnetId
 	if ctx.ClientIP != "" {
 		params.ClientIP = ctx.ClientIP
@@ -341,6 +346,7 @@ func handleStoreMagnetGet(w http.ResponseWriter, r *http.Request) {
 func removeMagnet(ctx *context.StoreContext, magnetId string) (*store.RemoveMagnetData, error) {
 	params := &store.RemoveMagnetParams{}
 	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
 	params.Id = magnetId
 	return ctx.Store.RemoveMagnet(params)
 }
@@ -377,7 +383,7 @@ func handleStoreMagnet(w http.ResponseWriter, r *http.Request) {
 }
 
 type GenerateLinkPayload struct {
-	Link string `json:"link"`
+	Link string `json:"link" openapi:"required"`
 }
 
 func handleStoreLinkGenerate(w http.ResponseWriter, r *http.Request) {
@@ -398,15 +404,6 @@ func handleStoreLinkGenerate(w http.ResponseWriter, r *http.Request) {
 	SendResponse(w, r, 200, link, err)
 }
 
-type contentProxyConnection struct {
-	IP   string `json:"ip"`
-	Link string `json:"link"`
-}
-
-var contentProxyConnectionStore = kv.NewKVStore[contentProxyConnection](&kv.KVStoreConfig{
-	Type: "cproxyconn",
-})
-
 func handleStatic(w http.ResponseWriter, r *http.Request) {
 	if !shared.IsMethod(r, http.MethodGet) && !shared.IsMethod(r, http.MethodHead) {
 		shared.ErrorMethodNotAllowed(r).Send(w, r)
@@ -422,7 +419,7 @@ func handleStatic(w http.ResponseWriter, r *http.Request) {
 
 func AddStoreEndpoints(mux *http.ServeMux) {
 	withCors := shared.Middleware(shared.EnableCORS)
-	withStore := StoreMiddleware(ProxyAuthContext, StoreContext, StoreRequired)
+	withStore := StoreMiddleware(ProxyAuthContext, RateLimited(ratelimit.RouteStore), StoreContext, StoreRequired)
 
 	mux.HandleFunc("/v0/store/user", withStore(handleStoreUser))
 	mux.HandleFunc("/v0/store/magnets", withStore(handleStoreMagnets))
@@ -16,7 +16,7 @@ import (
 )
 
 type RecordTorrentsPayload struct {
-	Items []torrent_info.TorrentItem `json:"items"`
+	Items []torrent_info.TorrentItem `json:"items" openapi:"required"`
 }
 
 func handleRecordTorrents(w http.ResponseWriter, r *http.Request) {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/ratelimit"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	"github.com/MunifTanjim/stremthru/internal/torznab"
 )
@@ -59,10 +60,21 @@ func handleTorznab(w http.ResponseWriter, r *http.Request) {
 		sendResponse(w, r, 200, torznab.ErrorIncorrectParameter(t), o)
 	}
 }
+
+// Torznab clients expect the error in torznab format, not the usual json.
+func sendTorznabRateLimited(w http.ResponseWriter, r *http.Request) {
+	o := strings.ToLower(r.URL.Query().Get("o"))
+	if o != "json" {
+		o = "xml"
+	}
+	sendResponse(w, r, http.StatusTooManyRequests, torznab.ErrorRequestLimitReached, o)
+}
 func AddTorznabEndpoints(mux *http.ServeMux) {
 	if !config.Feature.HasTorrentInfo() {
 		return
 	}
 
-	mux.HandleFunc("/v0/torznab/api", handleTorznab)
+	withRateLimit := StoreMiddleware(ProxyAuthContext, rateLimited(ratelimit.RouteTorznab, sendTorznabRateLimited))
+
+	mux.HandleFunc("/v0/torznab/api", withRateLimit(handleTorznab))
 }
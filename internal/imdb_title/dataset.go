@@ -33,7 +33,8 @@ func GetDatasetTemporaryDir() string {
 	return datasetDownloadDir
 }
 
-func SyncDataset() error {
+// `onUpsert` is called with the count of titles in each upserted batch.
+func SyncDataset(onUpsert func(count int) error) error {
 	log = logger.Scoped("imdb_title/dataset")
 
 	if !datasetSyncMutex.TryLock() {
@@ -58,6 +59,7 @@ func SyncDataset() error {
 			return Upsert(titles)
 		},
 		SleepDuration: 200 * time.Millisecond,
+		OnUpsert:      onUpsert,
 	})
 
 	ds := util.NewTSVDataset(&util.TSVDatasetConfig[IMDBTitle]{
@@ -39,6 +39,7 @@ type KVStore[V any] interface {
 	GetValue(key string, value *V) error
 	GetLast() (*ParsedKV[V], error)
 	List() ([]ParsedKV[V], error)
+	ListAll() ([]ParsedKV[V], error)
 	Count() (int, error)
 	Set(key string, value V) error
 	Del(key string) error
@@ -137,6 +138,45 @@ func (kv *SQLKVStore[V]) List() ([]ParsedKV[V], error) {
 	return vs, nil
 }
 
+var likePatternReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Same as `List`, but also includes every scope of the type. Expired
+// items are skipped, but not deleted.
+func (kv *SQLKVStore[V]) ListAll() ([]ParsedKV[V], error) {
+	if kv.t == "" {
+		return nil, errors.New("missing kv type value")
+	}
+	query := "SELECT k, v, cat, uat, eat FROM " + TableName + " WHERE t = ? OR t LIKE ? ESCAPE '\\'"
+	rows, err := db.Query(query, kv.t, likePatternReplacer.Replace(kv.t)+":%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	vs := []ParsedKV[V]{}
+	for rows.Next() {
+		kv := KV{}
+		if err := rows.Scan(&kv.Key, &kv.Value, &kv.CreatedAt, &kv.UpdatedAt, &kv.ExpiresAt); err != nil {
+			return nil, err
+		}
+		if kv.Key == "" || (!kv.ExpiresAt.IsZero() && kv.ExpiresAt.Before(now)) {
+			continue
+		}
+		var val V
+		if err := json.Unmarshal([]byte(kv.Value), &val); err != nil {
+			return nil, err
+		}
+		vs = append(vs, ParsedKV[V]{
+			Key:       kv.Key,
+			Value:     val,
+			CreatedAt: kv.CreatedAt.Time,
+			UpdatedAt: kv.UpdatedAt.Time,
+		})
+	}
+	return vs, rows.Err()
+}
+
 func (kv *SQLKVStore[V]) Count() (int, error) {
 	if kv.t == "" {
 		return -1, errors.New("missing kv type value")
@@ -13,6 +13,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/logger/log"
 	"github.com/MunifTanjim/stremthru/internal/posthog"
+	"github.com/MunifTanjim/stremthru/internal/tracing"
 	"github.com/MunifTanjim/stremthru/internal/util"
 )
 
@@ -58,6 +59,10 @@ var _ = func() *struct{} {
 	logProps.Add("key")
 	logProps.Add("store.name")
 
+	if config.Tracing.IsEnabled() {
+		handler = tracing.WrapLogHandler(handler)
+	}
+
 	handler = posthog.WrapLogHandler(handler, logProps)
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
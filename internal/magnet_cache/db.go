@@ -2,6 +2,7 @@ package magnet_cache
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -35,12 +36,12 @@ func (mc MagnetCache) IsStale() bool {
 	return mc.ModifiedAt.Before(time.Now().Add(-staleTime))
 }
 
-func GetByHashes(store store.StoreCode, hashes []string, sid string) ([]MagnetCache, error) {
+func GetByHashes(ctx context.Context, store store.StoreCode, hashes []string, sid string) ([]MagnetCache, error) {
 	if len(hashes) == 0 {
 		return []MagnetCache{}, nil
 	}
 
-	filesByHash, err := torrent_stream.GetFilesByHashes(hashes)
+	filesByHash, err := torrent_stream.GetFilesByHashes(ctx, hashes)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +66,7 @@ func syncList(l *MDBListList, apiKey string) error {
 		return errors.New("list not found")
 	}
 
-	if list.Private && config.IsPublicInstance {
+	if list.Private && config.IsPublicInstance() {
 		return errors.New("private list not supported on public instance")
 	}
 
@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "stremthru"
+
+var IsEnabled = config.Metrics.IsEnabled()
+
+var registry = func() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests, by route, method and status.",
+	}, []string{"route", "method", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route and method.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"route", "method"})
+
+	storeRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "requests_total",
+		Help:      "Number of upstream store API requests, by store code and status.",
+	}, []string{"store", "status"})
+	storeRequestErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "request_errors_total",
+		Help:      "Number of failed upstream store API requests, by store code.",
+	}, []string{"store"})
+	storeRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of upstream store API requests, by store code.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"store"})
+
+	cacheHitsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Number of cache hits, by cache name.",
+	}, []string{"cache"})
+	cacheMissesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Number of cache misses, by cache name.",
+	}, []string{"cache"})
+
+	workerRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "worker",
+		Name:      "runs_total",
+		Help:      "Number of worker runs, by worker id and status.",
+	}, []string{"worker", "status"})
+	workerRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "worker",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of worker runs, by worker id.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600},
+	}, []string{"worker"})
+
+	contentProxyBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "content_proxy",
+		Name:      "bytes_total",
+		Help:      "Number of bytes sent by the content proxy.",
+	})
+	contentProxyActiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "content_proxy",
+		Name:      "active_connections",
+		Help:      "Number of active content proxy connections.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		httpRequestsTotal,
+		httpRequestDuration,
+		storeRequestsTotal,
+		storeRequestErrorsTotal,
+		storeRequestDuration,
+		cacheHitsTotal,
+		cacheMissesTotal,
+		workerRunsTotal,
+		workerRunDuration,
+		contentProxyBytesTotal,
+		contentProxyActiveConnections,
+	)
+}
+
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	if !IsEnabled {
+		return
+	}
+	if route == "" {
+		route = "unmatched"
+	} else if _, path, ok := strings.Cut(route, " "); ok {
+		// strip method from pattern, e.g. `GET /v0/health`
+		route = path
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	httpRequestsTotal.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	httpRequestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func RecordCacheGet(name string, hit bool) {
+	if !IsEnabled {
+		return
+	}
+	if name == "" {
+		name = "unnamed"
+	}
+	if hit {
+		cacheHitsTotal.WithLabelValues(name).Inc()
+	} else {
+		cacheMissesTotal.WithLabelValues(name).Inc()
+	}
+}
+
+func ObserveWorkerRun(id string, duration time.Duration, err error) {
+	if !IsEnabled {
+		return
+	}
+	status := "done"
+	if err != nil {
+		status = "failed"
+	}
+	workerRunsTotal.WithLabelValues(id, status).Inc()
+	workerRunDuration.WithLabelValues(id).Observe(duration.Seconds())
+}
+
+func AddContentProxyBytes(n int) {
+	if !IsEnabled || n <= 0 {
+		return
+	}
+	contentProxyBytesTotal.Add(float64(n))
+}
+
+func IncContentProxyConnections() {
+	if !IsEnabled {
+		return
+	}
+	contentProxyActiveConnections.Inc()
+}
+
+func DecContentProxyConnections() {
+	if !IsEnabled {
+		return
+	}
+	contentProxyActiveConnections.Dec()
+}
+
+func Handler() http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	token := config.Metrics.Token
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
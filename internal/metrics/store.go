@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type storeTransport struct {
+	base      http.RoundTripper
+	storeCode string
+}
+
+func (t *storeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	storeRequestDuration.WithLabelValues(t.storeCode).Observe(time.Since(start).Seconds())
+	if err != nil {
+		storeRequestsTotal.WithLabelValues(t.storeCode, "error").Inc()
+		storeRequestErrorsTotal.WithLabelValues(t.storeCode).Inc()
+		return res, err
+	}
+	storeRequestsTotal.WithLabelValues(t.storeCode, strconv.Itoa(res.StatusCode)).Inc()
+	if res.StatusCode >= 400 {
+		storeRequestErrorsTotal.WithLabelValues(t.storeCode).Inc()
+	}
+	return res, nil
+}
+
+// Returns a copy of the client that records store api metrics
+func InstrumentStoreHTTPClient(client *http.Client, storeCode string) *http.Client {
+	if !IsEnabled {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *client
+	c.Transport = &storeTransport{base: base, storeCode: storeCode}
+	return &c
+}
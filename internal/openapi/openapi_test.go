@@ -0,0 +1,107 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus string
+
+type testFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type testNode struct {
+	Children []testNode `json:"children"`
+}
+
+type testPayload struct {
+	Link    string            `json:"link" openapi:"required"`
+	Status  testStatus        `json:"status,omitempty"`
+	Files   []testFile        `json:"files"`
+	File    *testFile         `json:"file"`
+	Headers map[string]string `json:"headers"`
+	AddedAt time.Time         `json:"added_at"`
+	Ignored string            `json:"-"`
+	Node    testNode          `json:"node"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	RegisterEnum[testStatus]("ok", "failed")
+
+	d := NewDocument(Info{})
+	schema := d.SchemaOf(&testPayload{})
+
+	assert.Equal(t, &Schema{
+		AllOf:    []*Schema{{Ref: "#/components/schemas/openapi.testPayload"}},
+		Nullable: true,
+	}, schema)
+	assert.Same(t, schema, d.SchemaOf(&testPayload{}))
+
+	payload := d.Components.Schemas["openapi.testPayload"]
+	assert.Equal(t, []string{"link"}, payload.Required)
+	assert.Equal(t, &Schema{Type: "string", Enum: []any{testStatus("ok"), testStatus("failed")}}, payload.Properties["status"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testFile"}}, payload.Properties["files"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, payload.Properties["headers"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, payload.Properties["added_at"])
+	assert.NotContains(t, payload.Properties, "Ignored")
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testNode"}}, d.Components.Schemas["openapi.testNode"].Properties["children"])
+}
+
+func TestValidate(t *testing.T) {
+	RegisterEnum[testStatus]("ok", "failed")
+
+	d := NewDocument(Info{})
+	schema := d.SchemaOf(&testPayload{})
+
+	for _, tc := range []struct {
+		name  string
+		value any
+		errs  []ValidationError
+	}{
+		{
+			"valid",
+			map[string]any{
+				"link":    "https://example.com",
+				"status":  "ok",
+				"files":   []any{map[string]any{"name": "a.mkv", "size": 1.0}},
+				"file":    nil,
+				"headers": map[string]any{"a": "b"},
+				"unknown": true,
+			},
+			[]ValidationError{},
+		},
+		{
+			"missing required",
+			map[string]any{},
+			[]ValidationError{{Location: "link", Message: "is required"}},
+		},
+		{
+			"wrong type",
+			map[string]any{"link": 5.0},
+			[]ValidationError{{Location: "link", Message: "must be string"}},
+		},
+		{
+			"invalid enum",
+			map[string]any{"link": "x", "status": "nope"},
+			[]ValidationError{{Location: "status", Message: "must be one of [ok failed]"}},
+		},
+		{
+			"nested",
+			map[string]any{"link": "x", "files": []any{map[string]any{"size": 1.5}}},
+			[]ValidationError{{Location: "files[0].size", Message: "must be integer"}},
+		},
+		{
+			"not nullable",
+			map[string]any{"link": "x", "headers": map[string]any{"a": nil}},
+			[]ValidationError{{Location: "headers.a", Message: "must not be null"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.errs, d.Validate(schema, tc.value))
+		})
+	}
+}
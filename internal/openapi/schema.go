@@ -0,0 +1,214 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var enumByType sync.Map // map[reflect.Type][]any
+
+// Registers the allowed values of a named type, e.g. `store.MagnetStatus`.
+func RegisterEnum[T any](values ...T) {
+	enum := make([]any, len(values))
+	for i, v := range values {
+		enum[i] = v
+	}
+	enumByType.Store(reflect.TypeFor[T](), enum)
+}
+
+func getEnum(t reflect.Type) []any {
+	if enum, ok := enumByType.Load(t); ok {
+		return enum.([]any)
+	}
+	return nil
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	rawMessageType      = reflect.TypeFor[json.RawMessage]()
+)
+
+var invalidSchemaNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func schemaName(t reflect.Type) string {
+	// e.g. `store.MagnetFile`
+	return strings.Trim(invalidSchemaNameChars.ReplaceAllString(t.String(), "_"), "_")
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Generates the schema for the Go type of `v`, following `encoding/json`
+// rules. Named struct types are added to the components and referenced.
+//
+// Struct fields tagged with `openapi:"required"` are required.
+func (d *Document) SchemaOf(v any) *Schema {
+	return d.SchemaFor(reflect.TypeOf(v))
+}
+
+func (d *Document) SchemaFor(t reflect.Type) *Schema {
+	// called for every request body, the write lock is only needed once
+	// per type
+	d.mu.RLock()
+	schema, ok := d.schemaByType[t]
+	d.mu.RUnlock()
+	if ok {
+		return schema
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if schema, ok := d.schemaByType[t]; ok {
+		return schema
+	}
+	schema = d.schemaFor(t)
+	d.schemaByType[t] = schema
+	return schema
+}
+
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		schema := d.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		s := *schema
+		s.Nullable = true
+		return &s
+	}
+
+	if enum := getEnum(t); enum != nil {
+		schema := d.schemaForKind(t)
+		schema.Enum = enum
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case implements(t, jsonMarshalerType) || implements(t, jsonUnmarshalerType):
+		// custom encoding, can be anything
+		return &Schema{}
+	case implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" {
+		name := schemaName(t)
+		if _, ok := d.schemas[t]; !ok {
+			// placeholder, for recursive types
+			d.schemas[t] = ref(name)
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+		return d.schemas[t]
+	}
+
+	return d.schemaForKind(t)
+}
+
+func (d *Document) schemaForKind(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return d.structSchema(t)
+	}
+	return &Schema{}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	d.addStructFields(schema, t)
+	slices.Sort(schema.Required)
+	return schema
+}
+
+func (d *Document) addStructFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.addStructFields(schema, ft)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		var fieldSchema *Schema
+		if slices.Contains(strings.Split(opts, ","), "string") {
+			fieldSchema = &Schema{Type: "string"}
+		} else {
+			fieldSchema = d.schemaFor(field.Type)
+		}
+		schema.Properties[name] = fieldSchema
+
+		if slices.Contains(strings.Split(field.Tag.Get("openapi"), ","), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func (d *Document) resolve(schema *Schema) *Schema {
+	if schema.Ref == "" {
+		return schema
+	}
+	if s, ok := d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]; ok {
+		return s
+	}
+	return &Schema{}
+}
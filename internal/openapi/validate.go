@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+type ValidationError struct {
+	Location string
+	Message  string
+}
+
+func joinLocation(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Validates the JSON decoded `value` against `schema`. Unknown object
+// properties are allowed.
+func (d *Document) Validate(schema *Schema, value any) []ValidationError {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	errs := []ValidationError{}
+	d.validate(schema, value, "", &errs)
+	return errs
+}
+
+func (d *Document) validate(schema *Schema, value any, location string, errs *[]ValidationError) {
+	schema = d.resolve(schema)
+
+	if value == nil {
+		if schema.Nullable {
+			return
+		}
+		if len(schema.AllOf) > 0 {
+			for _, s := range schema.AllOf {
+				d.validate(s, value, location, errs)
+			}
+			return
+		}
+		if schema.Type != "" {
+			*errs = append(*errs, ValidationError{Location: location, Message: "must not be null"})
+		}
+		return
+	}
+
+	for _, s := range schema.AllOf {
+		d.validate(s, value, location, errs)
+	}
+
+	if schema.Type != "" {
+		actual := typeOf(value)
+		if actual != schema.Type && !(schema.Type == "number" && actual == "integer") {
+			*errs = append(*errs, ValidationError{Location: location, Message: "must be " + schema.Type})
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool {
+		return reflect.ValueOf(e).Kind() == reflect.String && reflect.ValueOf(e).String() == value
+	}) {
+		*errs = append(*errs, ValidationError{Location: location, Message: fmt.Sprintf("must be one of %v", schema.Enum)})
+		return
+	}
+
+	switch v := value.(type) {
+	case []any:
+		if schema.Items != nil {
+			for i, item := range v {
+				d.validate(schema.Items, item, location+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Location: joinLocation(location, name), Message: "is required"})
+			}
+		}
+		for key, val := range v {
+			if prop, ok := schema.Properties[key]; ok {
+				d.validate(prop, val, joinLocation(location, key), errs)
+			} else if schema.AdditionalProperties != nil {
+				d.validate(schema.AdditionalProperties, val, joinLocation(location, key), errs)
+			}
+		}
+	}
+}
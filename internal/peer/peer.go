@@ -1,8 +1,6 @@
 package peer
 
 import (
-	"encoding/json"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -13,6 +11,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/request"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	stremthru "github.com/MunifTanjim/stremthru/sdk/go"
 	"github.com/MunifTanjim/stremthru/store"
 )
 
@@ -30,13 +29,7 @@ type APIClientConfig struct {
 }
 
 type APIClient struct {
-	BaseURL    *url.URL
-	HTTPClient *http.Client
-	apiKey     string
-	agent      string
-
-	reqQuery  func(query *url.Values, params request.Context)
-	reqHeader func(query *http.Header, params request.Context)
+	client *stremthru.Client
 
 	checkMagnetRetryAfter *time.Time
 }
@@ -52,104 +45,65 @@ func NewAPIClient(conf *APIClientConfig) *APIClient {
 
 	c := &APIClient{}
 
-	if conf.BaseURL != "" {
-		baseUrl, err := url.Parse(conf.BaseURL)
-		if err != nil {
-			panic(err)
-		}
-		c.BaseURL = baseUrl
-	}
-
-	c.HTTPClient = conf.HTTPClient
-	c.apiKey = conf.APIKey
-	c.agent = conf.agent
-
-	c.reqQuery = func(query *url.Values, params request.Context) {
-	}
-
-	c.reqHeader = func(header *http.Header, params request.Context) {
-		header.Set("X-StremThru-Peer-Token", params.GetAPIKey(c.apiKey))
-		header.Set(server.HEADER_INSTANCE_ID, config.InstanceId)
-		header.Set("X-StremThru-Version", config.Version)
-		header.Add("User-Agent", c.agent)
-	}
+	c.client = stremthru.NewClient(&stremthru.ClientConfig{
+		BaseURL:    conf.BaseURL,
+		PeerToken:  conf.APIKey,
+		UserAgent:  conf.agent,
+		HTTPClient: conf.HTTPClient,
+		Header: http.Header{
+			server.HEADER_INSTANCE_ID: []string{config.InstanceId},
+			"X-StremThru-Version":     []string{config.Version},
+		},
+		// called on the stream path, where `HaltCheckMagnet` already backs
+		// off on failures
+		Retry: &stremthru.RetryConfig{MaxRetries: 0},
+	})
 
 	return c
 }
 
-type Ctx = request.Ctx
+type Ctx = stremthru.Ctx
 
-type ResponseEnvelop interface {
-	GetError() error
-}
-
-type ResponseError struct {
-	Code       core.ErrorCode `json:"code"`
-	Message    string         `json:"message"`
-	StatusCode int            `json:"status_code"`
-}
-
-func (e *ResponseError) Error() string {
-	ret, _ := json.Marshal(e)
-	return string(ret)
-}
-
-type Response[D any] struct {
-	Data  D              `json:"data,omitempty"`
-	Error *ResponseError `json:"error,omitempty"`
-}
-
-func (r Response[any]) GetError() error {
-	if r.Error == nil {
-		return nil
+func toSDKCtx(ctx store.Ctx) stremthru.Ctx {
+	return stremthru.Ctx{
+		Context: ctx.Context,
+		Form:    ctx.Form,
+		JSON:    ctx.JSON,
+		Headers: ctx.Headers,
+		Query:   ctx.Query,
+		Body:    ctx.Body,
 	}
-	return r.Error
 }
 
-func processResponseBody(res *http.Response, err error, v ResponseEnvelop) error {
-	if err != nil {
-		return err
+func toAPIResponse[T any](res stremthru.APIResponse[T]) request.APIResponse[T] {
+	return request.APIResponse[T]{
+		Header:     res.Header,
+		StatusCode: res.StatusCode,
+		Data:       res.Data,
 	}
+}
 
-	body, err := io.ReadAll(res.Body)
-	defer res.Body.Close()
-
-	if err != nil {
-		return err
-	}
+func upstreamErrorWithCause(cause error) *core.UpstreamError {
+	err := core.NewUpstreamError("")
 
-	err = core.UnmarshalJSON(res.StatusCode, body, v)
-	if err != nil {
-		return err
+	if rerr, ok := cause.(*stremthru.ResponseError); ok {
+		err.Msg = rerr.Message
+		err.Code = rerr.Code
+		err.StatusCode = rerr.StatusCode
+		err.Method = rerr.Method
+		err.Path = rerr.Path
+		err.UpstreamCause = rerr
+	} else {
+		err.Cause = cause
 	}
 
-	return v.GetError()
+	return err
 }
 
-func (c APIClient) Request(method, path string, params request.Context, v ResponseEnvelop) (*http.Response, error) {
-	if params == nil {
-		params = &Ctx{}
-	}
-	req, err := params.NewRequest(c.BaseURL, method, path, c.reqHeader, c.reqQuery)
+func (c APIClient) Request(method, path string, params stremthru.RequestContext, v stremthru.ResponseEnvelop) (*http.Response, error) {
+	res, err := c.client.Request(method, path, params, v)
 	if err != nil {
-		error := core.NewAPIError("failed to create request")
-		error.Cause = err
-		return nil, error
-	}
-	res, err := c.HTTPClient.Do(req)
-	err = processResponseBody(res, err, v)
-	if err != nil {
-		error := core.NewUpstreamError("")
-		if rerr, ok := err.(*core.Error); ok {
-			error.Msg = rerr.Msg
-			error.Code = rerr.Code
-			error.StatusCode = rerr.StatusCode
-			error.UpstreamCause = rerr
-		} else {
-			error.Cause = err
-		}
-		error.InjectReq(req)
-		return res, err
+		return res, upstreamErrorWithCause(err)
 	}
 	return res, nil
 }
@@ -177,20 +131,21 @@ type CheckMagnetParams struct {
 }
 
 func (c APIClient) CheckMagnet(params *CheckMagnetParams) (request.APIResponse[store.CheckMagnetData], error) {
-	params.Query = &url.Values{"magnet": params.Magnets}
-	params.Query.Set("client_ip", params.ClientIP)
-	if params.SId != "" {
-		params.Query.Set("sid", params.SId)
-	}
-	params.Query.Set("local_only", "1")
-	params.Headers = &http.Header{
-		"X-StremThru-Store-Name":          []string{string(params.StoreName)},
-		"X-StremThru-Store-Authorization": []string{"Bearer " + params.StoreToken},
+	res, err := c.client.Store.CheckMagnet(&stremthru.CheckMagnetParams{
+		StoreCtx: stremthru.StoreCtx{
+			Ctx:        toSDKCtx(params.Ctx),
+			StoreName:  params.StoreName,
+			StoreToken: params.StoreToken,
+			ClientIP:   params.ClientIP,
+		},
+		Magnets:   params.Magnets,
+		SId:       params.SId,
+		LocalOnly: true,
+	})
+	if err != nil {
+		err = upstreamErrorWithCause(err)
 	}
-
-	response := &Response[store.CheckMagnetData]{}
-	res, err := c.Request("GET", "/v0/store/magnets/check", params, response)
-	return request.NewAPIResponse(res, response.Data), err
+	return toAPIResponse(res), err
 }
 
 type TrackMagnetParams struct {
@@ -205,10 +160,6 @@ type TrackMagnetParams struct {
 type TrackMagnetData struct{}
 
 func (c APIClient) TrackMagnet(params *TrackMagnetParams) (request.APIResponse[TrackMagnetData], error) {
-	params.Headers = &http.Header{
-		"X-StremThru-Store-Name":          []string{string(params.StoreName)},
-		"X-StremThru-Store-Authorization": []string{"Bearer " + params.StoreToken},
-	}
 	if config.PeerFlag.NoSpillTorz {
 		if params.Cached == nil {
 			params.Cached = map[string]bool{}
@@ -221,15 +172,20 @@ func (c APIClient) TrackMagnet(params *TrackMagnetParams) (request.APIResponse[T
 		}
 		params.TorrentInfos = nil
 	}
-	params.JSON = params
+	ctx := &stremthru.StoreCtx{
+		Ctx:        toSDKCtx(params.Ctx),
+		StoreName:  params.StoreName,
+		StoreToken: params.StoreToken,
+	}
+	ctx.JSON = params
 
-	response := &Response[TrackMagnetData]{}
-	res, err := c.Request("POST", "/v0/store/magnets/check", params, response)
+	response := &stremthru.Response[TrackMagnetData]{}
+	res, err := c.Request("POST", "/v0/store/magnets/check", ctx, response)
 	return request.NewAPIResponse(res, response.Data), err
 }
 
 type ListTorrentsByStremIdParams struct {
-	request.Ctx
+	Ctx
 	SId              string
 	LocalOnly        bool
 	OriginInstanceId string
@@ -249,14 +205,14 @@ func (c APIClient) ListTorrents(params *ListTorrentsByStremIdParams) (request.AP
 		params.Headers.Set(server.HEADER_ORIGIN_INSTANCE_ID, config.InstanceId)
 	}
 
-	response := &Response[ListTorrentsByStremIdData]{}
+	response := &stremthru.Response[ListTorrentsByStremIdData]{}
 	res, err := c.Request("GET", "/v0/torrents", params, response)
 
 	return request.NewAPIResponse(res, response.Data), err
 }
 
 type PushTorrentsParams struct {
-	request.Ctx
+	Ctx
 	Items []torrent_info.TorrentItem `json:"items"`
 }
 
@@ -265,29 +221,39 @@ type PushTorrentsData struct{}
 func (c APIClient) PushTorrents(params *PushTorrentsParams) (request.APIResponse[PushTorrentsData], error) {
 	params.JSON = params
 
-	response := &Response[PushTorrentsData]{}
+	response := &stremthru.Response[PushTorrentsData]{}
 	res, err := c.Request("POST", "/v0/torrents", params, response)
 	return request.NewAPIResponse(res, response.Data), err
 }
 
 type FetchLetterboxdListParams struct {
-	request.Ctx
+	Ctx
 	ListId string
 }
 
 func (c APIClient) FetchLetterboxdList(params *FetchLetterboxdListParams) (request.APIResponse[meta_type.List], error) {
-	response := &Response[meta_type.List]{}
-	res, err := c.Request("GET", "/v0/meta/letterboxd/lists/"+params.ListId, params, response)
-	return request.NewAPIResponse(res, response.Data), err
+	res, err := c.client.Meta.GetLetterboxdList(&stremthru.GetLetterboxdListParams{
+		Ctx:    params.Ctx,
+		ListId: params.ListId,
+	})
+	if err != nil {
+		err = upstreamErrorWithCause(err)
+	}
+	return toAPIResponse(res), err
 }
 
 type FetchLetterboxdUserWatchlistParams struct {
-	request.Ctx
+	Ctx
 	UserId string
 }
 
 func (c APIClient) FetchLetterboxdUserWatchlist(params *FetchLetterboxdUserWatchlistParams) (request.APIResponse[meta_type.List], error) {
-	response := &Response[meta_type.List]{}
-	res, err := c.Request("GET", "/v0/meta/letterboxd/users/"+params.UserId+"/lists/watchlist", params, response)
-	return request.NewAPIResponse(res, response.Data), err
+	res, err := c.client.Meta.GetLetterboxdUserWatchlist(&stremthru.GetLetterboxdUserWatchlistParams{
+		Ctx:    params.Ctx,
+		UserId: params.UserId,
+	})
+	if err != nil {
+		err = upstreamErrorWithCause(err)
+	}
+	return toAPIResponse(res), err
 }
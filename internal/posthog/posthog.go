@@ -60,6 +60,9 @@ func Init() posthog.Client {
 }
 
 func Close() error {
+	if client == nil {
+		return nil
+	}
 	return client.Close()
 }
 
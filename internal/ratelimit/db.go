@@ -148,6 +148,10 @@ func Update(id string, name string, limit int, window string) (*RateLimitConfig,
 	if err != nil {
 		return nil, err
 	}
+	cachedLimiterById.Delete(id)
+	for _, route := range Routes {
+		routeLimiterCache.Remove(string(route))
+	}
 
 	return GetById(id)
 }
@@ -159,7 +163,11 @@ var query_delete = fmt.Sprintf(
 )
 
 func Delete(id string) error {
+	if err := deleteRoutesByConfigId(id); err != nil {
+		return err
+	}
 	_, err := db.Exec(query_delete, id)
+	cachedLimiterById.Delete(id)
 	return err
 }
 
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/server"
+)
+
+func toSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func SetHeaders(w http.ResponseWriter, result *RouteResult) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", toSeconds(result.ResetAfter))
+	h.Set("RateLimit-Policy", strconv.Itoa(result.Limit)+";w="+toSeconds(result.Window))
+	if !result.Allowed {
+		h.Set("Retry-After", toSeconds(result.RetryAfter))
+	}
+}
+
+// Applies the rate limit attached to `route` and sets the response headers.
+// Returns `false` if the request should be rejected; sending the response
+// is left to the caller. Lookup errors are logged and let the request
+// through.
+func CheckRoute(w http.ResponseWriter, r *http.Request, route Route, key RouteKey) bool {
+	result, err := TryRoute(route, key)
+	if err != nil {
+		server.GetReqCtx(r).Log.Error("failed to check rate limit", "error", err, "route", route)
+		return true
+	}
+	if result == nil {
+		return true
+	}
+	SetHeaders(w, result)
+	return result.Allowed
+}
@@ -0,0 +1,283 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+// Inbound routes a rate limit config can be attached to.
+type Route string
+
+const (
+	RouteStore       Route = "store"        // /v0/store/*
+	RouteStremioTorz Route = "stremio_torz" // /stremio/torz
+	RouteTorznab     Route = "torznab"      // /v0/torznab/api
+)
+
+var Routes = []Route{RouteStore, RouteStremioTorz, RouteTorznab}
+
+func (r Route) IsValid() bool {
+	return slices.Contains(Routes, r)
+}
+
+type KeyBy string
+
+const (
+	KeyByUser     KeyBy = "user"
+	KeyByIP       KeyBy = "ip"
+	KeyByUserData KeyBy = "userdata"
+)
+
+var KeyBys = []KeyBy{KeyByUser, KeyByIP, KeyByUserData}
+
+func (k KeyBy) IsValid() bool {
+	return slices.Contains(KeyBys, k)
+}
+
+const RouteTableName = "rate_limit_route"
+
+type RouteConfig struct {
+	Route             Route
+	KeyBy             KeyBy
+	RateLimitConfigId string
+	CAt               db.Timestamp
+	UAt               db.Timestamp
+}
+
+var RouteColumn = struct {
+	Route             string
+	KeyBy             string
+	RateLimitConfigId string
+	CAt               string
+	UAt               string
+}{
+	Route:             "route",
+	KeyBy:             "key_by",
+	RateLimitConfigId: "rate_limit_config_id",
+	CAt:               "cat",
+	UAt:               "uat",
+}
+
+var routeColumns = []string{
+	RouteColumn.Route,
+	RouteColumn.KeyBy,
+	RouteColumn.RateLimitConfigId,
+	RouteColumn.CAt,
+	RouteColumn.UAt,
+}
+
+var query_get_all_routes = fmt.Sprintf(
+	`SELECT %s FROM %s ORDER BY %s`,
+	db.JoinColumnNames(routeColumns...),
+	RouteTableName,
+	RouteColumn.Route,
+)
+
+func GetAllRoutes() ([]RouteConfig, error) {
+	rows, err := db.Query(query_get_all_routes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RouteConfig{}
+	for rows.Next() {
+		item := RouteConfig{}
+		if err := rows.Scan(&item.Route, &item.KeyBy, &item.RateLimitConfigId, &item.CAt, &item.UAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+var query_get_route = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	db.JoinColumnNames(routeColumns...),
+	RouteTableName,
+	RouteColumn.Route,
+)
+
+func GetRoute(route Route) (*RouteConfig, error) {
+	row := db.QueryRow(query_get_route, route)
+
+	item := RouteConfig{}
+	if err := row.Scan(&item.Route, &item.KeyBy, &item.RateLimitConfigId, &item.CAt, &item.UAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+var query_upsert_route = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (?,?,?) ON CONFLICT (%s) DO UPDATE SET %s`,
+	RouteTableName,
+	db.JoinColumnNames(
+		RouteColumn.Route,
+		RouteColumn.KeyBy,
+		RouteColumn.RateLimitConfigId,
+	),
+	RouteColumn.Route,
+	strings.Join([]string{
+		fmt.Sprintf(`%s = EXCLUDED.%s`, RouteColumn.KeyBy, RouteColumn.KeyBy),
+		fmt.Sprintf(`%s = EXCLUDED.%s`, RouteColumn.RateLimitConfigId, RouteColumn.RateLimitConfigId),
+		fmt.Sprintf(`%s = %s`, RouteColumn.UAt, db.CurrentTimestamp),
+	}, ", "),
+)
+
+func UpsertRoute(route Route, keyBy KeyBy, rateLimitConfigId string) (*RouteConfig, error) {
+	if _, err := db.Exec(query_upsert_route, route, keyBy, rateLimitConfigId); err != nil {
+		return nil, err
+	}
+	routeLimiterCache.Remove(string(route))
+	return GetRoute(route)
+}
+
+var query_delete_route = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ?`,
+	RouteTableName,
+	RouteColumn.Route,
+)
+
+func DeleteRoute(route Route) error {
+	if _, err := db.Exec(query_delete_route, route); err != nil {
+		return err
+	}
+	routeLimiterCache.Remove(string(route))
+	return nil
+}
+
+var query_delete_routes_by_config_id = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ?`,
+	RouteTableName,
+	RouteColumn.RateLimitConfigId,
+)
+
+func deleteRoutesByConfigId(id string) error {
+	if _, err := db.Exec(query_delete_routes_by_config_id, id); err != nil {
+		return err
+	}
+	for _, route := range Routes {
+		routeLimiterCache.Remove(string(route))
+	}
+	return nil
+}
+
+type routeLimiter struct {
+	keyBy   KeyBy
+	limiter *Limiter
+}
+
+// `limiter` is `nil` for routes without a config. Short lifetime, so that
+// changes made on other instances are picked up.
+var routeLimiterCache = cache.NewLRUCache[routeLimiter](&cache.CacheConfig{
+	Lifetime:      1 * time.Minute,
+	Name:          "rate_limit_route",
+	LocalCapacity: 16,
+})
+
+func getRouteLimiter(route Route) (*routeLimiter, error) {
+	rl := routeLimiter{}
+	if routeLimiterCache.Get(string(route), &rl) {
+		return &rl, nil
+	}
+
+	conf, err := GetRoute(route)
+	if err != nil {
+		return nil, err
+	}
+	if conf != nil {
+		limiter, err := NewLimiterById(conf.RateLimitConfigId)
+		if err != nil {
+			return nil, err
+		}
+		rl.keyBy = conf.KeyBy
+		rl.limiter = limiter
+	}
+
+	if err := routeLimiterCache.Add(string(route), rl); err != nil {
+		return nil, err
+	}
+	return &rl, nil
+}
+
+type RouteKey struct {
+	User     string
+	IP       string
+	UserData string
+}
+
+func (k RouteKey) get(keyBy KeyBy) string {
+	switch keyBy {
+	case KeyByUser:
+		if k.User != "" {
+			return "user:" + k.User
+		}
+	case KeyByUserData:
+		if k.UserData != "" {
+			hash := sha256.Sum256([]byte(k.UserData))
+			return "userdata:" + hex.EncodeToString(hash[:16])
+		}
+	}
+	return "ip:" + k.IP
+}
+
+type RouteResult struct {
+	Allowed    bool
+	Limit      int
+	Window     time.Duration
+	Remaining  int
+	RetryAfter time.Duration
+	// Until the limit is fully restored.
+	ResetAfter time.Duration
+}
+
+// Returns `nil` if no rate limit config is attached to `route`. Falls back
+// to the IP when the value for the configured key is missing.
+func TryRoute(route Route, key RouteKey) (*RouteResult, error) {
+	rl, err := getRouteLimiter(route)
+	if err != nil {
+		return nil, err
+	}
+	if rl.limiter == nil {
+		return nil, nil
+	}
+
+	result, err := rl.limiter.Try("route:" + string(route) + ":" + key.get(rl.keyBy))
+	if err != nil {
+		return nil, err
+	}
+
+	conf := rl.limiter.Config()
+	window, err := conf.ParseWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := int(max(0, result.Remaining))
+	res := &RouteResult{
+		Allowed:    result.Allowed,
+		Limit:      conf.Limit,
+		Window:     window,
+		Remaining:  remaining,
+		RetryAfter: result.RetryAfter,
+		ResetAfter: window / time.Duration(conf.Limit) * time.Duration(conf.Limit-remaining),
+	}
+	if !res.Allowed {
+		res.ResetAfter = max(res.ResetAfter, res.RetryAfter)
+	}
+	return res, nil
+}
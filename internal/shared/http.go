@@ -15,6 +15,7 @@ import (
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/openapi"
 	"github.com/MunifTanjim/stremthru/internal/server"
 )
 
@@ -38,22 +39,40 @@ func GetQueryInt(queryParams url.Values, name string, defaultValue int) (int, er
 	return defaultValue, nil
 }
 
+// Decodes the body into `payload`, after validating it against the
+// OpenAPI schema of the payload type.
 func ReadRequestBodyJSON[T any](r *http.Request, payload T) error {
 	contentType := r.Header.Get("Content-Type")
 	if !strings.Contains(contentType, "application/json") {
 		return ErrorUnsupportedMediaType(r)
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&payload)
-	if err == nil {
-		return err
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return ErrorBadRequest(r, "failed to read body").WithCause(err)
 	}
-	if err == io.EOF {
+	if len(bytes.TrimSpace(body)) == 0 {
 		return ErrorBadRequest(r, "missing body")
 	}
-	error := core.NewAPIError("failed to decode body")
-	error.Cause = err
-	return error
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return ErrorBadRequest(r, "failed to decode body").WithCause(err)
+	}
+	if errs := openapi.Spec.Validate(openapi.Spec.SchemaOf(payload), value); len(errs) > 0 {
+		e := ErrorBadRequest(r, "invalid body")
+		for _, err := range errs {
+			e.Append(core.ErrorDetail{Location: err.Location, Message: err.Message})
+		}
+		return e
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		error := core.NewAPIError("failed to decode body")
+		error.Cause = err
+		return error
+	}
+	return nil
 }
 
 type response struct {
@@ -146,6 +165,11 @@ func copyHeaders(src http.Header, dest http.Header, stripIpHeaders bool) {
 	}
 }
 
+// Copies request headers, except the ones that leak client ip
+func CopyRequestHeaders(src http.Header, dest http.Header) {
+	copyHeaders(src, dest, true)
+}
+
 var proxyHttpClientByTunnelType = map[config.TunnelType]*http.Client{
 	config.TUNNEL_TYPE_NONE: func() *http.Client {
 		transport := config.DefaultHTTPTransport.Clone()
@@ -170,8 +194,12 @@ var proxyHttpClientByTunnelType = map[config.TunnelType]*http.Client{
 	}(),
 }
 
+func GetProxyHttpClient(tunnelType config.TunnelType) *http.Client {
+	return proxyHttpClientByTunnelType[tunnelType]
+}
+
 func ProxyResponse(w http.ResponseWriter, r *http.Request, url string, tunnelType config.TunnelType) (bytesWritten int64, err error) {
-	request, err := http.NewRequest(r.Method, url, nil)
+	request, err := http.NewRequestWithContext(r.Context(), r.Method, url, nil)
 	if err != nil {
 		e := ErrorInternalServerError(r, "failed to create request")
 		e.Cause = err
@@ -244,6 +272,11 @@ func ExtractRequestBaseURL(r *http.Request) *url.URL {
 	}
 }
 
+// Same as `core.GetPeerIP`, trusting `STREMTHRU_TRUSTED_PROXY`.
+func GetPeerIP(r *http.Request) string {
+	return core.GetPeerIP(r, config.TrustedProxy.Contains)
+}
+
 func GetClientIP(r *http.Request, ctx *context.StoreContext) string {
 	if !ctx.IsProxyAuthorized {
 		return core.GetClientIP(r)
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/logger"
+	"github.com/MunifTanjim/stremthru/internal/metrics"
 	"github.com/MunifTanjim/stremthru/internal/server"
+	"github.com/MunifTanjim/stremthru/internal/tracing"
 	"github.com/rs/xid"
 )
 
@@ -41,6 +43,12 @@ func RootServerContext(next http.Handler) http.Handler {
 		ctx := &server.ReqCtx{StartTime: time.Now(), ReqMethod: r.Method, ReqPath: r.URL.Path, ReqQuery: r.URL.Query()}
 		r = server.SetReqCtx(r, ctx)
 
+		spanCtx, span := tracing.StartServerSpan(r)
+		r = r.WithContext(spanCtx)
+		defer func() {
+			tracing.EndServerSpan(span, ctx.ReqMethod, r.Pattern, rw.getStatusCode())
+		}()
+
 		defer func() {
 			if err := recover(); err != nil {
 				buf := make([]byte, 2048)
@@ -50,6 +58,7 @@ func RootServerContext(next http.Handler) http.Handler {
 				reqLog.Error("panic recovered", "error", err, "stack", string(buf), "req.id", ctx.RequestId)
 				ErrorInternalServerError(r, "").Send(rw, r)
 				logRequest(rw, r)
+				metrics.ObserveHTTPRequest(r.Pattern, ctx.ReqMethod, rw.getStatusCode(), time.Since(ctx.StartTime))
 			}
 		}()
 
@@ -61,9 +70,13 @@ func RootServerContext(next http.Handler) http.Handler {
 		w.Header().Set("Request-ID", ctx.RequestId)
 
 		ctx.Log = logger.New(r.Context(), "req.id", ctx.RequestId)
+		if traceId, _ := tracing.GetIds(r.Context()); traceId != "" {
+			w.Header().Set("Trace-ID", traceId)
+		}
 
 		next.ServeHTTP(rw, r)
 		logRequest(rw, r)
+		metrics.ObserveHTTPRequest(r.Pattern, ctx.ReqMethod, rw.getStatusCode(), time.Since(ctx.StartTime))
 	})
 }
 
@@ -84,6 +97,10 @@ func (rw *responseWriter) WriteHeader(statusCode int) {
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func (rw *responseWriter) getStatusCode() int {
 	return rw.statusCode
 }
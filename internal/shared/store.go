@@ -1,9 +1,10 @@
 package shared
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,6 +13,8 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/cache"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/kv"
+	"github.com/MunifTanjim/stremthru/internal/metrics"
 	"github.com/MunifTanjim/stremthru/store"
 
 	"github.com/MunifTanjim/stremthru/store/alldebrid"
@@ -37,47 +40,47 @@ import (
 */
 
 var adStore = alldebrid.NewStoreClient(&alldebrid.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("alldebrid")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("alldebrid")), string(store.StoreCodeAllDebrid)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var drStore = debrider.NewStoreClient(&debrider.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("debrider")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("debrider")), string(store.StoreCodeDebrider)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var dlStore = debridlink.NewStoreClient(&debridlink.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("debridlink")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("debridlink")), string(store.StoreCodeDebridLink)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var edStore = easydebrid.NewStoreClient(&easydebrid.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("easydebrid")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("easydebrid")), string(store.StoreCodeEasyDebrid)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var pmStore = premiumize.NewStoreClient(&premiumize.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("premiumize")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("premiumize")), string(store.StoreCodePremiumize)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var ppStore = pikpak.NewStoreClient(&pikpak.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("pikpak")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("pikpak")), string(store.StoreCodePikPak)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var ocStore = offcloud.NewStoreClient(&offcloud.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("offcloud")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("offcloud")), string(store.StoreCodeOffcloud)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
 var rdStore = realdebrid.NewStoreClient(&realdebrid.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("realdebrid")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("realdebrid")), string(store.StoreCodeRealDebrid)),
 	UserAgent:  "Mozilla/5.0",
 })
 
 var tbStore = torbox.NewStoreClient(&torbox.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("torbox")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("torbox")), string(store.StoreCodeTorBox)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
@@ -88,7 +91,7 @@ var tbStore = torbox.NewStoreClient(&torbox.StoreClientConfig{
 */
 
 var sdStore = seedr.NewStoreClient(&seedr.StoreClientConfig{
-	HTTPClient: config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("seedr")),
+	HTTPClient: metrics.InstrumentStoreHTTPClient(config.GetHTTPClient(config.StoreTunnel.GetTypeForAPI("seedr")), string(store.StoreCodeSeedr)),
 	UserAgent:  config.StoreClientUserAgent,
 })
 
@@ -154,7 +157,7 @@ func GetStoreByCode(code string) store.Store {
 
 /*
 |--------------------------------------------------------------------------
-| Proxy Logic (unchanged from original)
+| Proxy Link
 |--------------------------------------------------------------------------
 */
 
@@ -162,13 +165,18 @@ type proxyLinkTokenData struct {
 	EncLink    string            `json:"enc_link"`
 	EncFormat  string            `json:"enc_format"`
 	TunnelType config.TunnelType `json:"tunt,omitempty"`
+	// ip address or cidr prefix the token is bound to
+	ClientIP string `json:"cip,omitempty"`
 }
 
 type proxyLinkData struct {
-	User    string            `json:"u"`
-	Value   string            `json:"v"`
-	Headers map[string]string `json:"reqh,omitempty"`
-	TunT    config.TunnelType `json:"tunt,omitempty"`
+	User      string            `json:"u"`
+	Value     string            `json:"v"`
+	Headers   map[string]string `json:"reqh,omitempty"`
+	TunT      config.TunnelType `json:"tunt,omitempty"`
+	ClientIP  string            `json:"cip,omitempty"`
+	IssuedAt  int64             `json:"iat,omitempty"`
+	ExpiresAt int64             `json:"exp,omitempty"`
 }
 
 var proxyLinkTokenCache = func() cache.Cache[proxyLinkData] {
@@ -178,10 +186,233 @@ var proxyLinkTokenCache = func() cache.Cache[proxyLinkData] {
 	})
 }()
 
-/*
-|--------------------------------------------------------------------------
-| The rest of the file stays unchanged
-|--------------------------------------------------------------------------
-| (CreateProxyLink, GenerateStremThruLink, UnwrapProxyLinkToken etc.)
-|--------------------------------------------------------------------------
-*/
\ No newline at end of file
+// value is the unix timestamp, tokens issued before it are revoked
+var proxyLinkRevocationStore = kv.NewKVStore[int64](&kv.KVStoreConfig{
+	Type: "proxylinkrevoke",
+})
+
+// shared through redis, if available
+var proxyLinkRevocationCache = func() cache.Cache[int64] {
+	return cache.NewCache[int64](&cache.CacheConfig{
+		Name:     "store:proxyLinkRevocation",
+		Lifetime: 1 * time.Minute,
+	})
+}()
+
+func getProxyLinkRevokedAt(user string) (int64, error) {
+	var revokedAt int64
+	if proxyLinkRevocationCache.Get(user, &revokedAt) {
+		return revokedAt, nil
+	}
+	if err := proxyLinkRevocationStore.GetValue(user, &revokedAt); err != nil {
+		return 0, err
+	}
+	proxyLinkRevocationCache.Add(user, revokedAt)
+	return revokedAt, nil
+}
+
+// Revokes every proxy link issued for the user till now
+func RevokeProxyLinks(user string) error {
+	revokedAt := time.Now().Unix()
+	if err := proxyLinkRevocationStore.Set(user, revokedAt); err != nil {
+		return err
+	}
+	// also drops the stale value cached by other instances
+	proxyLinkRevocationCache.Remove(user)
+	return nil
+}
+
+func ParseProxyLinkClientIP(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return "", err
+		}
+		return prefix.Masked().String(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return "", err
+	}
+	return addr.Unmap().String(), nil
+}
+
+func isProxyLinkClientIPAllowed(boundIP string, clientIP string) bool {
+	if boundIP == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	if prefix, err := netip.ParsePrefix(boundIP); err == nil {
+		return prefix.Contains(addr)
+	}
+	bound, err := netip.ParseAddr(boundIP)
+	return err == nil && bound == addr
+}
+
+func CreateProxyLink(r *http.Request, link string, headers map[string]string, tunnelType config.TunnelType, expiresIn time.Duration, clientIP string, user, password string, shouldEncrypt bool, filename string) (string, error) {
+	if len(headers) > 0 {
+		for k, v := range headers {
+			link += "\n" + k + ": " + v
+		}
+	}
+
+	encLink := link
+	encFormat := ""
+	if shouldEncrypt {
+		encryptedLink, err := core.Encrypt(password, link)
+		if err != nil {
+			return "", err
+		}
+		encLink = encryptedLink
+		encFormat = core.EncryptionFormat
+	}
+
+	claims := core.JWTClaims[proxyLinkTokenData]{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   "stremthru",
+			Subject:  user,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		Data: &proxyLinkTokenData{
+			EncLink:    encLink,
+			EncFormat:  encFormat,
+			TunnelType: tunnelType,
+			ClientIP:   clientIP,
+		},
+	}
+	if expiresIn != 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(expiresIn))
+	}
+
+	token, err := core.CreateJWT(password, claims)
+	if err != nil {
+		return "", err
+	}
+
+	pLink := ExtractRequestBaseURL(r).JoinPath("/v0/proxy", token)
+	if filename == "" {
+		link, _, _ := strings.Cut(link, "\n")
+		if u, err := url.Parse(link); err == nil {
+			filename = filepath.Base(u.Path)
+		}
+	}
+	if filename != "" && filename != "." && filename != "/" {
+		pLink = pLink.JoinPath(filename)
+	}
+
+	return pLink.String(), nil
+}
+
+func newProxyLinkUnauthorizedError(msg string, cause error) *core.APIError {
+	err := core.NewAPIError(msg)
+	err.StatusCode = http.StatusUnauthorized
+	err.Code = core.ErrorCodeUnauthorized
+	err.Cause = cause
+	return err
+}
+
+func verifyProxyLinkData(r *http.Request, data *proxyLinkData) error {
+	if data.ExpiresAt != 0 && time.Now().Unix() >= data.ExpiresAt {
+		return newProxyLinkUnauthorizedError("expired token", nil)
+	}
+	if !isProxyLinkClientIPAllowed(data.ClientIP, GetPeerIP(r)) {
+		return newProxyLinkUnauthorizedError("token not valid for client ip", nil)
+	}
+	revokedAt, err := getProxyLinkRevokedAt(data.User)
+	if err != nil {
+		return err
+	}
+	if revokedAt != 0 && data.IssuedAt <= revokedAt {
+		return newProxyLinkUnauthorizedError("revoked token", nil)
+	}
+	return nil
+}
+
+func UnwrapProxyLinkToken(r *http.Request, encodedToken string) (user string, link string, headers map[string]string, tunnelType config.TunnelType, err error) {
+	data := proxyLinkData{}
+	if !proxyLinkTokenCache.Get(encodedToken, &data) {
+		claims := &core.JWTClaims[proxyLinkTokenData]{}
+		_, err := core.ParseJWT(func(t *jwt.Token) (any, error) {
+			password := config.ProxyAuthPassword.GetPassword(claims.Subject)
+			if password == "" {
+				return nil, errors.New("unknown user")
+			}
+			return []byte(password), nil
+		}, encodedToken, claims, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil {
+			return "", "", nil, "", newProxyLinkUnauthorizedError("invalid token", err)
+		}
+		if claims.Data == nil {
+			return "", "", nil, "", newProxyLinkUnauthorizedError("malformed token", nil)
+		}
+
+		data.User = claims.Subject
+		data.TunT = claims.Data.TunnelType
+		data.ClientIP = claims.Data.ClientIP
+		if claims.IssuedAt != nil {
+			data.IssuedAt = claims.IssuedAt.Unix()
+		}
+		if claims.ExpiresAt != nil {
+			data.ExpiresAt = claims.ExpiresAt.Unix()
+		}
+
+		value := claims.Data.EncLink
+		if claims.Data.EncFormat == core.EncryptionFormat {
+			value, err = core.Decrypt(config.ProxyAuthPassword.GetPassword(data.User), value)
+			if err != nil {
+				return "", "", nil, "", newProxyLinkUnauthorizedError("invalid token", err)
+			}
+		}
+
+		value, headersBlob, hasHeaders := strings.Cut(value, "\n")
+		data.Value = value
+		if hasHeaders {
+			data.Headers = map[string]string{}
+			for header := range strings.SplitSeq(headersBlob, "\n") {
+				if k, v, ok := strings.Cut(header, ": "); ok {
+					data.Headers[k] = v
+				}
+			}
+		}
+
+		proxyLinkTokenCache.Add(encodedToken, data)
+	}
+
+	if err := verifyProxyLinkData(r, &data); err != nil {
+		return "", "", nil, "", err
+	}
+
+	return data.User, data.Value, data.Headers, data.TunT, nil
+}
+
+func GenerateStremThruLink(r *http.Request, ctx *context.StoreContext, link string) (*store.GenerateLinkData, error) {
+	params := &store.GenerateLinkParams{}
+	params.APIKey = ctx.StoreAuthToken
+	params.Context = ctx.Context
+	params.Link = link
+	params.ClientIP = ctx.ClientIP
+
+	data, err := ctx.Store.GenerateLink(params)
+	if err != nil {
+		return nil, err
+	}
+
+	storeName := string(ctx.Store.GetName())
+	if ctx.IsProxyAuthorized && config.StoreContentProxy.IsEnabled(storeName) && ctx.StoreAuthToken == config.StoreAuthToken.GetToken(ctx.ProxyAuthUser, storeName) {
+		tunnelType := config.StoreTunnel.GetTypeForStream(storeName)
+		proxyLink, err := CreateProxyLink(r, data.Link, nil, tunnelType, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, "")
+		if err != nil {
+			return nil, err
+		}
+		data.Link = proxyLink
+	}
+
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProxyLinkClientIP(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out string
+		err bool
+	}{
+		{"", "", false},
+		{"1.2.3.4", "1.2.3.4", false},
+		{"::ffff:1.2.3.4", "1.2.3.4", false},
+		{"10.0.1.7/16", "10.0.0.0/16", false},
+		{"2001:db8::1/64", "2001:db8::/64", false},
+		{"1.2.3", "", true},
+		{"1.2.3.4/33", "", true},
+	} {
+		out, err := ParseProxyLinkClientIP(tc.in)
+		if tc.err {
+			assert.Error(t, err, tc.in)
+		} else {
+			assert.NoError(t, err, tc.in)
+			assert.Equal(t, tc.out, out)
+		}
+	}
+}
+
+func TestIsProxyLinkClientIPAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		bound    string
+		clientIP string
+		allowed  bool
+	}{
+		{"", "1.2.3.4", true},
+		{"1.2.3.4", "1.2.3.4", true},
+		{"1.2.3.4", "1.2.3.5", false},
+		{"1.2.3.4", "::ffff:1.2.3.4", true},
+		{"10.0.0.0/16", "10.0.255.1", true},
+		{"10.0.0.0/16", "10.1.0.1", false},
+		{"1.2.3.4", "", false},
+	} {
+		assert.Equal(t, tc.allowed, isProxyLinkClientIPAllowed(tc.bound, tc.clientIP), tc.bound+" "+tc.clientIP)
+	}
+}
@@ -25,6 +25,14 @@ func decrypt(value string) (string, error) {
 	return core.Decrypt(config.VaultSecret, value)
 }
 
+func reencrypt(oldSecret, newSecret, value string) (string, error) {
+	decrypted, err := core.Decrypt(oldSecret, value)
+	if err != nil {
+		return "", err
+	}
+	return core.Encrypt(newSecret, decrypted)
+}
+
 const TableName = "stremio_account"
 
 type StremioAccount struct {
@@ -308,3 +316,52 @@ func Delete(id string) error {
 	}
 	return nil
 }
+
+var query_set_secrets = fmt.Sprintf(
+	`UPDATE %s SET %s = ?, %s = ? WHERE %s = ?`,
+	TableName,
+	Column.Password,
+	Column.Token,
+	Column.Id,
+)
+
+// Re-encrypts the password and token of every account from `oldSecret`
+// to `newSecret`, within `tx`.
+func RotateSecret(tx *db.Tx, oldSecret, newSecret string) (int, error) {
+	rows, err := tx.Query(query_get_all)
+	if err != nil {
+		return 0, err
+	}
+	items := []StremioAccount{}
+	for rows.Next() {
+		item := StremioAccount{}
+		if err := rows.Scan(&item.Id, &item.Email, &item.Password, &item.Token, &item.TokenEAt, &item.CAt, &item.UAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		password, err := reencrypt(oldSecret, newSecret, item.Password)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt password for %s: %w", item.Id, err)
+		}
+		token := item.Token
+		if token != "" {
+			token, err = reencrypt(oldSecret, newSecret, token)
+			if err != nil {
+				return 0, fmt.Errorf("failed to re-encrypt token for %s: %w", item.Id, err)
+			}
+		}
+		if _, err := tx.Exec(query_set_secrets, password, token, item.Id); err != nil {
+			return 0, err
+		}
+	}
+	return len(items), nil
+}
@@ -171,6 +171,8 @@ func adjustClientIPHeader(params request.Ctx, clientIp string, r *http.Request)
 	}
 }
 
+var ErrInvalidManifest = errors.New("invalid manifest")
+
 type GetManifestParams struct {
 	request.Ctx
 	BaseURL  *url.URL
@@ -182,7 +184,7 @@ func (c Client) GetManifest(params *GetManifestParams) (request.APIResponse[stre
 	response := &stremio.Manifest{}
 	res, err := c.Request("GET", params.BaseURL.JoinPath("manifest.json"), params, response)
 	if err == nil && !response.IsValid() {
-		err = errors.New("invalid manifest")
+		err = ErrInvalidManifest
 	}
 	return request.NewAPIResponse(res, *response), err
 }
@@ -38,7 +38,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 	if action := stremio_shared.GetConfigureAction(r); action != "" {
 		switch action {
 		case "authorize":
-			if !IsPublicInstance {
+			if !IsPublicInstance() {
 				user := r.Form.Get("user")
 				pass := r.Form.Get("pass")
 				if pass == "" || config.AdminPassword.GetPassword(user) != pass {
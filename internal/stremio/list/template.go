@@ -408,7 +408,7 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 		td.Version = config.Version
 		td.IsTrusted = config.IsTrusted
 
-		td.CanAuthorize = !IsPublicInstance
+		td.CanAuthorize = !IsPublicInstance()
 		td.CanAddList = td.IsAuthed || len(td.Lists) < MaxPublicInstanceListCount
 		td.CanRemoveList = len(td.Lists) > 1
 
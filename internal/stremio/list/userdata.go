@@ -598,7 +598,7 @@ func getUserData(r *http.Request, isAuthed bool) (*UserData, error) {
 		}
 	}
 
-	if IsPublicInstance && len(ud.Lists) > MaxPublicInstanceListCount {
+	if IsPublicInstance() && len(ud.Lists) > MaxPublicInstanceListCount {
 		ud.Lists = ud.Lists[0:MaxPublicInstanceListCount]
 	}
 
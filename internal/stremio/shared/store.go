@@ -25,7 +25,7 @@ func GetStoreCodeOptions(includeP2P bool) []configure.ConfigOption {
 		{Value: "rd", Label: "RealDebrid"},
 		{Value: "tb", Label: "TorBox"},
 	}
-	if config.IsPublicInstance {
+	if config.IsPublicInstance() {
 		options[0].Disabled = true
 		options[0].Label = ""
 	}
@@ -46,6 +46,7 @@ func WaitForMagnetStatus(ctx *context.StoreContext, m *store.GetMagnetData, stat
 			ClientIP: ctx.ClientIP,
 		}
 		gmParams.APIKey = ctx.StoreAuthToken
+		gmParams.Context = ctx.Context
 		magnet, err := ctx.Store.GetMagnet(gmParams)
 		if err != nil {
 			return m, err
@@ -111,7 +111,7 @@ func handleRoot(w http.ResponseWriter, r *http.Request) {
 	if action := r.Header.Get("x-addon-configure-action"); action != "" {
 		switch action {
 		case "authorize":
-			if !IsPublicInstance {
+			if !IsPublicInstance() {
 				user := r.FormValue("user")
 				pass := r.FormValue("pass")
 				if pass == "" || config.AdminPassword.GetPassword(user) != pass {
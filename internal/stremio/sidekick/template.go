@@ -121,7 +121,7 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 	return stremio_template.GetExecutor("stremio/sidekick", func(td *TemplateData) *TemplateData {
 		td.StremThruAddons = stremio_shared.GetStremThruAddons()
 
-		td.CanAuthAdmin = !IsPublicInstance
+		td.CanAuthAdmin = !IsPublicInstance()
 
 		td.Version = config.Version
 		td.IsTrusted = config.IsTrusted
@@ -40,6 +40,7 @@ func getADWebDLsMeta(r *http.Request, ctx *context.StoreContext, idr *ParsedId,
 	if !adLinksCache.Get(cacheKey, &meta.Videos) {
 		params := &stremio_store_webdl.ListWebDLsParams{}
 		params.APIKey = ctx.StoreAuthToken
+		params.Context = ctx.Context
 		res, err := stremio_store_webdl.ListWebDLs(params, idr.storeName)
 		if err != nil {
 			log.Error("failed to list webdls", "error", err, "store.name", idr.storeName)
@@ -82,7 +83,7 @@ func getADWebDLsMeta(r *http.Request, ctx *context.StoreContext, idr *ParsedId,
 			if shouldCreateProxyLink {
 				videoTitle = "✨ " + videoTitle
 				if isDirectLink {
-					if proxyLink, err := shared.CreateProxyLink(r, stream.URL, nil, tunnelType, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, stream.BehaviorHints.Filename); err == nil {
+					if proxyLink, err := shared.CreateProxyLink(r, stream.URL, nil, tunnelType, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, stream.BehaviorHints.Filename); err == nil {
 						stream.URL = proxyLink
 					} else {
 						log.Error("failed to create proxy link, skipping file", "error", err, "store.name", storeName, "filename", stream.BehaviorHints.Filename)
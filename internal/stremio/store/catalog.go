@@ -1,6 +1,8 @@
 package stremio_store
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -32,6 +34,7 @@ var catalogCache = cache.NewCache[[]CachedCatalogItem](&cache.CacheConfig{
 	Lifetime:      config.Stremio.Store.CatalogCacheTime,
 	Name:          "stremio:store:catalog",
 	LocalCapacity: 2048,
+	Persist:       true,
 })
 
 func InvalidateCatalogCache(storeCode store.StoreCode, storeToken string) {
@@ -48,12 +51,12 @@ var max_fetch_list_items = config.Stremio.Store.CatalogItemLimit
 const fetch_list_limit = 500
 
 func getUsenetCatalogItems(s store.Store, storeToken string, clientIp string, idStoreCode string, log *logger.Logger) []CachedCatalogItem {
-	items := []CachedCatalogItem{}
-
 	idPrefix := getIdPrefix(idStoreCode)
 
 	cacheKey := getCatalogCacheKey(idStoreCode, storeToken)
-	if !catalogCache.Get(cacheKey, &items) {
+	items, _ := catalogCache.GetOrLoad(cacheKey, func() ([]CachedCatalogItem, error) {
+		items := []CachedCatalogItem{}
+
 		storeName := s.GetName()
 
 		offset := 0
@@ -95,19 +98,19 @@ func getUsenetCatalogItems(s store.Store, storeToken string, clientIp string, id
 
 			time.Sleep(500 * time.Millisecond)
 		}
-		catalogCache.Add(cacheKey, items)
-	}
+		return items, nil
+	})
 
 	return items
 }
 
 func getWebDLCatalogItems(s store.Store, storeToken string, clientIp string, idStoreCode string, log *logger.Logger) []CachedCatalogItem {
-	items := []CachedCatalogItem{}
-
 	idPrefix := getIdPrefix(idStoreCode)
 
 	cacheKey := getCatalogCacheKey(idStoreCode, storeToken)
-	if !catalogCache.Get(cacheKey, &items) {
+	items, _ := catalogCache.GetOrLoad(cacheKey, func() ([]CachedCatalogItem, error) {
+		items := []CachedCatalogItem{}
+
 		storeName := s.GetName()
 
 		offset := 0
@@ -149,8 +152,8 @@ func getWebDLCatalogItems(s store.Store, storeToken string, clientIp string, idS
 
 			time.Sleep(500 * time.Millisecond)
 		}
-		catalogCache.Add(cacheKey, items)
-	}
+		return items, nil
+	})
 
 	return items
 }
@@ -166,10 +169,10 @@ func getCatalogItems(s store.Store, storeToken string, clientIp string, idr *Par
 		return getWebDLCatalogItems(s, storeToken, clientIp, idStoreCode, log)
 	}
 
-	items := []CachedCatalogItem{}
-
 	cacheKey := getCatalogCacheKey(idStoreCode, storeToken)
-	if !catalogCache.Get(cacheKey, &items) {
+	items, _ := catalogCache.GetOrLoad(cacheKey, func() ([]CachedCatalogItem, error) {
+		items := []CachedCatalogItem{}
+
 		idPrefix := getIdPrefix(idStoreCode)
 
 		storeName := s.GetName()
@@ -231,9 +234,9 @@ func getCatalogItems(s store.Store, storeToken string, clientIp string, idr *Par
 
 			time.Sleep(500 * time.Millisecond)
 		}
-		catalogCache.Add(cacheKey, items)
 		go torrent_info.Upsert(tInfoItems, "", storeCode != store.StoreCodeRealDebrid)
-	}
+		return items, nil
+	})
 
 	return items
 }
@@ -277,7 +280,8 @@ func getCatalogCacheKey(idStoreCode, storeToken string) string {
 	idStoreCode = strings.TrimPrefix(idStoreCode, "st")
 	idStoreCode = strings.TrimPrefix(idStoreCode, "-")
 	idStoreCode = strings.TrimPrefix(idStoreCode, ":")
-	return idStoreCode + ":" + storeToken
+	hash := sha256.Sum256([]byte(storeToken))
+	return idStoreCode + ":" + hex.EncodeToString(hash[:])
 }
 
 var whitespacesRegex = regexp.MustCompile(`\s+`)
@@ -77,6 +77,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 	} else if token_config.Error == "" {
 		params := &store.GetUserParams{}
 		params.APIKey = ctx.StoreAuthToken
+		params.Context = ctx.Context
 		user, err := ctx.Store.GetUser(params)
 		if err != nil {
 			LogError(r, "failed to get user", err)
@@ -81,13 +81,14 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 			CLientIP: ctx.ClientIP,
 		}
 		rParams.APIKey = ctx.StoreAuthToken
+		rParams.Context = ctx.Context
 		var lerr error
 		data, err := stremio_store_usenet.GenerateLink(rParams, storeName)
 		if err == nil {
 			if config.StoreContentProxy.IsEnabled(string(storeName)) && ctx.StoreAuthToken == config.StoreAuthToken.GetToken(ctx.ProxyAuthUser, string(storeName)) {
 				if ctx.IsProxyAuthorized {
 					tunnelType := config.StoreTunnel.GetTypeForStream(string(ctx.Store.GetName()))
-					if proxyLink, err := shared.CreateProxyLink(r, data.Link, nil, tunnelType, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil {
+					if proxyLink, err := shared.CreateProxyLink(r, data.Link, nil, tunnelType, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil {
 						data.Link = proxyLink
 					} else {
 						lerr = err
@@ -112,6 +113,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 			CLientIP: ctx.ClientIP,
 		}
 		rParams.APIKey = ctx.StoreAuthToken
+		rParams.Context = ctx.Context
 		var lerr error
 		data, err := stremio_store_webdl.GenerateLink(rParams, storeName)
 		if err == nil {
@@ -122,7 +124,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 			if config.StoreContentProxy.IsEnabled(string(storeName)) && ctx.StoreAuthToken == config.StoreAuthToken.GetToken(ctx.ProxyAuthUser, string(storeName)) {
 				if ctx.IsProxyAuthorized {
 					tunnelType := config.StoreTunnel.GetTypeForStream(string(ctx.Store.GetName()))
-					if proxyLink, err := shared.CreateProxyLink(r, data.Link, nil, tunnelType, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil {
+					if proxyLink, err := shared.CreateProxyLink(r, data.Link, nil, tunnelType, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil {
 						data.Link = proxyLink
 					} else {
 						lerr = err
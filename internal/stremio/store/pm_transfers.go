@@ -40,6 +40,7 @@ func getPMWebDLsMeta(r *http.Request, ctx *context.StoreContext, idr *ParsedId,
 	if !pmItemsCache.Get(cacheKey, &meta.Videos) {
 		params := &stremio_store_webdl.ListWebDLsParams{}
 		params.APIKey = ctx.StoreAuthToken
+		params.Context = ctx.Context
 		res, err := stremio_store_webdl.ListWebDLs(params, idr.storeName)
 		if err != nil {
 			log.Error("failed to list webdls", "error", err, "store.name", idr.storeName)
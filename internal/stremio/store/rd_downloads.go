@@ -52,6 +52,7 @@ func getRDWebDLsMeta(r *http.Request, ctx *context.StoreContext, idr *ParsedId)
 				Offset: offset,
 			}
 			params.APIKey = ctx.StoreAuthToken
+			params.Context = ctx.Context
 			res, err := rdClient.ListDownloads(params)
 			if err != nil {
 				log.Error("failed to list downloads", "error", err, "store.name", storeName)
@@ -76,7 +77,7 @@ func getRDWebDLsMeta(r *http.Request, ctx *context.StoreContext, idr *ParsedId)
 				}
 				videoTitle := getMetaPreviewDescriptionForWebDL(dl.Host, dl.Filename, true) + "\n📄 " + dl.Filename
 				if shouldCreateProxyLink {
-					if proxyLink, err := shared.CreateProxyLink(r, stream.URL, nil, tunnelType, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, dl.Filename); err == nil {
+					if proxyLink, err := shared.CreateProxyLink(r, stream.URL, nil, tunnelType, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, dl.Filename); err == nil {
 						stream.URL = proxyLink
 						videoTitle = "✨ " + videoTitle
 					} else {
@@ -21,7 +21,7 @@ func getStoreNameConfig(defaultValue string) configure.Config {
 		{Value: "realdebrid", Label: "RealDebrid"},
 		{Value: "torbox", Label: "TorBox"},
 	}
-	if config.IsPublicInstance {
+	if config.IsPublicInstance() {
 		options[0].Disabled = true
 		options[0].Label = ""
 	}
@@ -31,7 +31,7 @@ func getStoreNameConfig(defaultValue string) configure.Config {
 		Default:  defaultValue,
 		Title:    "Store Name",
 		Options:  options,
-		Required: config.IsPublicInstance,
+		Required: config.IsPublicInstance(),
 	}
 	return config
 }
@@ -106,7 +106,8 @@ func (uderr *userDataError) Error() string {
 func (ud UserData) GetRequestContext(r *http.Request, idr *ParsedId) (*context.StoreContext, error) {
 	rCtx := server.GetReqCtx(r)
 	ctx := &context.StoreContext{
-		Log: rCtx.Log,
+		Context: context.DetachContext(r),
+		Log:     rCtx.Log,
 	}
 
 	storeToken := ud.StoreToken
@@ -117,6 +118,9 @@ func (ud UserData) GetRequestContext(r *http.Request, idr *ParsedId) (*context.S
 		}
 		password := config.ProxyAuthPassword.GetPassword(user.Username)
 		if password != "" && password == user.Password {
+			if !config.UserFeature.IsEnabled(user.Username, config.FeatureStremioStore) {
+				return ctx, &userDataError{storeToken: "feature not allowed"}
+			}
 			ctx.IsProxyAuthorized = true
 			ctx.ProxyAuthUser = user.Username
 			ctx.ProxyAuthPassword = user.Password
@@ -69,6 +69,11 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkRateLimit(w, r, ctx) {
+		store_video.Redirect(store_video.StoreVideoName429, w, r)
+		return
+	}
+
 	sid := r.PathValue("stremId")
 
 	s := ud.GetStoreByCode(r.PathValue("storeCode"))
@@ -90,6 +95,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 			ClientIP: ctx.ClientIP,
 		}
 		amParams.APIKey = ctx.StoreAuthToken
+		amParams.Context = ctx.Context
 		if encodedLink == "" {
 			amParams.Magnet = magnetHash
 		} else {
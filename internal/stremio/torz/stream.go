@@ -1,6 +1,7 @@
 package stremio_torz
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
@@ -15,7 +16,9 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/request"
 	"github.com/MunifTanjim/stremthru/internal/shared"
+	store_video "github.com/MunifTanjim/stremthru/internal/store/video"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
 	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
@@ -234,7 +237,7 @@ func GetStreamsFromIndexers(ctx *RequestContext, stremType, stremId string) ([]W
 		go func(sq indexerSearchQuery, i int) {
 			defer wg.Done()
 			start := time.Now()
-			results[i], errs[i] = sq.indexer.Search(sq.query.Values())
+			results[i], errs[i] = sq.indexer.Search(ctx.Context, sq.query.Values())
 			if errs[i] == nil {
 				log.Debug("indexer search completed", "indexer", sq.indexer.GetId(), "query", sq.query.Encode(), "duration", time.Since(start).String(), "count", len(results[i]))
 			} else {
@@ -288,12 +291,12 @@ func GetStreamsFromIndexers(ctx *RequestContext, stremType, stremId string) ([]W
 		}
 	}
 
-	tInfoByHash, err := torrent_info.GetByHashes(hashes)
+	tInfoByHash, err := torrent_info.GetByHashes(ctx.Context, hashes)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	filesByHashes, err := torrent_stream.GetFilesByHashes(hashes)
+	filesByHashes, err := torrent_stream.GetFilesByHashes(ctx.Context, hashes)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -509,13 +512,13 @@ func GetStreamsFromIndexers(ctx *RequestContext, stremType, stremId string) ([]W
 	return wrappedStreams, hashes, nil
 }
 
-func GetStreamsForHashes(stremType, stremId string, hashes []string, nsid *torrent_stream.NormalizedStremId) ([]WrappedStream, error) {
-	tInfoByHash, err := torrent_info.GetByHashes(hashes)
+func GetStreamsForHashes(ctx context.Context, stremType, stremId string, hashes []string, nsid *torrent_stream.NormalizedStremId) ([]WrappedStream, error) {
+	tInfoByHash, err := torrent_info.GetByHashes(ctx, hashes)
 	if err != nil {
 		return nil, err
 	}
 
-	filesByHashes, err := torrent_stream.GetFilesByHashes(hashes)
+	filesByHashes, err := torrent_stream.GetFilesByHashes(ctx, hashes)
 	if err != nil {
 		return nil, err
 	}
@@ -645,6 +648,19 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkRateLimit(w, r, ctx) {
+		SendResponse(w, r, 200, &stremio.StreamHandlerResponse{
+			Streams: []stremio.Stream{
+				{
+					URL:         store_video.GetLink(store_video.StoreVideoName429, r),
+					Name:        "Torz",
+					Description: "Too many requests, try again later.",
+				},
+			},
+		})
+		return
+	}
+
 	contentType := r.PathValue("contentType")
 	id := stremio_shared.GetPathValue(r, "id")
 
@@ -693,7 +709,7 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashes, err := torrent_info.ListHashesByStremId(id)
+	hashes, err := torrent_info.ListHashesByStremId(ctx.Context, id)
 	if err != nil {
 		SendError(w, r, err)
 		return
@@ -718,7 +734,7 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 	var wrappedStreams []WrappedStream
 	var getStreamsError error
 	wg.Go(func() {
-		wrappedStreams, getStreamsError = GetStreamsForHashes(contentType, id, hashes, nsid)
+		wrappedStreams, getStreamsError = GetStreamsForHashes(ctx.Context, contentType, id, hashes, nsid)
 	})
 
 	var wrappedStreamsFromIndexers []WrappedStream
@@ -771,6 +787,7 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 	var checkMagnetError error
 	if !isP2P && len(hashes) > 0 {
 		cmRes := ud.CheckMagnet(&store.CheckMagnetParams{
+			Ctx:      request.Ctx{Context: ctx.Context},
 			Magnets:  hashes,
 			ClientIP: ctx.ClientIP,
 			SId:      id,
@@ -204,13 +204,13 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 		td.Version = config.Version
 		td.IsTrusted = config.IsTrusted
 
-		td.CanAuthorize = !IsPublicInstance
+		td.CanAuthorize = !IsPublicInstance()
 
 		td.CanAddIndexer = td.IsAuthed || len(td.Indexers) < MaxPublicInstanceIndexerCount
 		td.CanRemoveIndexer = len(td.Indexers) > 0
 
 		td.CanAddStore = td.IsAuthed || len(td.Stores) < MaxPublicInstanceStoreCount
-		if !IsPublicInstance && td.CanAddStore {
+		if !IsPublicInstance() && td.CanAddStore {
 			for i := range td.Stores {
 				s := &td.Stores[i]
 				if s.Code.IsP2P() || (s.Code.IsStremThru() && s.Token != "") {
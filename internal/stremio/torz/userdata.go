@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
@@ -140,11 +141,12 @@ func (ud *UserData) GetRequestContext(r *http.Request) (*RequestContext, error)
 	rCtx := server.GetReqCtx(r)
 	ctx := &RequestContext{
 		StoreContext: &context.StoreContext{
-			Log: rCtx.Log,
+			Context: context.DetachContext(r),
+			Log:     rCtx.Log,
 		},
 	}
 
-	if err, errField := ud.UserDataStores.Prepare(ctx.StoreContext); err != nil {
+	if err, errField := ud.UserDataStores.Prepare(ctx.StoreContext, config.FeatureStremioTorz); err != nil {
 		switch errField {
 		case "store":
 			return ctx, &userDataError{storeCode: []string{err.Error()}}
@@ -236,7 +238,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 		data.IncludeUncachedPrivate = r.Form.Get("uncached_private") == "on"
 	}
 
-	if IsPublicInstance && len(data.Stores) > MaxPublicInstanceStoreCount {
+	if IsPublicInstance() && len(data.Stores) > MaxPublicInstanceStoreCount {
 		data.Stores = data.Stores[0:MaxPublicInstanceStoreCount]
 	}
 
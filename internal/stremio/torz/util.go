@@ -1,6 +1,9 @@
 package stremio_torz
 
 import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/ratelimit"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
 )
@@ -11,3 +14,14 @@ var ExtractRequestBaseURL = shared.ExtractRequestBaseURL
 
 var SendResponse = stremio_shared.SendResponse
 var SendHTML = stremio_shared.SendHTML
+
+func checkRateLimit(w http.ResponseWriter, r *http.Request, ctx *RequestContext) bool {
+	key := ratelimit.RouteKey{
+		IP:       shared.GetPeerIP(r),
+		UserData: r.PathValue("userData"),
+	}
+	if ctx.IsProxyAuthorized {
+		key.User = ctx.ProxyAuthUser
+	}
+	return ratelimit.CheckRoute(w, r, ratelimit.RouteStremioTorz, key)
+}
@@ -19,7 +19,7 @@ var IsPublicInstance = config.IsPublicInstance
 type StoreCode string
 
 func (sc StoreCode) IsStremThru() bool {
-	return !IsPublicInstance && sc == ""
+	return !IsPublicInstance() && sc == ""
 }
 
 func (sc StoreCode) IsP2P() bool {
@@ -72,7 +72,9 @@ func (ud *UserDataStores) IsP2P() bool {
 	return ud.isP2P
 }
 
-func (ud *UserDataStores) Prepare(ctx *context.StoreContext) (err error, errField string) {
+// `feature` is checked against the features allowed for the StremThru
+// store user.
+func (ud *UserDataStores) Prepare(ctx *context.StoreContext, feature string) (err error, errField string) {
 	storeCount := len(ud.Stores)
 	if storeCount == 0 {
 		return errors.New("missing store"), "store"
@@ -91,6 +93,9 @@ func (ud *UserDataStores) Prepare(ctx *context.StoreContext) (err error, errFiel
 			ctx.ProxyAuthUser = auth.Username
 			ctx.ProxyAuthPassword = auth.Password
 		}
+		if !config.UserFeature.IsEnabled(auth.Username, feature) {
+			return errors.New("feature not allowed"), "token"
+		}
 
 		storeNames := config.StoreAuthToken.ListStores(auth.Username)
 		stores := make([]resolvedStore, len(storeNames))
@@ -226,6 +231,7 @@ func (ud *UserDataStores) CheckMagnet(params *store.CheckMagnetParams, log *logg
 		SId:      params.SId,
 	}
 	cmParams.APIKey = firstStore.AuthToken
+	cmParams.Context = params.Context
 	storeCode := strings.ToUpper(string(firstStore.Store.GetName().Code()))
 	if cmRes, err := firstStore.Store.CheckMagnet(cmParams); err != nil {
 		log.Warn("failed to check magnet", "error", err, "store.name", firstStore.Store.GetName())
@@ -268,6 +274,7 @@ func (ud *UserDataStores) CheckMagnet(params *store.CheckMagnetParams, log *logg
 				SId:      params.SId,
 			}
 			cmParams.APIKey = s.AuthToken
+			cmParams.Context = params.Context
 			cmRes, err := s.Store.CheckMagnet(cmParams)
 			storeCode := strings.ToUpper(string(s.Store.GetName().Code()))
 			if err != nil {
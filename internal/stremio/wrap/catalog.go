@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/request"
 	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
 	"github.com/MunifTanjim/stremthru/internal/util"
 	"github.com/MunifTanjim/stremthru/stremio"
@@ -58,6 +59,7 @@ func (ud UserData) fetchCatalog(ctx *context.StoreContext, rType, id, extra stri
 	}
 
 	res, err := addon.FetchCatalog(&stremio_addon.FetchCatalogParams{
+		Ctx:      request.Ctx{Context: ctx.Context},
 		BaseURL:  ud.Upstreams[idx].baseUrl,
 		Type:     rType,
 		Id:       catalogId,
@@ -109,6 +111,7 @@ func (ud UserData) fetchCatalogWithMetasDetails(ctx *context.StoreContext, rType
 	for i := range upstreams {
 		wg.Go(func() {
 			res, err := addon.FetchCatalog(&stremio_addon.FetchCatalogParams{
+				Ctx:      request.Ctx{Context: ctx.Context},
 				BaseURL:  upstreams[i].baseUrl,
 				Type:     rType,
 				Id:       id,
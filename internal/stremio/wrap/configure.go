@@ -38,7 +38,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 	if action := r.Header.Get("x-addon-configure-action"); action != "" {
 		switch action {
 		case "authorize":
-			if !IsPublicInstance {
+			if !IsPublicInstance() {
 				user := r.Form.Get("user")
 				pass := r.Form.Get("pass")
 				if pass == "" || config.AdminPassword.GetPassword(user) != pass {
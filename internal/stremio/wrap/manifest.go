@@ -129,7 +129,7 @@ func GetManifest(r *http.Request, upstreamManifests []stremio.Manifest, ud *User
 	for mIdx := range upstreamManifests {
 		m := upstreamManifests[mIdx]
 		for _, r := range m.Resources {
-			if IsPublicInstance {
+			if IsPublicInstance() {
 				if r.Name == stremio.ResourceNameMeta || r.Name == stremio.ResourceNameSubtitles {
 					continue
 				}
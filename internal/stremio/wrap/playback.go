@@ -91,6 +91,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 			ClientIP: ctx.ClientIP,
 		}
 		amParams.APIKey = ctx.StoreAuthToken
+		amParams.Context = ctx.Context
 		amRes, err := ctx.Store.AddMagnet(amParams)
 		if err != nil {
 			return &stremResult{
@@ -13,6 +13,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/request"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
 	stremio_torz "github.com/MunifTanjim/stremthru/internal/stremio/torz"
@@ -80,7 +81,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 		chunkIdxOffset = 1
 		wg.Go(func() {
 
-			hashes, err := torrent_info.ListHashesByStremId(stremId)
+			hashes, err := torrent_info.ListHashesByStremId(ctx.Context, stremId)
 			if err != nil {
 				if errors.Is(err, torrent_stream.ErrUnsupportedStremId) {
 					return
@@ -94,7 +95,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 				return
 			}
 
-			streams, err := stremio_torz.GetStreamsForHashes(rType, stremId, hashes, nsid)
+			streams, err := stremio_torz.GetStreamsForHashes(ctx.Context, rType, stremId, hashes, nsid)
 			if err != nil {
 				errs[0] = err
 				return
@@ -126,6 +127,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 		wg.Go(func() {
 			up := &upstreams[i]
 			res, err := addon.FetchStream(&stremio_addon.FetchStreamParams{
+				Ctx:      request.Ctx{Context: ctx.Context},
 				BaseURL:  up.baseUrl,
 				Type:     rType,
 				Id:       id,
@@ -234,6 +236,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 	hasErrByStoreCode := map[string]struct{}{}
 	if len(hashes) > 0 {
 		cmRes := ud.CheckMagnet(&store.CheckMagnetParams{
+			Ctx:      request.Ctx{Context: ctx.Context},
 			Magnets:  hashes,
 			ClientIP: ctx.ClientIP,
 			SId:      stremId,
@@ -307,7 +310,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 				}
 
 				if ctx.IsProxyAuthorized {
-					if url, err := shared.CreateProxyLink(r, stream.URL, headers, config.TUNNEL_TYPE_AUTO, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil && url != stream.URL {
+					if url, err := shared.CreateProxyLink(r, stream.URL, headers, config.TUNNEL_TYPE_AUTO, 12*time.Hour, "", ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, ""); err == nil && url != stream.URL {
 						stream.URL = url
 						stream.Name = "✨ " + stream.Name
 					}
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/request"
 	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
 	"github.com/MunifTanjim/stremthru/stremio"
 )
@@ -26,6 +27,7 @@ func (ud UserData) fetchSubtitles(ctx *context.StoreContext, rType, id, extra st
 	for i := range upstreams {
 		wg.Go(func() {
 			res, err := addon.FetchSubtitles(&stremio_addon.FetchSubtitlesParams{
+				Ctx:      request.Ctx{Context: ctx.Context},
 				BaseURL:  upstreams[i].baseUrl,
 				Type:     rType,
 				Id:       id,
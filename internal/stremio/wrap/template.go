@@ -318,11 +318,11 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 		td.Version = config.Version
 		td.IsTrusted = config.IsTrusted
 
-		td.CanAuthorize = !IsPublicInstance
+		td.CanAuthorize = !IsPublicInstance()
 		td.CanAddUpstream = td.IsAuthed || len(td.Upstreams) < MaxPublicInstanceUpstreamCount
 		td.CanRemoveUpstream = len(td.Upstreams) > 1
 		td.CanAddStore = td.IsAuthed || len(td.Stores) < MaxPublicInstanceStoreCount
-		if !IsPublicInstance && td.CanAddStore {
+		if !IsPublicInstance() && td.CanAddStore {
 			for i := range td.Stores {
 				s := &td.Stores[i]
 				if s.Code.IsStremThru() && s.Token != "" {
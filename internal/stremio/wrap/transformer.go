@@ -203,7 +203,7 @@ func getTemplateIds() ([]string, error) {
 }
 
 func seedDefaultTransformerEntities() {
-	if config.IsPublicInstance {
+	if config.IsPublicInstance() {
 		for oldId := range newTransformerExtractorIdMap {
 			if err := extractorStore.Del(oldId); err != nil {
 				log.Warn("Failed to cleanup seed extractor: " + oldId)
@@ -220,7 +220,7 @@ func seedDefaultTransformerEntities() {
 		if err := templateStore.Del(key); err != nil {
 			log.Warn("Failed to cleanup seed template: " + key)
 		}
-		if config.IsPublicInstance {
+		if config.IsPublicInstance() {
 			key = strings.TrimPrefix(key, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX)
 			if err := templateStore.Del(key); err != nil {
 				log.Warn("Failed to cleanup seed template: " + key)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,11 +24,25 @@ import (
 )
 
 var upstreamManifestCache = cache.NewCache[stremio.Manifest](&cache.CacheConfig{
-	Name:          "stremio:wrap:upstreamManifest",
-	Lifetime:      6 * time.Hour,
-	LocalCapacity: 1024,
+	Name:                 "stremio:wrap:upstreamManifest",
+	Lifetime:             6 * time.Hour,
+	LocalCapacity:        1024,
+	StaleWhileRevalidate: 1 * time.Hour,
+	ErrorLifetime:        15 * time.Minute,
+	IsCacheableError:     isCacheableUpstreamManifestError,
+	Persist:              true,
 })
 
+// Only remember failures that are about the manifest url itself, others can
+// be specific to the request, e.g. the forwarded client ip.
+func isCacheableUpstreamManifestError(err error) bool {
+	if errors.Is(err, stremio_addon.ErrInvalidManifest) {
+		return true
+	}
+	var rerr *stremio_addon.ResponseError
+	return errors.As(err, &rerr) && (rerr.StatusCode == http.StatusNotFound || rerr.StatusCode == http.StatusGone)
+}
+
 var upstreamResolverCache = cache.NewCache[upstreamsResolver](&cache.CacheConfig{
 	Name:          "stremio:wrap:upstreamResolver",
 	Lifetime:      24 * time.Hour,
@@ -207,7 +222,8 @@ func (uderr *userDataError) Error() string {
 func (ud *UserData) GetRequestContext(r *http.Request) (*context.StoreContext, error) {
 	rCtx := server.GetReqCtx(r)
 	ctx := &context.StoreContext{
-		Log: rCtx.Log,
+		Context: context.DetachContext(r),
+		Log:     rCtx.Log,
 	}
 
 	udErr := &userDataError{}
@@ -241,7 +257,7 @@ func (ud *UserData) GetRequestContext(r *http.Request) (*context.StoreContext, e
 		return ctx, udErr
 	}
 
-	if err, errField := ud.UserDataStores.Prepare(ctx); err != nil {
+	if err, errField := ud.UserDataStores.Prepare(ctx, config.FeatureStremioWrap); err != nil {
 		switch errField {
 		case "store":
 			udErr.store = []string{err.Error()}
@@ -264,37 +280,31 @@ func (ud UserData) getUpstreamManifests(ctx *context.StoreContext, useCache bool
 
 		manifests := make([]stremio.Manifest, len(ud.Upstreams))
 		errs := make([]error, len(ud.Upstreams))
-		hasError := false
 		for i := range ud.Upstreams {
 			up := &ud.Upstreams[i]
 			cacheKey := up.baseUrl.String()
-			hasCached := useCache && upstreamManifestCache.Get(cacheKey, &manifests[i])
-			if !hasCached {
-				wg.Go(func() {
+			wg.Go(func() {
+				fetch := func() (stremio.Manifest, error) {
 					res, err := addon.GetManifest(&stremio_addon.GetManifestParams{BaseURL: up.baseUrl, ClientIP: ctx.ClientIP})
-					manifests[i] = res.Data
-					errs[i] = err
-					if err != nil {
-						hasError = true
-						if err := upstreamManifestCache.AddWithLifetime(cacheKey, manifests[i], 15*time.Minute); err != nil {
-							log.Warn("failed to cache upstream manifest (empty)", "error", err, "host", up.baseUrl.Host)
-						}
-					} else {
-						if err := upstreamManifestCache.Add(cacheKey, manifests[i]); err != nil {
-							log.Warn("failed to cache upstream manifest", "error", err, "host", up.baseUrl.Host)
-						}
+					return res.Data, err
+				}
+				if useCache {
+					manifests[i], errs[i] = upstreamManifestCache.GetOrLoad(cacheKey, fetch)
+				} else if manifests[i], errs[i] = fetch(); errs[i] == nil {
+					if err := upstreamManifestCache.Add(cacheKey, manifests[i]); err != nil {
+						log.Warn("failed to cache upstream manifest", "error", err, "host", up.baseUrl.Host)
 					}
-				})
-			} else if manifests[i].ID == "" {
-				hasError = true
-				errs[i] = errors.New("failed to fetch manifest: " + up.baseUrl.Host)
-			}
+				}
+				if errs[i] == nil && manifests[i].ID == "" {
+					errs[i] = errors.New("failed to fetch manifest: " + up.baseUrl.Host)
+				}
+			})
 		}
 		wg.Wait()
 
 		ud.manifests = manifests
 
-		if hasError {
+		if slices.ContainsFunc(errs, func(err error) bool { return err != nil }) {
 			return manifests, errs
 		}
 	}
@@ -411,7 +421,7 @@ func (ud UserData) getUpstreams(ctx *context.StoreContext, rName stremio.Resourc
 			return ud.Upstreams, nil
 		}
 
-		if IsPublicInstance {
+		if IsPublicInstance() {
 			if rName == stremio.ResourceNameMeta || rName == stremio.ResourceNameSubtitles {
 				if upstreamsCount > 1 {
 					return []UserDataUpstream{}, nil
@@ -479,7 +489,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 			up := &data.Upstreams[i]
 
 			if up.ExtractorId != "" {
-				if config.IsPublicInstance {
+				if config.IsPublicInstance() {
 					up.ExtractorId = getNewTransformerExtractorId(up.ExtractorId)
 				}
 
@@ -492,7 +502,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 		}
 
 		if data.TemplateId != "" {
-			if config.IsPublicInstance && !strings.HasPrefix(data.TemplateId, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX) {
+			if config.IsPublicInstance() && !strings.HasPrefix(data.TemplateId, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX) {
 				data.TemplateId = BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + data.TemplateId
 			}
 
@@ -596,7 +606,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 		}
 	}
 
-	if IsPublicInstance && len(data.Upstreams) > MaxPublicInstanceUpstreamCount {
+	if IsPublicInstance() && len(data.Upstreams) > MaxPublicInstanceUpstreamCount {
 		data.Upstreams = data.Upstreams[0:MaxPublicInstanceUpstreamCount]
 	}
 
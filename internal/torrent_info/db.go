@@ -1,6 +1,7 @@
 package torrent_info
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -569,7 +570,7 @@ var get_by_hashes_query = fmt.Sprintf(
 	Column.Hash,
 )
 
-func GetByHashes(hashes []string) (map[string]TorrentInfo, error) {
+func GetByHashes(ctx context.Context, hashes []string) (map[string]TorrentInfo, error) {
 	byHash := map[string]TorrentInfo{}
 
 	if len(hashes) == 0 {
@@ -581,7 +582,7 @@ func GetByHashes(hashes []string) (map[string]TorrentInfo, error) {
 	for i, hash := range hashes {
 		args[i] = hash
 	}
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1149,7 +1150,7 @@ var query_list_hashes_by_stremid_from_imdb_torrent_for_series = fmt.Sprintf(
 	Column.Episodes,
 )
 
-func ListHashesByStremId(stremId string) ([]string, error) {
+func ListHashesByStremId(ctx context.Context, stremId string) ([]string, error) {
 	nsid, err := ts.NormalizeStreamId(stremId)
 	if err != nil {
 		return nil, err
@@ -1181,7 +1182,7 @@ func ListHashesByStremId(stremId string) ([]string, error) {
 		args = append(args, stremId, stremId+":%", stremId)
 	}
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Error("failed to list hashes by strem id", "error", err, "stremId", stremId)
 		return nil, err
@@ -1372,6 +1373,40 @@ func ListByStremId(stremId string, excludeMissingSize bool) (*ListTorrentsData,
 	return data, nil
 }
 
+var query_list_after_hash = fmt.Sprintf(
+	"%s%s WHERE ti.%s > ? GROUP BY ti.%s ORDER BY ti.%s LIMIT ?",
+	query_list_by_stremid_select,
+	query_list_by_stremid_after_select,
+	Column.Hash,
+	Column.Hash,
+	Column.Hash,
+)
+
+// Lists torrents ordered by hash, starting after `afterHash`. Used for
+// paging through the whole table.
+func ListAfterHash(afterHash string, limit int) ([]TorrentItem, error) {
+	rows, err := db.Query(query_list_after_hash, afterHash, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []TorrentItem{}
+	for rows.Next() {
+		var item TorrentItem
+		if err := rows.Scan(&item.Hash, &item.TorrentTitle, &item.Size, &item.Indexer, &item.Source, &item.Category, &item.Seeders, &item.Leechers, &item.Private, &item.Files); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 var query_dump_torrents_before_cond = fmt.Sprintf(`
 SELECT ti.%s,
        ti.%s,
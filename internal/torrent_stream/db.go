@@ -1,6 +1,7 @@
 package torrent_stream
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
@@ -317,7 +318,7 @@ func GetFile(hash string, sid string) (*File, error) {
 	return &file, nil
 }
 
-func GetFilesByHashes(hashes []string) (map[string]Files, error) {
+func GetFilesByHashes(ctx context.Context, hashes []string) (map[string]Files, error) {
 	byHash := map[string]Files{}
 
 	if len(hashes) == 0 {
@@ -331,7 +332,7 @@ func GetFilesByHashes(hashes []string) (map[string]Files, error) {
 		hashPlaceholders[i] = "?"
 	}
 
-	rows, err := db.Query("SELECT h, "+db.FnJSONGroupArray+"("+db.FnJSONObject+"('i', i, 'p', p, 's', s, 'sid', sid, 'asid', asid, 'src', src, 'vhash', vhash)) AS files FROM "+TableName+" WHERE h IN ("+strings.Join(hashPlaceholders, ",")+") GROUP BY h", args...)
+	rows, err := db.QueryContext(ctx, "SELECT h, "+db.FnJSONGroupArray+"("+db.FnJSONObject+"('i', i, 'p', p, 's', s, 'sid', sid, 'asid', asid, 'src', src, 'vhash', vhash)) AS files FROM "+TableName+" WHERE h IN ("+strings.Join(hashPlaceholders, ",")+") GROUP BY h", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,9 @@
 package torznab_client
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -22,11 +24,21 @@ type torzFileCached struct {
 }
 
 var torzFileCache = cache.NewCache[torzFileCached](&cache.CacheConfig{
-	Lifetime:      6 * time.Hour,
-	Name:          "torznab:indexer:file",
-	LocalCapacity: 5120,
+	Lifetime:         6 * time.Hour,
+	Name:             "torznab:indexer:file",
+	LocalCapacity:    5120,
+	ErrorLifetime:    1 * time.Minute,
+	IsCacheableError: isCacheableTorzFileError,
+	Persist:          true,
 })
 
+var errTorzFileUnavailable = errors.New("torrent file unavailable")
+
+// Source temporarily failing is not remembered, it can succeed on retry.
+func isCacheableTorzFileError(err error) bool {
+	return !errors.Is(err, errTorzFileUnavailable)
+}
+
 type Torz struct {
 	Indexer string
 
@@ -57,82 +69,80 @@ func (t *Torz) EnsureMagnet() error {
 		return errors.New("no source link to generate magnet")
 	}
 
-	cachedTorz := torzFileCached{}
-	if torzFileCache.Get(t.SourceLink, &cachedTorz) {
-		t.Hash = cachedTorz.Hash
-		t.MagnetLink = cachedTorz.MagnetLink
-		if cachedTorz.Private {
-			t.Private = true
-		}
-		t.Files = cachedTorz.Files
-		return nil
+	cachedTorz, err := torzFileCache.GetOrLoad(t.SourceLink, func() (torzFileCached, error) {
+		return fetchTorzFile(t.SourceLink)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Hash = cachedTorz.Hash
+	t.MagnetLink = cachedTorz.MagnetLink
+	if cachedTorz.Private {
+		t.Private = true
 	}
+	t.Files = cachedTorz.Files
+	return nil
+}
+
+func fetchTorzFile(sourceLink string) (torzFileCached, error) {
+	cachedTorz := torzFileCached{}
 
 	client := config.GetHTTPClient(config.TUNNEL_TYPE_AUTO)
 	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
 	}
 
-	resp, err := client.Get(t.SourceLink)
+	resp, err := client.Get(sourceLink)
 	if err != nil {
-		return err
+		return cachedTorz, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return cachedTorz, fmt.Errorf("%w: %s", errTorzFileUnavailable, resp.Status)
+	}
+
 	if http.StatusMovedPermanently <= resp.StatusCode && resp.StatusCode <= http.StatusSeeOther {
 		if location := resp.Header.Get("Location"); strings.HasPrefix(location, "magnet:?") {
 			m, err := core.ParseMagnetLink(location)
 			if err != nil {
-				return err
+				return cachedTorz, err
 			}
-			t.Hash = m.Hash
-			t.MagnetLink = m.RawLink
-
-			cachedTorz.Hash = t.Hash
-			cachedTorz.MagnetLink = t.MagnetLink
-			torzFileCache.Add(t.SourceLink, cachedTorz)
-			return nil
+			cachedTorz.Hash = m.Hash
+			cachedTorz.MagnetLink = m.RawLink
+			return cachedTorz, nil
 		}
 	}
 
 	mi, err := metainfo.Load(resp.Body)
 	if err != nil {
-		return err
+		return cachedTorz, err
 	}
 
 	m, err := mi.MagnetV2()
 	if err != nil {
-		return err
+		return cachedTorz, err
 	}
 	if !m.InfoHash.Ok {
-		return errors.New("unsupported torrent file: only v1 torrents are supported")
+		return cachedTorz, errors.New("unsupported torrent file: only v1 torrents are supported")
 	}
 
-	t.Hash = strings.ToLower(m.InfoHash.Value.String())
-	t.MagnetLink = m.String()
-
 	mii, err := mi.UnmarshalInfo()
 	if err != nil {
-		return err
+		return cachedTorz, err
 	}
 
-	t.Files = torrent_stream.FilesFromTorrentInfo(&mii)
+	cachedTorz.Hash = strings.ToLower(m.InfoHash.Value.String())
+	cachedTorz.MagnetLink = m.String()
+	cachedTorz.Files = torrent_stream.FilesFromTorrentInfo(&mii)
+	cachedTorz.Private = mii.Private != nil && *mii.Private
 
-	if mii.Private != nil && *mii.Private {
-		t.Private = true
-	}
-
-	cachedTorz.Hash = t.Hash
-	cachedTorz.MagnetLink = t.MagnetLink
-	cachedTorz.Private = t.Private
-	cachedTorz.Files = t.Files
-	torzFileCache.Add(t.SourceLink, cachedTorz)
-
-	return nil
+	return cachedTorz, nil
 }
 
 type Indexer interface {
 	GetId() string
 	NewSearchQuery(fn func(caps Caps) Function) (*Query, error)
-	Search(query url.Values) ([]Torz, error)
+	Search(ctx context.Context, query url.Values) ([]Torz, error)
 }
@@ -21,6 +21,14 @@ func decrypt(value string) (string, error) {
 	return core.Decrypt(config.VaultSecret, value)
 }
 
+func reencrypt(oldSecret, newSecret, value string) (string, error) {
+	decrypted, err := core.Decrypt(oldSecret, value)
+	if err != nil {
+		return "", err
+	}
+	return core.Encrypt(newSecret, decrypted)
+}
+
 const TableName = "torznab_indexer"
 
 type IndexerType string
@@ -314,3 +322,48 @@ func DeleteByCompositeId(compositeId string) error {
 	}
 	return Delete(indexerType, id)
 }
+
+var query_set_api_key = fmt.Sprintf(
+	`UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?`,
+	TableName,
+	Column.APIKey,
+	Column.Type,
+	Column.Id,
+)
+
+// Re-encrypts the api key of every indexer from `oldSecret` to
+// `newSecret`, within `tx`.
+func RotateSecret(tx *db.Tx, oldSecret, newSecret string) (int, error) {
+	rows, err := tx.Query(query_get_all)
+	if err != nil {
+		return 0, err
+	}
+	items := []TorznabIndexer{}
+	for rows.Next() {
+		item := TorznabIndexer{}
+		if err := rows.Scan(&item.Type, &item.Id, &item.Name, &item.URL, &item.APIKey, &item.RateLimitConfigId, &item.CAt, &item.UAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.APIKey == "" {
+			continue
+		}
+		apiKey, err := reencrypt(oldSecret, newSecret, item.APIKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt api key for %s: %w", item.GetCompositeId(), err)
+		}
+		if _, err := tx.Exec(query_set_api_key, apiKey, item.Type, item.Id); err != nil {
+			return 0, err
+		}
+	}
+	return len(items), nil
+}
@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+)
+
+type logHandler struct {
+	slog.Handler
+}
+
+func (h logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if traceId, spanId := GetIds(ctx); traceId != "" {
+		r.AddAttrs(slog.String("trace_id", traceId), slog.String("span_id", spanId))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{h.Handler.WithGroup(name)}
+}
+
+// Adds `trace_id` and `span_id` to the log records
+func WrapLogHandler(handler slog.Handler) slog.Handler {
+	return logHandler{handler}
+}
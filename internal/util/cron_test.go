@@ -0,0 +1,37 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSchedule(t *testing.T) {
+	from := time.Date(2026, time.January, 14, 10, 30, 15, 0, time.UTC) // wednesday
+
+	for _, tc := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.January, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.January, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, time.January, 15, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * sun", time.Date(2026, time.January, 18, 2, 30, 0, 0, time.UTC)},
+		{"30 2 * * 7", time.Date(2026, time.January, 18, 2, 30, 0, 0, time.UTC)},
+		{"0 4 1,15 * 1", time.Date(2026, time.January, 15, 4, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 22-23/1 * * mon-fri", time.Date(2026, time.January, 14, 22, 0, 0, 0, time.UTC)},
+	} {
+		s, err := ParseCronSchedule(tc.expr)
+		if assert.NoError(t, err, tc.expr) {
+			assert.Equal(t, tc.next, s.Next(from), tc.expr)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		_, err := ParseCronSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -15,6 +15,7 @@ type DatasetWriter[T any] struct {
 	items          []T
 	upsert         func([]T) error
 	sleep_duration time.Duration
+	on_upsert      func(count int) error
 }
 
 type DatasetWriterConfig[T any] struct {
@@ -22,6 +23,9 @@ type DatasetWriterConfig[T any] struct {
 	Log           *log.Logger
 	Upsert        func([]T) error
 	SleepDuration time.Duration
+	// Called with the count of items in each upserted batch. Returning an
+	// error stops the writer, e.g. on cancellation.
+	OnUpsert func(count int) error
 }
 
 func NewDatasetWriter[T any](conf DatasetWriterConfig[T]) *DatasetWriter[T] {
@@ -31,6 +35,9 @@ func NewDatasetWriter[T any](conf DatasetWriterConfig[T]) *DatasetWriter[T] {
 	if conf.SleepDuration == 0 {
 		conf.SleepDuration = 250 * time.Millisecond
 	}
+	if conf.OnUpsert == nil {
+		conf.OnUpsert = func(count int) error { return nil }
+	}
 	dsw := DatasetWriter[T]{
 		batch_idx:      0,
 		batch_size:     conf.BatchSize,
@@ -40,6 +47,7 @@ func NewDatasetWriter[T any](conf DatasetWriterConfig[T]) *DatasetWriter[T] {
 		items:          make([]T, conf.BatchSize),
 		upsert:         conf.Upsert,
 		sleep_duration: conf.SleepDuration,
+		on_upsert:      conf.OnUpsert,
 	}
 	return &dsw
 }
@@ -61,6 +69,9 @@ func (w *DatasetWriter[T]) Write(t *T) error {
 			return err
 		}
 		w.log.Info("upserted items", "count", w.batch_idx*w.batch_size)
+		if err := w.on_upsert(w.batch_size); err != nil {
+			return err
+		}
 		w.idx = 0
 		time.Sleep(w.sleep_duration)
 	}
@@ -80,5 +91,5 @@ func (w *DatasetWriter[T]) Done() error {
 	}
 	w.is_done = true
 	w.log.Info("upserted items", "count", w.batch_idx*w.batch_size+w.idx)
-	return nil
+	return w.on_upsert(w.idx)
 }
@@ -0,0 +1,216 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MunifTanjim/stremthru/internal/job_log"
+	"github.com/MunifTanjim/stremthru/internal/kv"
+)
+
+var (
+	ErrWorkerNotScheduled   = errors.New("worker is not scheduled on this instance")
+	ErrWorkerRunning        = errors.New("worker is already running")
+	ErrWorkerNotRunning     = errors.New("worker is not running")
+	ErrJobCancelled         = errors.New("job cancelled")
+	ErrWorkerNotCancellable = errors.New("worker can not be cancelled")
+)
+
+// Shared by every instance, checked before each run and on heartbeat.
+type WorkerState struct {
+	Paused      bool   `json:"paused"`
+	CancelJobId string `json:"cancel_job_id,omitempty"`
+}
+
+// Fields are stored separately, so that concurrent pause and cancel don't
+// overwrite each other.
+var workerPausedStore = kv.NewKVStore[bool](&kv.KVStoreConfig{
+	Type: "job:state:paused",
+})
+
+var workerCancelStore = kv.NewKVStore[string](&kv.KVStoreConfig{
+	Type: "job:state:cancel",
+})
+
+func GetState(id string) (*WorkerState, error) {
+	state := WorkerState{}
+	if err := workerPausedStore.GetValue(id, &state.Paused); err != nil {
+		return nil, err
+	}
+	if err := workerCancelStore.GetValue(id, &state.CancelJobId); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func GetStates() (map[string]WorkerState, error) {
+	pausedItems, err := workerPausedStore.List()
+	if err != nil {
+		return nil, err
+	}
+	cancelItems, err := workerCancelStore.List()
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]WorkerState, len(pausedItems))
+	for i := range pausedItems {
+		state := states[pausedItems[i].Key]
+		state.Paused = pausedItems[i].Value
+		states[pausedItems[i].Key] = state
+	}
+	for i := range cancelItems {
+		state := states[cancelItems[i].Key]
+		state.CancelJobId = cancelItems[i].Value
+		states[cancelItems[i].Key] = state
+	}
+	return states, nil
+}
+
+// Scheduled runs are skipped while paused, on every instance.
+func SetPaused(id string, paused bool) (*WorkerState, error) {
+	if err := workerPausedStore.Set(id, paused); err != nil {
+		return nil, err
+	}
+	return GetState(id)
+}
+
+func clearCancel(id, jobId string) error {
+	cancelJobId := ""
+	if err := workerCancelStore.GetValue(id, &cancelJobId); err != nil {
+		return err
+	}
+	if cancelJobId != jobId {
+		return nil
+	}
+	return workerCancelStore.Del(id)
+}
+
+func getScheduledWorker(id string) *Worker {
+	if w, ok := scheduledWorkers.Load(id); ok {
+		return w.(*Worker)
+	}
+	return nil
+}
+
+// Runs the worker now, in background, even if paused or its last job was
+// recently done.
+func Run(id string) error {
+	w := getScheduledWorker(id)
+	if w == nil {
+		return ErrWorkerNotScheduled
+	}
+	if !w.runM.TryLock() {
+		return ErrWorkerRunning
+	}
+	if w.getJobId() != "" {
+		w.runM.Unlock()
+		return ErrWorkerRunning
+	}
+	w.manualRun.Store(true)
+	go func() {
+		err := w.run()
+		w.runM.Unlock()
+		if err != nil {
+			w.task.ErrFunc(err)
+		}
+	}()
+	return nil
+}
+
+// Requests the running job to be cancelled, on whichever instance it is
+// running. Executors stop at their next checkpoint.
+func Cancel(id string) error {
+	if details, ok := WorkerDetailsById[id]; !ok || !details.Cancellable {
+		return ErrWorkerNotCancellable
+	}
+	tjob, err := job_log.GetLastJobLog[struct{}](id)
+	if err != nil {
+		return err
+	}
+	if tjob == nil || tjob.Status != "started" {
+		return ErrWorkerNotRunning
+	}
+	if err := workerCancelStore.Set(id, tjob.Id); err != nil {
+		return err
+	}
+	if w := getScheduledWorker(id); w != nil {
+		w.cancelJob(tjob.Id)
+	}
+	return nil
+}
+
+type workerJob struct {
+	id     string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *Worker) startJob(jobId string) {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.job = &workerJob{id: jobId, ctx: ctx, cancel: cancel}
+}
+
+func (w *Worker) endJob() {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	if w.job != nil {
+		w.job.cancel()
+		w.job = nil
+	}
+}
+
+// Context of the running job, done once the job is cancelled.
+func (w *Worker) Context() context.Context {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	if w.job == nil {
+		return context.Background()
+	}
+	return w.job.ctx
+}
+
+// Replaces the context of the running job, with `ctx` derived from it.
+func (w *Worker) setJobContext(ctx context.Context) {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	if w.job != nil {
+		w.job.ctx = ctx
+	}
+}
+
+func (w *Worker) cancelJob(jobId string) {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	if w.job != nil && w.job.id == jobId {
+		w.Log.Info("cancelling job", "jobId", jobId)
+		w.job.cancel()
+	}
+}
+
+// Checks for cancellation requested from other instances.
+func (w *Worker) syncJobState(jobId string) {
+	state, err := GetState(w.name)
+	if err != nil {
+		w.Log.Error("failed to get worker state", "error", err)
+		return
+	}
+	if state.CancelJobId == jobId {
+		w.cancelJob(jobId)
+	}
+}
+
+// Should be checked by executors between batches, returning
+// `ErrJobCancelled` when true.
+func (w *Worker) IsCancelled() bool {
+	w.jobM.Lock()
+	defer w.jobM.Unlock()
+
+	return w.job != nil && w.job.ctx.Err() != nil
+}
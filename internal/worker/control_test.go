@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	release := make(chan struct{})
+	var count atomic.Int32
+	w := &Worker{}
+	w.jobId.Store("")
+	w.run = func() error {
+		<-release
+		count.Add(1)
+		return nil
+	}
+	scheduledWorkers.Store("test-run", w)
+	defer scheduledWorkers.Delete("test-run")
+
+	assert.NoError(t, Run("test-run"))
+	assert.ErrorIs(t, Run("test-run"), ErrWorkerRunning)
+	assert.False(t, w.runM.TryLock(), "scheduled run should be skipped")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return Run("test-run") == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return count.Load() == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCancelNotCancellable(t *testing.T) {
+	assert.ErrorIs(t, Cancel("push-torrent"), ErrWorkerNotCancellable)
+	assert.ErrorIs(t, Cancel("unknown"), ErrWorkerNotCancellable)
+}
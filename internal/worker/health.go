@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+var scheduledWorkers sync.Map // map[string]*Worker
+
+type WorkerHealth struct {
+	Id              string
+	JobId           string
+	LastHeartbeatAt time.Time
+	IsStale         bool
+}
+
+// Lists the workers scheduled on this instance that are running a job,
+// and whether their heartbeat is overdue.
+func GetRunningWorkerHealth() []WorkerHealth {
+	result := []WorkerHealth{}
+	scheduledWorkers.Range(func(key, value any) bool {
+		w := value.(*Worker)
+		jobId := w.getJobId()
+		if jobId == "" || w.running.Load() == 0 {
+			return true
+		}
+		health := WorkerHealth{
+			Id:    w.name,
+			JobId: jobId,
+		}
+		if lastHeartbeatAt := w.lastHeartbeatAt.Load(); lastHeartbeatAt != 0 {
+			health.LastHeartbeatAt = time.Unix(0, lastHeartbeatAt)
+			health.IsStale = time.Since(health.LastHeartbeatAt) > w.heartbeatInterval
+		}
+		result = append(result, health)
+		return true
+	})
+	return result
+}
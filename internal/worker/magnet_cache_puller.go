@@ -17,6 +17,7 @@ import (
 
 func InitMagnetCachePullerWorker(conf *WorkerConfig) *Worker {
 	conf.Executor = func(w *Worker) error {
+		worker_queue.MagnetCachePullerQueue.Flush()
 		worker_queue.MagnetCachePullerQueue.ProcessGroup(func(key string, items []worker_queue.MagnetCachePullerQueueItem) error {
 			storeCode, sid, _ := strings.Cut(key, ":")
 
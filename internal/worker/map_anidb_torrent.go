@@ -640,8 +640,13 @@ func InitMapAniDBTorrentWorker(conf *WorkerConfig) *Worker {
 			chunk_size = 2000
 		}
 
+		w.SetPhase("map", 0)
+
 		totalCount := 0
 		for {
+			if w.IsCancelled() {
+				return ErrJobCancelled
+			}
 			hashes, err := torrent_info.GetAniDBUnmappedHashes(batch_size)
 			if err != nil {
 				return err
@@ -660,7 +665,7 @@ func InitMapAniDBTorrentWorker(conf *WorkerConfig) *Worker {
 					}()
 
 					items := []anidb.AniDBTorrent{}
-					tInfoByHash, err := torrent_info.GetByHashes(cHashes)
+					tInfoByHash, err := torrent_info.GetByHashes(w.Context(), cHashes)
 					if err != nil {
 						log.Error("failed to get torrent info", "error", err)
 						return
@@ -770,6 +775,7 @@ func InitMapAniDBTorrentWorker(conf *WorkerConfig) *Worker {
 					}
 
 					log.Info("mapped anidb torrent", "count", len(items))
+					w.AddProcessed(len(cHashes))
 				})
 			}
 			wg.Wait()
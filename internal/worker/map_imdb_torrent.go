@@ -27,6 +27,9 @@ func InitMapIMDBTorrentWorker(conf *WorkerConfig) *Worker {
 
 		totalCount := 0
 		for {
+			if w.IsCancelled() {
+				return ErrJobCancelled
+			}
 			hashes, err := torrent_info.GetIMDBUnmappedHashes(batch_size)
 			if err != nil {
 				return err
@@ -37,7 +40,7 @@ func InitMapIMDBTorrentWorker(conf *WorkerConfig) *Worker {
 				wg.Go(func() {
 
 					items := []imdb_torrent.IMDBTorrent{}
-					tInfoByHash, err := torrent_info.GetByHashes(cHashes)
+					tInfoByHash, err := torrent_info.GetByHashes(w.Context(), cHashes)
 					if err != nil {
 						w.Log.Error("failed to get torrent info", "error", err)
 						return
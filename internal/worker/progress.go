@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/job_log"
+)
+
+// Progress of a job, saved with it on heartbeat. `UpdatedAt` only moves
+// when the progress changes, so a job with fresh heartbeat but stale
+// progress is likely stuck.
+type JobProgress struct {
+	Phase     string    `json:"phase,omitempty"`
+	Processed int       `json:"processed"`
+	Total     int       `json:"total,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type jobProgress struct {
+	m sync.Mutex
+	p *JobProgress
+}
+
+func (jp *jobProgress) reset() {
+	jp.m.Lock()
+	defer jp.m.Unlock()
+
+	jp.p = nil
+}
+
+func (jp *jobProgress) update(f func(p *JobProgress)) {
+	jp.m.Lock()
+	defer jp.m.Unlock()
+
+	if jp.p == nil {
+		jp.p = &JobProgress{}
+	}
+	f(jp.p)
+	jp.p.UpdatedAt = time.Now()
+}
+
+func (jp *jobProgress) get() *JobProgress {
+	jp.m.Lock()
+	defer jp.m.Unlock()
+
+	if jp.p == nil {
+		return nil
+	}
+	p := *jp.p
+	return &p
+}
+
+// Starts a new phase of the job, with `total` items to process, or `0`
+// if unknown.
+func (w *Worker) SetPhase(phase string, total int) {
+	w.progress.update(func(p *JobProgress) {
+		p.Phase = phase
+		p.Processed = 0
+		p.Total = total
+		p.Message = ""
+	})
+}
+
+func (w *Worker) SetTotal(total int) {
+	w.progress.update(func(p *JobProgress) {
+		p.Total = total
+	})
+}
+
+// Safe to call concurrently.
+func (w *Worker) AddProcessed(count int) {
+	w.progress.update(func(p *JobProgress) {
+		p.Processed += count
+	})
+}
+
+func (w *Worker) SetMessage(message string) {
+	w.progress.update(func(p *JobProgress) {
+		p.Message = message
+	})
+}
+
+// Returns the last job of the worker. If it is running on this instance,
+// the progress is the live one instead of the one saved on heartbeat.
+func GetLastJob(id string) (*job_log.ParsedJobLog[JobProgress], error) {
+	job, err := job_log.GetLastJobLog[JobProgress](id)
+	if err != nil || job == nil {
+		return job, err
+	}
+	if w := getScheduledWorker(id); w != nil && job.Status == "started" && w.getJobId() == job.Id {
+		if progress := w.progress.get(); progress != nil {
+			job.Data = progress
+		}
+	}
+	return job, nil
+}
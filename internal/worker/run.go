@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+)
+
+type oneshotWorker struct {
+	name string
+	conf *WorkerConfig
+}
+
+// When set, `NewWorker` only collects the config for the matching
+// worker instead of scheduling anything.
+var oneshot *oneshotWorker
+
+// Runs the worker with `id` once, in the foreground, with the same
+// locking and job tracking as a scheduled run. With `force`, it runs
+// even if the worker is disabled or its last job was recently done.
+func RunOnce(id string, force bool) error {
+	if _, ok := WorkerDetailsById[id]; !ok {
+		return fmt.Errorf("unknown worker: %s", id)
+	}
+
+	oneshot = &oneshotWorker{name: id}
+	InitWorkers()
+	conf := oneshot.conf
+	oneshot = nil
+
+	if conf == nil {
+		return fmt.Errorf("worker not available: %s", id)
+	}
+	if conf.Disabled && !force {
+		return errors.New("worker is disabled, use --force to run anyway")
+	}
+
+	_, task := newWorker(conf, force)
+	if err := task.TaskFunc(); err != nil {
+		task.ErrFunc(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+func (w *Worker) getSchedule() string {
+	if w.cron != nil {
+		return w.cron.String()
+	}
+	return w.interval.String()
+}
+
+// Adds the task to the scheduler. Cron scheduled workers are added as a
+// one-off task, which schedules the next one when it fires.
+func (w *Worker) scheduleNext() error {
+	if w.cron == nil {
+		t := w.task.Clone()
+		taskFunc := t.TaskFunc
+		t.TaskFunc = func() error {
+			w.nextRunAt.Store(time.Now().Add(w.interval).UnixNano())
+			return taskFunc()
+		}
+		w.nextRunAt.Store(time.Now().Add(w.interval).UnixNano())
+		_, err := w.scheduler.Add(t)
+		return err
+	}
+
+	if w.stopped.Load() {
+		return nil
+	}
+
+	// the timer can fire a little early, don't match the same time again
+	after := time.Now()
+	if prev := time.Unix(0, w.nextRunAt.Load()); prev.After(after) {
+		after = prev
+	}
+	next := w.cron.Next(after)
+	if next.IsZero() {
+		return errors.New("no next run for schedule: " + w.cron.String())
+	}
+
+	t := w.task.Clone()
+	t.Interval = max(time.Until(next), time.Millisecond)
+	t.RunOnce = true
+	taskFunc := t.TaskFunc
+	t.TaskFunc = func() error {
+		if err := w.scheduleNext(); err != nil {
+			w.Log.Error("failed to schedule next run", "error", err)
+		}
+		return taskFunc()
+	}
+	w.nextRunAt.Store(next.UnixNano())
+	_, err := w.scheduler.Add(t)
+	return err
+}
+
+// Whether the next run is due, after the last one at `lastRunAt`.
+func (w *Worker) isDue(lastRunAt time.Time) bool {
+	if w.cron != nil {
+		next := w.cron.Next(lastRunAt)
+		return !next.IsZero() && !next.After(time.Now().Add(time.Minute))
+	}
+	return util.HasDurationPassedSince(lastRunAt, w.interval)
+}
+
+// Returns zero time if the worker is not scheduled on this instance.
+func GetNextRunAt(id string) time.Time {
+	w := getScheduledWorker(id)
+	if w == nil {
+		return time.Time{}
+	}
+	nextRunAt := w.nextRunAt.Load()
+	if nextRunAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nextRunAt)
+}
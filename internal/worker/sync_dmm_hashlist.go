@@ -241,6 +241,9 @@ func InitSyncDMMHashlistWorker(conf *WorkerConfig) *Worker {
 
 		totalCount := 0
 		for _, filename := range files {
+			if w.IsCancelled() {
+				return ErrJobCancelled
+			}
 			if !hashlistFilenameRegex.MatchString(filename) {
 				continue
 			}
@@ -7,7 +7,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/util"
 )
 
-var syncIMDBJobTracker *JobTracker[struct{}]
+var syncIMDBJobTracker *JobTracker[JobProgress]
 
 func isIMDBSyncedInLast24Hours() bool {
 	if syncIMDBJobTracker == nil {
@@ -22,10 +22,14 @@ func isIMDBSyncedInLast24Hours() bool {
 
 func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
 	conf.Executor = func(w *Worker) error {
-		if err := imdb_title.SyncDataset(); err != nil {
-			return err
-		}
-		return nil
+		w.SetPhase("sync", 0)
+		return imdb_title.SyncDataset(func(count int) error {
+			w.AddProcessed(count)
+			if w.IsCancelled() {
+				return ErrJobCancelled
+			}
+			return nil
+		})
 	}
 
 	worker := NewWorker(conf)
@@ -33,6 +33,9 @@ func InitParseTorrentWorker(conf *WorkerConfig) *Worker {
 	conf.Executor = func(w *Worker) error {
 		log := w.Log
 		for {
+			if w.IsCancelled() {
+				return ErrJobCancelled
+			}
 			tInfos, err := ti.GetUnparsed(5000)
 			if err != nil {
 				return err
@@ -40,6 +40,7 @@ func InitTorznabIndexerSyncerWorker(conf *WorkerConfig) *Worker {
 		}
 
 		log.Info("processing pending sync items", "count", len(pendingItems))
+		w.SetPhase("sync", len(pendingItems))
 
 		itemsByIndexerId := make(map[string][]torznab_indexer_syncinfo.TorznabIndexerSyncInfo)
 		for _, item := range pendingItems {
@@ -88,6 +89,7 @@ func InitTorznabIndexerSyncerWorker(conf *WorkerConfig) *Worker {
 
 				for i := range items {
 					item := &items[i]
+					w.AddProcessed(1)
 
 					queries := item.Queries
 					if len(queries) == 0 {
@@ -145,7 +147,7 @@ func InitTorznabIndexerSyncerWorker(conf *WorkerConfig) *Worker {
 						}
 
 						start := time.Now()
-						qResults, err := client.Search(query)
+						qResults, err := client.Search(w.Context(), query)
 						if err != nil {
 							log.Error("indexer search failed", "error", err, "indexer", indexer.Name, "query", sQuery.Query, "duration", time.Since(start).String())
 							sQuery.Error = err.Error()
@@ -1,18 +1,25 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/db"
 	"github.com/MunifTanjim/stremthru/internal/job_log"
 	"github.com/MunifTanjim/stremthru/internal/logger"
+	"github.com/MunifTanjim/stremthru/internal/metrics"
 	torznab_indexer "github.com/MunifTanjim/stremthru/internal/torznab/indexer"
 	torznab_indexer_syncinfo "github.com/MunifTanjim/stremthru/internal/torznab/indexer/syncinfo"
+	"github.com/MunifTanjim/stremthru/internal/tracing"
 	"github.com/MunifTanjim/stremthru/internal/util"
 	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
 	"github.com/madflojo/tasks"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var mutex sync.Mutex
@@ -36,18 +43,98 @@ type Worker struct {
 	onStart    func()
 	onEnd      func()
 	Log        *logger.Logger
-	jobTracker *JobTracker[struct{}]
+	jobTracker *JobTracker[JobProgress]
+
+	jobId   atomic.Value // string
+	running atomic.Int32
+	// held for the whole run, by scheduled and manual ones alike
+	runM sync.Mutex
+	run  func() error
+
+	task      *tasks.Task
+	manualRun atomic.Bool
+	jobM      sync.Mutex
+	job       *workerJob
+	progress  jobProgress
+
+	retryPolicy    *worker_queue.RetryPolicy
+	failedAttempts atomic.Int32
+
+	interval  time.Duration
+	cron      *util.CronSchedule
+	nextRunAt atomic.Int64 // unix nano
+	stopped   atomic.Bool
+
+	name              string
+	heartbeatInterval time.Duration
+	lastHeartbeatAt   atomic.Int64 // unix nano
+}
+
+func (w *Worker) getJobId() string {
+	jobId, _ := w.jobId.Load().(string)
+	return jobId
+}
+
+// Unschedules the worker and waits for the running job to finish. If
+// `ctx` is done before that, the job is marked as interrupted so that
+// the next instance does not wait for heartbeat timeout.
+func (w *Worker) stop(ctx context.Context) {
+	w.stopped.Store(true)
+	w.scheduler.Stop()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for w.running.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			if jobId := w.getJobId(); jobId != "" {
+				w.Log.Warn("interrupting running job", "jobId", jobId)
+				if err := w.jobTracker.Set(jobId, "failed", "interrupted by shutdown", w.progress.get()); err != nil {
+					w.Log.Error("failed to set job status", "error", err, "jobId", jobId, "status", "failed")
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Schedules a one-off run after backoff, so that a failed job does not
+// have to wait for the next interval.
+func (w *Worker) scheduleRetry(cause error) {
+	if w.retryPolicy == nil || errors.Is(cause, ErrJobCancelled) || getScheduledWorker(w.name) != w {
+		return
+	}
+
+	attempts := int(w.failedAttempts.Add(1))
+	delay, ok := w.retryPolicy.NextDelay(attempts)
+	if !ok {
+		w.Log.Warn("out of retry attempts, waiting for next interval", "attempts", attempts)
+		w.failedAttempts.Store(0)
+		return
+	}
+
+	t := w.task.Clone()
+	t.Interval = delay
+	t.RunOnce = true
+	if _, err := w.scheduler.Add(t); err != nil {
+		w.Log.Error("failed to schedule retry", "error", err)
+		return
+	}
+	w.Log.Info("retrying after backoff", "attempts", attempts, "delay", delay)
 }
 
 type WorkerConfig struct {
 	Disabled          bool
 	Executor          func(w *Worker) error
 	Interval          time.Duration
+	Schedule          *util.CronSchedule // takes precedence over `Interval`
 	HeartbeatInterval time.Duration
 	Log               *logger.Logger
 	Name              string
 	OnEnd             func()
 	OnStart           func()
+	Retry             *worker_queue.RetryPolicy // for failed jobs, instead of waiting for the next run
 	RunAtStartupAfter time.Duration
 	RunExclusive      bool
 	ShouldSkip        func() bool
@@ -58,11 +145,15 @@ type WorkerDetail struct {
 	Id       string        `json:"id"`
 	Title    string        `json:"title"`
 	Interval time.Duration `json:"interval"`
+	Schedule string        `json:"schedule"`
+	// Executor checks `IsCancelled` between batches.
+	Cancellable bool `json:"cancellable"`
 }
 
 var WorkerDetailsById = map[string]*WorkerDetail{
 	"parse-torrent": {
-		Title: "Parse Torrent",
+		Title:       "Parse Torrent",
+		Cancellable: true,
 	},
 	"push-torrent": {
 		Title: "Push Torrent",
@@ -71,13 +162,16 @@ var WorkerDetailsById = map[string]*WorkerDetail{
 		Title: "Crawl Store",
 	},
 	"sync-imdb": {
-		Title: "Sync IMDB",
+		Title:       "Sync IMDB",
+		Cancellable: true,
 	},
 	"sync-dmm-hashlist": {
-		Title: "Sync DMM Hashlist",
+		Title:       "Sync DMM Hashlist",
+		Cancellable: true,
 	},
 	"map-imdb-torrent": {
-		Title: "Map IMDB Torrent",
+		Title:       "Map IMDB Torrent",
+		Cancellable: true,
 	},
 	"pull-magnet-cache": {
 		Title: "Pull Magnet Cache",
@@ -98,7 +192,8 @@ var WorkerDetailsById = map[string]*WorkerDetail{
 		Title: "Sync Manami Anime Database",
 	},
 	"map-anidb-torrent": {
-		Title: "Map AniDB Torrent",
+		Title:       "Map AniDB Torrent",
+		Cancellable: true,
 	},
 	"sync-letterboxd-list": {
 		Title: "Sync Letterboxd List",
@@ -131,17 +226,64 @@ func NewWorker(conf *WorkerConfig) *Worker {
 		panic("worker name cannot be empty")
 	}
 
+	if config.Worker.IsDisabled(conf.Name) {
+		conf.Disabled = true
+	}
+	if schedule, ok := config.Worker.GetSchedule(conf.Name); ok {
+		if schedule.Cron != nil {
+			conf.Schedule = schedule.Cron
+		} else {
+			conf.Interval = schedule.Interval
+		}
+	}
+
 	if details, ok := WorkerDetailsById[conf.Name]; !ok {
 		panic("worker details not present: " + conf.Name)
 	} else {
 		details.Id = conf.Name
-		details.Interval = conf.Interval
+		if conf.Schedule != nil {
+			details.Interval = 0
+			details.Schedule = conf.Schedule.String()
+		} else {
+			details.Interval = conf.Interval
+			details.Schedule = conf.Interval.String()
+		}
+	}
+
+	if oneshot != nil {
+		if oneshot.name == conf.Name {
+			oneshot.conf = conf
+		}
+		return nil
 	}
 
 	if conf.Disabled {
 		return nil
 	}
 
+	worker, task := newWorker(conf, false)
+
+	if err := worker.scheduleNext(); err != nil {
+		panic(err)
+	}
+
+	worker.Log.Info("Started Worker", "schedule", worker.getSchedule())
+
+	scheduledWorkers.Store(conf.Name, worker)
+
+	if conf.RunAtStartupAfter != 0 {
+		t := task.Clone()
+		t.Interval = conf.RunAtStartupAfter
+		t.RunOnce = true
+		worker.scheduler.Add(t)
+	}
+
+	return worker
+}
+
+// Builds the worker and its task without scheduling it. With `force`,
+// the last job being recently done does not cause the run to be skipped.
+func newWorker(conf *WorkerConfig, force bool) (*Worker, *tasks.Task) {
 	if conf.Log == nil {
 		conf.Log = logger.Scoped("worker/" + conf.Name)
 	}
@@ -177,28 +319,50 @@ func NewWorker(conf *WorkerConfig) *Worker {
 		onStart:    conf.OnStart,
 		onEnd:      conf.OnEnd,
 		Log:        log,
+
+		name:              conf.Name,
+		heartbeatInterval: conf.HeartbeatInterval + heartbeatIntervalTolerance,
+
+		retryPolicy: conf.Retry,
+
+		interval: conf.Interval,
+		cron:     conf.Schedule,
 	}
 
 	jobTrackerExpiresIn := max(3*24*time.Hour, 10*conf.Interval)
-	jobTracker := NewJobTracker[struct{}](conf.Name, jobTrackerExpiresIn)
+	jobTracker := NewJobTracker[JobProgress](conf.Name, jobTrackerExpiresIn)
 	worker.jobTracker = jobTracker
 
-	jobId := ""
-	id, err := worker.scheduler.Add(&tasks.Task{
+	worker.jobId.Store("")
+	task := &tasks.Task{
 		Interval:          conf.Interval,
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
-			isAlreadyRunning := jobId != ""
+			worker.running.Add(1)
+			defer worker.running.Add(-1)
+
+			force := force || worker.manualRun.Swap(false)
+
+			isAlreadyRunning := worker.getJobId() != ""
 			defer func() {
 				if perr, stack := util.HandlePanic(recover(), true); perr != nil {
 					err = perr
 					log.Error("Worker Panic", "error", err, "stack", stack)
 				} else if err == nil && !isAlreadyRunning {
-					jobId = ""
+					worker.jobId.Store("")
 				}
 				worker.onEnd()
 			}()
 
+			if !force {
+				if state, err := GetState(conf.Name); err != nil {
+					log.Error("failed to get worker state", "error", err)
+				} else if state.Paused {
+					log.Info("skipping, paused")
+					return nil
+				}
+			}
+
 			if worker.shouldSkip != nil && worker.shouldSkip() {
 				log.Info("skipping")
 				return nil
@@ -230,7 +394,7 @@ func NewWorker(conf *WorkerConfig) *Worker {
 			}
 			defer lock.Release()
 
-			var tjob *job_log.ParsedJobLog[struct{}]
+			var tjob *job_log.ParsedJobLog[JobProgress]
 			if conf.RunExclusive {
 				tjob, err = jobTracker.GetLast()
 				if err != nil {
@@ -250,11 +414,11 @@ func NewWorker(conf *WorkerConfig) *Worker {
 						}
 
 						log.Warn("last job heartbeat timed out, restarting", "jobId", tjob.Id, "status", status)
-						if err := jobTracker.Set(tjob.Id, "failed", "heartbeat timed out", nil); err != nil {
+						if err := jobTracker.Set(tjob.Id, "failed", "heartbeat timed out", tjob.Data); err != nil {
 							log.Error("failed to set last job status", "error", err, "jobId", tjob.Id, "status", "failed")
 						}
 					case "done":
-						if !util.HasDurationPassedSince(tjob.CreatedAt, conf.Interval) {
+						if !force && !worker.isDue(tjob.CreatedAt) {
 							log.Info("already done", "jobId", tjob.Id, "status", status)
 							return nil
 						}
@@ -264,13 +428,18 @@ func NewWorker(conf *WorkerConfig) *Worker {
 				}
 			}
 
-			jobId = time.Now().Format(time.DateTime)
+			jobId := time.Now().Format(time.DateTime)
+			worker.jobId.Store(jobId)
 
+			worker.progress.reset()
 			err = jobTracker.Set(jobId, "started", "", nil)
 			if err != nil {
 				log.Error("failed to set job status", "error", err, "jobId", jobId, "status", "started")
 				return err
 			}
+			worker.lastHeartbeatAt.Store(time.Now().UnixNano())
+			worker.startJob(jobId)
+			defer worker.endJob()
 
 			if !lock.Release() {
 				log.Error("failed to release advisory lock", "name", lock.GetName())
@@ -284,12 +453,15 @@ func NewWorker(conf *WorkerConfig) *Worker {
 				for {
 					select {
 					case <-heartbeat.C:
-						if jobId == "" {
+						if worker.getJobId() == "" {
 							return
 						}
-						if err := jobTracker.Set(jobId, "started", "", nil); err != nil {
+						if err := jobTracker.Set(jobId, "started", "", worker.progress.get()); err != nil {
 							log.Error("failed to set job status heartbeat", "error", err, "jobId", jobId)
+						} else {
+							worker.lastHeartbeatAt.Store(time.Now().UnixNano())
 						}
+						worker.syncJobState(jobId)
 					case <-heartbeat_done:
 						heartbeat.Stop()
 						return
@@ -297,16 +469,32 @@ func NewWorker(conf *WorkerConfig) *Worker {
 				}
 			}()
 
-			if err = conf.Executor(worker); err != nil {
+			ctx, span := tracing.Start(worker.Context(), "worker "+conf.Name, trace.WithAttributes(
+				attribute.String("worker.id", conf.Name),
+				attribute.String("worker.job_id", jobId),
+			))
+			worker.setJobContext(ctx)
+			startedAt := time.Now()
+			err = conf.Executor(worker)
+			metrics.ObserveWorkerRun(conf.Name, time.Since(startedAt), err)
+			tracing.End(span, err)
+			if worker.IsCancelled() {
+				if err := clearCancel(conf.Name, jobId); err != nil {
+					log.Error("failed to clear worker state", "error", err, "jobId", jobId)
+				}
+			}
+			if err != nil {
 				return err
 			}
 
-			err = jobTracker.Set(jobId, "done", "", nil)
+			err = jobTracker.Set(jobId, "done", "", worker.progress.get())
 			if err != nil {
 				log.Error("failed to set job status", "error", err, "jobId", jobId, "status", "done")
 				return err
 			}
 
+			worker.failedAttempts.Store(0)
+
 			log.Info("done", "jobId", jobId)
 
 			return err
@@ -318,34 +506,48 @@ func NewWorker(conf *WorkerConfig) *Worker {
 				if perr, stack := util.HandlePanic(recover(), true); perr != nil {
 					log.Error("Worker Err Panic", "error", perr, "stack", stack)
 				}
-				jobId = ""
+				worker.jobId.Store("")
 			}()
 
-			if terr := jobTracker.Set(jobId, "failed", err.Error(), nil); terr != nil {
+			jobId := worker.getJobId()
+			if terr := jobTracker.Set(jobId, "failed", err.Error(), worker.progress.get()); terr != nil {
 				log.Error("failed to set job status", "error", terr, "jobId", jobId, "status", "failed")
 			}
-		},
-	})
 
-	if err != nil {
-		panic(err)
+			worker.scheduleRetry(err)
+		},
 	}
 
-	log.Info("Started Worker", "id", id)
-
-	if conf.RunAtStartupAfter != 0 {
-		if task, err := worker.scheduler.Lookup(id); err == nil && task != nil {
-			t := task.Clone()
-			t.Interval = conf.RunAtStartupAfter
-			t.RunOnce = true
-			worker.scheduler.Add(t)
+	// `RunSingleInstance` does not hold across clones of the task
+	worker.run = task.TaskFunc
+	task.TaskFunc = func() error {
+		if !worker.runM.TryLock() {
+			log.Debug("skipping, already running")
+			return nil
 		}
+		defer worker.runM.Unlock()
+		return worker.run()
 	}
 
-	return worker
+	worker.task = task
+
+	return worker, task
+}
+
+// For dataset syncs, which otherwise wait for hours or days after failure.
+var datasetSyncRetryPolicy = &worker_queue.RetryPolicy{
+	MaxAttempts: 4,
+	Backoff:     15 * time.Minute,
+	MaxBackoff:  2 * time.Hour,
 }
 
-func InitWorkers() func() {
+func InitWorkers() func(ctx context.Context) {
+	for _, id := range config.Worker.Ids() {
+		if _, ok := WorkerDetailsById[id]; !ok {
+			panic("unknown worker id in config: " + id)
+		}
+	}
+
 	workers := []*Worker{}
 
 	if worker := InitParseTorrentWorker(&WorkerConfig{
@@ -427,6 +629,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.HasIMDBTitle(),
 		Name:              "sync-imdb",
 		Interval:          24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 30 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -452,6 +655,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.HasDMMHashlist(),
 		Name:              "sync-dmm-hashlist",
 		Interval:          6 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 30 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -556,6 +760,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.IsEnabled("anime"),
 		Name:              "sync-animeapi",
 		Interval:          1 * 24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 45 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -588,6 +793,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.IsEnabled("anime"),
 		Name:              "sync-anidb-titles",
 		Interval:          1 * 24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 30 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -613,6 +819,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.IsEnabled("anime"),
 		Name:              "sync-anidb-tvdb-episode-map",
 		Interval:          1 * 24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 45 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -645,6 +852,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.IsEnabled("anime"),
 		Name:              "manami-anime-database",
 		Interval:          6 * 24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 60 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -785,6 +993,7 @@ func InitWorkers() func() {
 		Disabled:          !config.Feature.IsEnabled("anime"),
 		Name:              "sync-animetosho",
 		Interval:          24 * time.Hour,
+		Retry:             datasetSyncRetryPolicy,
 		RunAtStartupAfter: 90 * time.Second,
 		RunExclusive:      true,
 		ShouldWait: func() (bool, string) {
@@ -888,9 +1097,13 @@ func InitWorkers() func() {
 		workers = append(workers, worker)
 	}
 
-	return func() {
+	return func(ctx context.Context) {
+		var wg sync.WaitGroup
 		for _, worker := range workers {
-			worker.scheduler.Stop()
+			wg.Go(func() {
+				worker.stop(ctx)
+			})
 		}
+		wg.Wait()
 	}
 }
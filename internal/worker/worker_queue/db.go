@@ -0,0 +1,368 @@
+package worker_queue
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+// Encrypted value is stored as JSON string, since the column is JSON.
+func encryptWith(secret, value string) (string, error) {
+	encrypted, err := core.Encrypt(secret, value)
+	if err != nil {
+		return "", err
+	}
+	blob, err := json.Marshal(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(blob), nil
+}
+
+// Also accepts the bare ciphertext, as stored by earlier versions.
+func decryptWith(secret, value string) (string, error) {
+	encrypted := value
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal([]byte(value), &encrypted); err != nil {
+			return "", err
+		}
+	}
+	return core.Decrypt(secret, encrypted)
+}
+
+// Plaintext value is the JSON object of the item.
+func isEncrypted(value string) bool {
+	return !strings.HasPrefix(value, "{")
+}
+
+func encrypt(value string) (string, error) {
+	return encryptWith(config.VaultSecret, value)
+}
+
+func decrypt(value string) (string, error) {
+	return decryptWith(config.VaultSecret, value)
+}
+
+// Plaintext value, queued without vault secret, is encrypted.
+func rotateValue(oldSecret, newSecret, value string) (string, error) {
+	if !isEncrypted(value) {
+		return encryptWith(newSecret, value)
+	}
+	decrypted, err := decryptWith(oldSecret, value)
+	if err != nil {
+		return "", err
+	}
+	return encryptWith(newSecret, decrypted)
+}
+
+const TableName = "worker_queue"
+
+type QueueEntry struct {
+	Queue      string
+	Key        string
+	GroupKey   string
+	Value      string // JSON Encoded Value
+	Seq        int
+	Attempts   int
+	RunAt      db.Timestamp
+	LeaseOwner string
+	LeaseUntil db.Timestamp
+	CAt        db.Timestamp
+	UAt        db.Timestamp
+}
+
+var Column = struct {
+	Queue      string
+	Key        string
+	GroupKey   string
+	Value      string
+	Seq        string
+	Attempts   string
+	RunAt      string
+	LeaseOwner string
+	LeaseUntil string
+	CAt        string
+	UAt        string
+}{
+	Queue:      "queue",
+	Key:        "key",
+	GroupKey:   "group_key",
+	Value:      "value",
+	Seq:        "seq",
+	Attempts:   "attempts",
+	RunAt:      "run_at",
+	LeaseOwner: "lease_owner",
+	LeaseUntil: "lease_until",
+	CAt:        "cat",
+	UAt:        "uat",
+}
+
+var query_upsert_before_values = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES `,
+	TableName,
+	db.JoinColumnNames(
+		Column.Queue,
+		Column.Key,
+		Column.GroupKey,
+		Column.Value,
+		Column.RunAt,
+	),
+)
+var query_upsert_values_placeholder = "(" + util.RepeatJoin("?", 5, ",") + ")"
+
+// re-queueing bumps `seq`, resets `attempts` and pushes back `run_at`,
+// leaving the lease as is
+var query_upsert_on_conflict = fmt.Sprintf(
+	` ON CONFLICT (%s) DO UPDATE SET "%s" = EXCLUDED."%s", "%s" = EXCLUDED."%s", "%s" = EXCLUDED."%s", "%s" = %s."%s" + 1, "%s" = 0, "%s" = %s`,
+	db.JoinColumnNames(Column.Queue, Column.Key),
+	Column.GroupKey, Column.GroupKey,
+	Column.Value, Column.Value,
+	Column.RunAt, Column.RunAt,
+	Column.Seq, TableName, Column.Seq,
+	Column.Attempts,
+	Column.UAt, db.CurrentTimestamp,
+)
+
+var query_upsert = query_upsert_before_values + query_upsert_values_placeholder + query_upsert_on_conflict
+
+func upsert(queue, key, groupKey, value string, runAt time.Time) error {
+	_, err := db.Exec(query_upsert, queue, key, groupKey, value, db.Timestamp{Time: runAt})
+	return err
+}
+
+const upsertBatchSize = 500
+
+// Keys of `entries` must be unique.
+func upsertMany(queue string, entries []QueueEntry) error {
+	args := make([]any, 0, len(entries)*5)
+	for i := range entries {
+		entry := &entries[i]
+		args = append(args, queue, entry.Key, entry.GroupKey, entry.Value, entry.RunAt)
+	}
+	query := query_upsert_before_values +
+		util.RepeatJoin(query_upsert_values_placeholder, len(entries), ",") +
+		query_upsert_on_conflict
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+var query_claimable_cond = fmt.Sprintf(
+	`"%s" = ? AND "%s" <= ? AND ("%s" IS NULL OR "%s" < ?)`,
+	Column.Queue,
+	Column.RunAt,
+	Column.LeaseUntil,
+	Column.LeaseUntil,
+)
+
+var query_claim = fmt.Sprintf(
+	`UPDATE %s SET "%s" = ?, "%s" = ?, "%s" = %s WHERE %s AND "%s" IN (SELECT "%s" FROM %s WHERE %s ORDER BY "%s" LIMIT ?)`,
+	TableName,
+	Column.LeaseOwner,
+	Column.LeaseUntil,
+	Column.UAt,
+	db.CurrentTimestamp,
+	query_claimable_cond,
+	Column.Key,
+	Column.Key,
+	TableName,
+	query_claimable_cond,
+	Column.RunAt,
+)
+
+var query_get_claimed = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE "%s" = ? AND "%s" = ? ORDER BY "%s"`,
+	db.JoinColumnNames(
+		Column.Key,
+		Column.GroupKey,
+		Column.Value,
+		Column.Seq,
+		Column.Attempts,
+	),
+	TableName,
+	Column.Queue,
+	Column.LeaseOwner,
+	Column.RunAt,
+)
+
+// Leases up to `limit` due entries to `owner`. Entries with expired lease
+// are claimable again.
+func claim(queue, owner string, leaseTime time.Duration, limit int) ([]QueueEntry, error) {
+	now := db.Timestamp{Time: time.Now()}
+	leaseUntil := db.Timestamp{Time: now.Add(leaseTime)}
+	_, err := db.Exec(query_claim, owner, leaseUntil, queue, now, now, queue, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(query_get_claimed, queue, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []QueueEntry{}
+	for rows.Next() {
+		entry := QueueEntry{Queue: queue, LeaseOwner: owner, LeaseUntil: leaseUntil}
+		if err := rows.Scan(&entry.Key, &entry.GroupKey, &entry.Value, &entry.Seq, &entry.Attempts); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+var query_release = fmt.Sprintf(
+	`UPDATE %s SET "%s" = '', "%s" = NULL, "%s" = %s WHERE "%s" = ? AND "%s" = ? AND "%s" = ?`,
+	TableName,
+	Column.LeaseOwner,
+	Column.LeaseUntil,
+	Column.UAt,
+	db.CurrentTimestamp,
+	Column.Queue,
+	Column.Key,
+	Column.LeaseOwner,
+)
+
+// Removes the entry, unless it was re-queued after the claim, in which case
+// only the lease is released.
+func complete(entry *QueueEntry) error {
+	removed, err := remove(entry)
+	if err != nil || removed {
+		return err
+	}
+	return release(entry)
+}
+
+func release(entry *QueueEntry) error {
+	_, err := db.Exec(query_release, entry.Queue, entry.Key, entry.LeaseOwner)
+	return err
+}
+
+var query_retry = fmt.Sprintf(
+	`UPDATE %s SET "%s" = "%s" + 1, "%s" = ?, "%s" = '', "%s" = NULL, "%s" = %s WHERE "%s" = ? AND "%s" = ? AND "%s" = ?`,
+	TableName,
+	Column.Attempts,
+	Column.Attempts,
+	Column.RunAt,
+	Column.LeaseOwner,
+	Column.LeaseUntil,
+	Column.UAt,
+	db.CurrentTimestamp,
+	Column.Queue,
+	Column.Key,
+	Column.Seq,
+)
+
+// Counts the failed attempt and releases the entry to be claimed again at
+// `runAt`. If it was re-queued after the claim, only the lease is released.
+func retry(entry *QueueEntry, runAt time.Time) error {
+	result, err := db.Exec(query_retry, db.Timestamp{Time: runAt}, entry.Queue, entry.Key, entry.Seq)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err == nil && count > 0 {
+		return nil
+	}
+	return release(entry)
+}
+
+var query_remove = fmt.Sprintf(
+	`DELETE FROM %s WHERE "%s" = ? AND "%s" = ? AND "%s" = ?`,
+	TableName,
+	Column.Queue,
+	Column.Key,
+	Column.Seq,
+)
+
+// Removes the entry, unless it was re-queued after the claim.
+func remove(entry *QueueEntry) (bool, error) {
+	result, err := db.Exec(query_remove, entry.Queue, entry.Key, entry.Seq)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+var query_exists = fmt.Sprintf(
+	`SELECT 1 FROM %s WHERE "%s" = ? LIMIT 1`,
+	TableName,
+	Column.Queue,
+)
+
+func exists(queue string) (bool, error) {
+	var one int
+	err := db.QueryRow(query_exists, queue).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+var query_get_values = fmt.Sprintf(
+	`SELECT "%s", "%s" FROM %s WHERE "%s" = ?`,
+	Column.Key,
+	Column.Value,
+	TableName,
+	Column.Queue,
+)
+
+var query_set_value = fmt.Sprintf(
+	`UPDATE %s SET "%s" = ? WHERE "%s" = ? AND "%s" = ?`,
+	TableName,
+	Column.Value,
+	Column.Queue,
+	Column.Key,
+)
+
+// Re-encrypts the value of every item, and dead letter, in the sensitive
+// queues from `oldSecret` to `newSecret`, within `tx`. Plaintext values are
+// encrypted.
+func RotateSecret(tx *db.Tx, oldSecret, newSecret string) (int, error) {
+	count := 0
+	for _, queue := range sensitiveQueues {
+		rows, err := tx.Query(query_get_values, queue)
+		if err != nil {
+			return 0, err
+		}
+		entries := []QueueEntry{}
+		for rows.Next() {
+			entry := QueueEntry{Queue: queue}
+			if err := rows.Scan(&entry.Key, &entry.Value); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			entries = append(entries, entry)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			value, err := rotateValue(oldSecret, newSecret, entry.Value)
+			if err != nil {
+				return 0, fmt.Errorf("failed to re-encrypt value for %s:%s: %w", queue, entry.Key, err)
+			}
+			if _, err := tx.Exec(query_set_value, value, queue, entry.Key); err != nil {
+				return 0, err
+			}
+		}
+		count += len(entries)
+
+		deadLetterCount, err := rotateDeadLetterSecret(tx, queue, oldSecret, newSecret)
+		if err != nil {
+			return 0, err
+		}
+		count += deadLetterCount
+	}
+	return count, nil
+}
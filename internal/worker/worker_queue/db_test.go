@@ -0,0 +1,139 @@
+package worker_queue
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/job_log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Needs a database migrated by stremthru, e.g.
+// `STREMTHRU_DATABASE_URI=postgresql://... go test ./internal/worker/worker_queue`
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if os.Getenv("STREMTHRU_DATABASE_URI") == "" {
+		t.Skip("STREMTHRU_DATABASE_URI is not set")
+	}
+	db.Open()
+	t.Cleanup(func() {
+		db.Close()
+	})
+}
+
+func cleanupTestQueue(t *testing.T, queue string) {
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM `+TableName+` WHERE "`+Column.Queue+`" = ?`, queue); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func newTestSensitiveQueue(name string) *WorkerQueue[StoreCrawlerQueueItem] {
+	return &WorkerQueue[StoreCrawlerQueueItem]{
+		name: name,
+		getKey: func(item StoreCrawlerQueueItem) string {
+			return item.StoreCode
+		},
+		transform: func(item *StoreCrawlerQueueItem) *StoreCrawlerQueueItem {
+			return item
+		},
+		sensitive: true,
+	}
+}
+
+func setTestVaultSecret(t *testing.T, secret string) {
+	vaultSecret := config.VaultSecret
+	config.VaultSecret = secret
+	t.Cleanup(func() {
+		config.VaultSecret = vaultSecret
+	})
+}
+
+func TestSensitiveQueueRoundTrip(t *testing.T) {
+	openTestDB(t)
+
+	item := StoreCrawlerQueueItem{StoreCode: "rd", StoreToken: "store-token"}
+
+	t.Run("encrypted", func(t *testing.T) {
+		setTestVaultSecret(t, "test-secret")
+
+		q := newTestSensitiveQueue("test:sensitive:encrypted")
+		cleanupTestQueue(t, q.name)
+		q.Queue(item)
+
+		var value string
+		require.NoError(t, db.QueryRow(query_get_values, q.name).Scan(new(string), &value))
+		assert.NotContains(t, value, item.StoreToken)
+		// the column is json
+		assert.True(t, json.Valid([]byte(value)))
+
+		items := q.claim()
+		require.Len(t, items, 1)
+		assert.Equal(t, item, items[0].v)
+	})
+
+	t.Run("plaintext without vault", func(t *testing.T) {
+		setTestVaultSecret(t, "")
+
+		q := newTestSensitiveQueue("test:sensitive:plaintext")
+		cleanupTestQueue(t, q.name)
+		q.Queue(item)
+
+		items := q.claim()
+		require.Len(t, items, 1)
+		assert.Equal(t, item, items[0].v)
+	})
+}
+
+func TestRotateSecret(t *testing.T) {
+	openTestDB(t)
+	setTestVaultSecret(t, "old-secret")
+
+	q := newTestSensitiveQueue("test:sensitive:rotate")
+	cleanupTestQueue(t, q.name)
+	queues := sensitiveQueues
+	sensitiveQueues = []string{q.name}
+	t.Cleanup(func() {
+		sensitiveQueues = queues
+	})
+
+	queued := StoreCrawlerQueueItem{StoreCode: "rd", StoreToken: "queued-token"}
+	buried := StoreCrawlerQueueItem{StoreCode: "tb", StoreToken: "buried-token"}
+	q.Queue(buried)
+	items := q.claim()
+	require.Len(t, items, 1)
+	require.NoError(t, bury(&items[0].entry, 1, errors.New("failed")))
+	t.Cleanup(func() {
+		job_log.PurgeJobLogs(getDeadLetterJobLogName(q.name))
+	})
+	q.Queue(queued)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	count, err := RotateSecret(tx, "old-secret", "new-secret")
+	if err != nil {
+		tx.Rollback()
+	}
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 2, count)
+
+	config.VaultSecret = "new-secret"
+
+	dl, err := GetDeadLetter(q.name, q.getKey(buried))
+	require.NoError(t, err)
+	require.NotNil(t, dl)
+	item := StoreCrawlerQueueItem{}
+	require.NoError(t, q.decode(dl.Data.Value, &item))
+	assert.Equal(t, buried, item)
+
+	items = q.claim()
+	require.Len(t, items, 1)
+	assert.Equal(t, queued, items[0].v)
+}
@@ -0,0 +1,200 @@
+package worker_queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/job_log"
+)
+
+const deadLetterJobLogNamePrefix = "dead-letter:"
+
+const deadLetterLifetime = 30 * 24 * time.Hour
+
+// Queue item that failed all of its attempts, kept in `job_log` under
+// `dead-letter:<queue>` with the item key as id.
+type DeadLetter struct {
+	Queue    string `json:"queue"`
+	Key      string `json:"key"`
+	GroupKey string `json:"group_key,omitempty"`
+	// As stored in the queue, i.e. encrypted for sensitive queues with vault
+	// secret.
+	Value    string `json:"value"`
+	Attempts int    `json:"attempts"`
+}
+
+var redactedFields = []string{"StoreToken", "ClientIP"}
+
+// Value of the item, with the credentials redacted.
+func (dl *DeadLetter) GetRedactedValue() json.RawMessage {
+	value := dl.Value
+	if isEncrypted(value) {
+		decrypted, err := decrypt(value)
+		if err != nil {
+			return json.RawMessage("null")
+		}
+		value = decrypted
+	}
+	var item any
+	if err := json.Unmarshal([]byte(value), &item); err != nil {
+		return json.RawMessage("null")
+	}
+	if fields, ok := item.(map[string]any); ok {
+		for _, field := range redactedFields {
+			if v, ok := fields[field]; ok && v != "" {
+				fields[field] = "...redacted..."
+			}
+		}
+	}
+	blob, err := json.Marshal(item)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return blob
+}
+
+func getDeadLetterJobLogName(queue string) string {
+	return deadLetterJobLogNamePrefix + queue
+}
+
+// Moves the entry to dead letters, unless it was re-queued after the claim,
+// in which case only the lease is released.
+func bury(entry *QueueEntry, attempts int, cause error) error {
+	name := getDeadLetterJobLogName(entry.Queue)
+	err := job_log.SaveJobLog(name, entry.Key, "failed", &DeadLetter{
+		Queue:    entry.Queue,
+		Key:      entry.Key,
+		GroupKey: entry.GroupKey,
+		Value:    entry.Value,
+		Attempts: attempts,
+	}, cause.Error(), deadLetterLifetime)
+	if err != nil {
+		return err
+	}
+
+	removed, err := remove(entry)
+	if err != nil || !removed {
+		if derr := job_log.DeleteJobLog(name, entry.Key); derr != nil {
+			log.Error("failed to delete dead letter", "error", derr, "queue", entry.Queue, "key", entry.Key)
+		}
+		if err != nil {
+			return err
+		}
+		return release(entry)
+	}
+	return nil
+}
+
+var query_get_dead_letter_data = fmt.Sprintf(
+	`SELECT %s, %s FROM %s WHERE %s = ?`,
+	job_log.Column.Id,
+	job_log.Column.Data,
+	job_log.TableName,
+	job_log.Column.Name,
+)
+
+var query_set_dead_letter_data = fmt.Sprintf(
+	`UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?`,
+	job_log.TableName,
+	job_log.Column.Data,
+	job_log.Column.Name,
+	job_log.Column.Id,
+)
+
+// Re-encrypts the value of the dead letters of `queue`, within `tx`.
+func rotateDeadLetterSecret(tx *db.Tx, queue, oldSecret, newSecret string) (int, error) {
+	name := getDeadLetterJobLogName(queue)
+	rows, err := tx.Query(query_get_dead_letter_data, name)
+	if err != nil {
+		return 0, err
+	}
+	ids := []string{}
+	deadLetters := []DeadLetter{}
+	for rows.Next() {
+		var id string
+		var data db.NullString
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if data.IsZero() || data.Is("null") {
+			continue
+		}
+		dl := DeadLetter{}
+		if err := json.Unmarshal([]byte(data.String), &dl); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to parse dead letter %s:%s: %w", queue, id, err)
+		}
+		ids = append(ids, id)
+		deadLetters = append(deadLetters, dl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range deadLetters {
+		dl := &deadLetters[i]
+		value, err := rotateValue(oldSecret, newSecret, dl.Value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt dead letter %s:%s: %w", queue, ids[i], err)
+		}
+		dl.Value = value
+		blob, err := json.Marshal(dl)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(query_set_dead_letter_data, string(blob), name, ids[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(deadLetters), nil
+}
+
+func GetDeadLetters() ([]job_log.ParsedJobLog[DeadLetter], error) {
+	names, err := job_log.GetUniqueJobNames()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []job_log.ParsedJobLog[DeadLetter]{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, deadLetterJobLogNamePrefix) {
+			continue
+		}
+		logs, err := job_log.GetAllJobLogs[DeadLetter](name)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, logs...)
+	}
+	return items, nil
+}
+
+func GetDeadLetter(queue, key string) (*job_log.ParsedJobLog[DeadLetter], error) {
+	return job_log.GetJobLog[DeadLetter](getDeadLetterJobLogName(queue), key)
+}
+
+// Puts the item back in its queue, to be processed right away with fresh
+// attempts.
+func ReplayDeadLetter(queue, key string) (bool, error) {
+	dl, err := GetDeadLetter(queue, key)
+	if err != nil || dl == nil || dl.Data == nil {
+		return false, err
+	}
+	if err := upsert(queue, key, dl.Data.GroupKey, dl.Data.Value, time.Now()); err != nil {
+		return true, err
+	}
+	return true, job_log.DeleteJobLog(getDeadLetterJobLogName(queue), key)
+}
+
+func DeleteDeadLetter(queue, key string) (bool, error) {
+	dl, err := GetDeadLetter(queue, key)
+	if err != nil || dl == nil {
+		return false, err
+	}
+	return true, job_log.DeleteJobLog(getDeadLetterJobLogName(queue), key)
+}
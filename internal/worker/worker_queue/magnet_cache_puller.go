@@ -15,6 +15,7 @@ type MagnetCachePullerQueueItem struct {
 }
 
 var MagnetCachePullerQueue = WorkerQueue[MagnetCachePullerQueueItem]{
+	name:         "magnet-cache-puller",
 	debounceTime: 5 * time.Minute,
 	getKey: func(item MagnetCachePullerQueueItem) string {
 		return item.StoreCode + ":" + item.SId + ":" + item.Hash
@@ -25,5 +26,7 @@ var MagnetCachePullerQueue = WorkerQueue[MagnetCachePullerQueueItem]{
 	transform: func(item *MagnetCachePullerQueueItem) *MagnetCachePullerQueueItem {
 		return item
 	},
-	Disabled: !config.PeerFlag.Lazy,
+	sensitive: true,
+	buffered:  true,
+	Disabled:  !config.PeerFlag.Lazy,
 }
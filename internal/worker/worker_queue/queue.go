@@ -1,23 +1,49 @@
 package worker_queue
 
 import (
+	"encoding/json"
 	"errors"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/rs/xid"
 )
 
-type WorkerQueueItem[T any] struct {
-	v T
-	t time.Time
-}
+const defaultLeaseTime = 15 * time.Minute
+
+const claimLimit = 1000
 
+// Items are persisted in database, and claimed with a lease, so that they
+// survive restarts and can be processed from any instance. Failed items are
+// retried with backoff, and moved to dead letters once out of attempts.
 type WorkerQueue[T any] struct {
-	m            sync.Map
+	name         string
 	getKey       func(item T) string
 	getGroupKey  func(item T) string
 	transform    func(item *T) *T
 	debounceTime time.Duration
-	Disabled     bool
+	// Defaults to `15m`.
+	leaseTime time.Duration
+	// Defaults to `defaultRetryPolicy`.
+	retryPolicy *RetryPolicy
+	// Items carry credentials, e.g. store token, so the value is encrypted
+	// with vault secret, if available. The key must not contain them.
+	sensitive bool
+	// Items are kept in memory until `Flush`, for queueing from the request
+	// path without a database write per item. Flushed by `InitFlush`.
+	buffered bool
+	pending  map[string]QueueEntry
+	pm       sync.Mutex
+	Disabled bool
+}
+
+// Queues with `sensitive` items, re-encrypted on secret rotation.
+var sensitiveQueues = []string{
+	StoreCrawlerQueue.name,
+	MagnetCachePullerQueue.name,
 }
 
 var ErrWorkerQueueItemDelayed = errors.New("worker queue item delayed")
@@ -27,68 +53,230 @@ func (q *WorkerQueue[T]) Queue(item T) {
 		return
 	}
 	item = *q.transform(&item)
-	q.m.Swap(q.getKey(item), WorkerQueueItem[T]{
-		v: item,
-		t: time.Now().Add(q.debounceTime),
-	})
+	key := q.getKey(item)
+	groupKey := ""
+	if q.getGroupKey != nil {
+		groupKey = q.getGroupKey(item)
+	}
+	value, err := q.encode(item)
+	if err != nil {
+		log.Error("WorkerQueue queue failed", "error", err, "queue", q.name, "key", key)
+		return
+	}
+	runAt := time.Now().Add(q.debounceTime)
+	if q.buffered {
+		q.pm.Lock()
+		if q.pending == nil {
+			q.pending = map[string]QueueEntry{}
+		}
+		q.pending[key] = QueueEntry{Key: key, GroupKey: groupKey, Value: value, RunAt: db.Timestamp{Time: runAt}}
+		q.pm.Unlock()
+		return
+	}
+	if err := upsert(q.name, key, groupKey, value, runAt); err != nil {
+		log.Error("WorkerQueue queue failed", "error", err, "queue", q.name, "key", key)
+	}
+}
+
+// Writes the buffered items to the database.
+func (q *WorkerQueue[T]) Flush() {
+	q.pm.Lock()
+	pending := q.pending
+	q.pending = nil
+	q.pm.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	entries := make([]QueueEntry, 0, len(pending))
+	for _, entry := range pending {
+		entries = append(entries, entry)
+	}
+	for cEntries := range slices.Chunk(entries, upsertBatchSize) {
+		if err := upsertMany(q.name, cEntries); err != nil {
+			log.Error("WorkerQueue flush failed", "error", err, "queue", q.name, "count", len(cEntries))
+		}
+	}
+}
+
+const flushInterval = 10 * time.Second
+
+// Queues with `buffered` items.
+var bufferedQueues = []interface{ Flush() }{
+	&MagnetCachePullerQueue,
+}
+
+func flushBufferedQueues() {
+	for _, q := range bufferedQueues {
+		q.Flush()
+	}
+}
+
+// Flushes the buffered queues periodically, on every instance. The returned
+// function stops it, and flushes one last time.
+func InitFlush() func() {
+	ticker := time.NewTicker(flushInterval)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				flushBufferedQueues()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(stop)
+		flushBufferedQueues()
+	}
+}
+
+func (q *WorkerQueue[T]) encode(item T) (string, error) {
+	blob, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	if q.sensitive && config.Feature.HasVault() {
+		return encrypt(string(blob))
+	}
+	return string(blob), nil
 }
 
-func (q *WorkerQueue[T]) delete(item T) {
-	q.m.Delete(q.getKey(item))
+func (q *WorkerQueue[T]) decode(value string, item *T) error {
+	if isEncrypted(value) {
+		decrypted, err := decrypt(value)
+		if err != nil {
+			return err
+		}
+		value = decrypted
+	}
+	return json.Unmarshal([]byte(value), item)
 }
 
 func (q *WorkerQueue[T]) IsEmpty() bool {
-	isEmpty := true
-	q.m.Range(func(k, v any) bool {
-		isEmpty = false
-		return false
-	})
-	return isEmpty
+	if q.buffered {
+		q.pm.Lock()
+		hasPending := len(q.pending) > 0
+		q.pm.Unlock()
+		if hasPending {
+			return false
+		}
+	}
+	hasItem, err := exists(q.name)
+	if err != nil {
+		log.Error("WorkerQueue check failed", "error", err, "queue", q.name)
+		return true
+	}
+	return !hasItem
+}
+
+type claimedItem[T any] struct {
+	entry QueueEntry
+	v     T
+}
+
+func (q *WorkerQueue[T]) claim() []claimedItem[T] {
+	leaseTime := q.leaseTime
+	if leaseTime == 0 {
+		leaseTime = defaultLeaseTime
+	}
+	owner := config.InstanceId + ":" + xid.New().String()
+	entries, err := claim(q.name, owner, leaseTime, claimLimit)
+	if err != nil {
+		log.Error("WorkerQueue claim failed", "error", err, "queue", q.name)
+		return nil
+	}
+	items := make([]claimedItem[T], 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		item := claimedItem[T]{entry: *entry}
+		if err := q.decode(entry.Value, &item.v); err != nil {
+			log.Error("WorkerQueue item parse failed", "error", err, "queue", q.name, "key", entry.Key)
+			q.complete(entry)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (q *WorkerQueue[T]) complete(entry *QueueEntry) {
+	if err := complete(entry); err != nil {
+		log.Error("WorkerQueue complete failed", "error", err, "queue", q.name, "key", entry.Key)
+	}
+}
+
+func (q *WorkerQueue[T]) release(entry *QueueEntry) {
+	if err := release(entry); err != nil {
+		log.Error("WorkerQueue release failed", "error", err, "queue", q.name, "key", entry.Key)
+	}
+}
+
+func (q *WorkerQueue[T]) fail(entry *QueueEntry, cause error) {
+	retryPolicy := q.retryPolicy
+	if retryPolicy == nil {
+		retryPolicy = &defaultRetryPolicy
+	}
+	attempts := entry.Attempts + 1
+	if delay, ok := retryPolicy.NextDelay(attempts); ok {
+		if err := retry(entry, time.Now().Add(delay)); err != nil {
+			log.Error("WorkerQueue retry failed", "error", err, "queue", q.name, "key", entry.Key)
+		}
+		return
+	}
+	log.Warn("WorkerQueue item out of attempts, moving to dead letters", "queue", q.name, "key", entry.Key, "attempts", attempts)
+	if err := bury(entry, attempts, cause); err != nil {
+		log.Error("WorkerQueue bury failed", "error", err, "queue", q.name, "key", entry.Key)
+	}
 }
 
 func (q *WorkerQueue[T]) Process(f func(item T) error) {
-	q.m.Range(func(k, v any) bool {
-		_, keyOk := k.(string)
-		val, valOk := v.(WorkerQueueItem[T])
-		if keyOk && valOk && val.t.Before(time.Now()) {
-			if err := f(val.v); err != nil {
-				if err == ErrWorkerQueueItemDelayed {
-					log.Debug("WorkerQueue process delayed", "key", q.getKey(val.v))
-				} else {
-					log.Error("WorkerQueue process failed", "error", err, "key", q.getKey(val.v))
-				}
+	for _, item := range q.claim() {
+		if err := f(item.v); err != nil {
+			if err == ErrWorkerQueueItemDelayed {
+				log.Debug("WorkerQueue process delayed", "key", item.entry.Key)
+				q.release(&item.entry)
 			} else {
-				q.delete(val.v)
+				log.Error("WorkerQueue process failed", "error", err, "key", item.entry.Key)
+				q.fail(&item.entry, err)
 			}
+		} else {
+			q.complete(&item.entry)
 		}
-		return true
-	})
+	}
 }
 
 func (q *WorkerQueue[T]) ProcessGroup(f func(groupKey string, items []T) error) {
-	byGroupKey := map[string][]T{}
-	q.m.Range(func(k, v any) bool {
-		_, keyOk := k.(string)
-		val, valOk := v.(WorkerQueueItem[T])
-		if keyOk && valOk && val.t.Before(time.Now()) {
-			groupKey := q.getGroupKey(val.v)
-			if _, ok := byGroupKey[groupKey]; !ok {
-				byGroupKey[groupKey] = []T{}
-			}
-			byGroupKey[groupKey] = append(byGroupKey[groupKey], val.v)
+	byGroupKey := map[string][]claimedItem[T]{}
+	for _, item := range q.claim() {
+		groupKey := item.entry.GroupKey
+		byGroupKey[groupKey] = append(byGroupKey[groupKey], item)
+	}
+	for groupKey, claimed := range byGroupKey {
+		items := make([]T, len(claimed))
+		for i := range claimed {
+			items[i] = claimed[i].v
 		}
-		return true
-	})
-	for groupKey, items := range byGroupKey {
 		if err := f(groupKey, items); err != nil {
 			if err == ErrWorkerQueueItemDelayed {
 				log.Debug("WorkerQueue processGroup delayed", "group_key", groupKey)
+				for i := range claimed {
+					q.release(&claimed[i].entry)
+				}
 			} else {
 				log.Error("WorkerQueue processGroup failed", "error", err, "group_key", groupKey)
+				for i := range claimed {
+					q.fail(&claimed[i].entry, err)
+				}
 			}
 		} else {
-			for i := range items {
-				q.delete(items[i])
+			for i := range claimed {
+				q.complete(&claimed[i].entry)
 			}
 		}
 	}
@@ -0,0 +1,36 @@
+package worker_queue
+
+import "time"
+
+type RetryPolicy struct {
+	// Including the first attempt.
+	MaxAttempts int
+	// Delay before the first retry, doubled for each one after.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     5 * time.Minute,
+	MaxBackoff:  6 * time.Hour,
+}
+
+// Returns the delay before the next attempt, after `attempts` failed ones.
+// `ok` is false once the attempts are exhausted.
+func (p RetryPolicy) NextDelay(attempts int) (delay time.Duration, ok bool) {
+	if attempts < 1 || attempts >= p.MaxAttempts {
+		return 0, false
+	}
+	delay = p.Backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if p.MaxBackoff != 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff, true
+		}
+	}
+	if p.MaxBackoff != 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay, true
+}
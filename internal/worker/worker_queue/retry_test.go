@@ -0,0 +1,33 @@
+package worker_queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     1 * time.Minute,
+		MaxBackoff:  5 * time.Minute,
+	}
+
+	for _, tc := range []struct {
+		attempts int
+		delay    time.Duration
+		ok       bool
+	}{
+		{0, 0, false},
+		{1, 1 * time.Minute, true},
+		{2, 2 * time.Minute, true},
+		{3, 4 * time.Minute, true},
+		{4, 5 * time.Minute, true},
+		{5, 0, false},
+	} {
+		delay, ok := p.NextDelay(tc.attempts)
+		assert.Equal(t, tc.ok, ok, tc.attempts)
+		assert.Equal(t, tc.delay, delay, tc.attempts)
+	}
+}
@@ -1,6 +1,8 @@
 package worker_queue
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/config"
@@ -12,12 +14,15 @@ type StoreCrawlerQueueItem struct {
 }
 
 var StoreCrawlerQueue = WorkerQueue[StoreCrawlerQueueItem]{
+	name:         "store-crawler",
 	debounceTime: 15 * time.Minute,
 	getKey: func(item StoreCrawlerQueueItem) string {
-		return item.StoreCode + ":" + item.StoreToken
+		hash := sha256.Sum256([]byte(item.StoreToken))
+		return item.StoreCode + ":" + hex.EncodeToString(hash[:])
 	},
 	transform: func(item *StoreCrawlerQueueItem) *StoreCrawlerQueueItem {
 		return item
 	},
-	Disabled: !config.Feature.HasTorrentInfo(),
+	sensitive: true,
+	Disabled:  !config.Feature.HasTorrentInfo(),
 }
@@ -1,20 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/MunifTanjim/stremthru/internal/auth_user"
+	"github.com/MunifTanjim/stremthru/internal/cache"
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/content_proxy"
 	"github.com/MunifTanjim/stremthru/internal/db"
 	"github.com/MunifTanjim/stremthru/internal/endpoint"
 	"github.com/MunifTanjim/stremthru/internal/posthog"
 	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/tracing"
 	"github.com/MunifTanjim/stremthru/internal/worker"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
 	"github.com/MunifTanjim/stremthru/store"
 )
 
-func main() {
-	config.PrintConfig(&config.AppState{
+func getAppState() *config.AppState {
+	return &config.AppState{
 		StoreNames: []string{
 			string(store.StoreNameAlldebrid),
 			string(store.StoreNameDebridLink),
@@ -25,18 +36,47 @@ func main() {
 			string(store.StoreNameRealDebrid),
 			string(store.StoreNameTorBox),
 		},
-	})
+	}
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+	serve()
+}
+
+func serve() {
+	config.PrintConfig(getAppState())
 
 	posthog.Init()
-	defer posthog.Close()
+
+	stopTracing := func(ctx context.Context) error { return nil }
+	if config.Tracing.IsEnabled() {
+		shutdownTracing, err := tracing.Init(&tracing.Config{
+			Exporter:       config.Tracing.Exporter,
+			FilePath:       config.Tracing.FilePath,
+			SampleRatio:    config.Tracing.SampleRatio,
+			ServiceVersion: config.Version,
+		})
+		if err != nil {
+			log.Fatalf("failed to initialize tracing: %v", err)
+		}
+		stopTracing = shutdownTracing
+	}
 
 	database := db.Open()
-	defer db.Close()
 	db.Ping()
 	RunSchemaMigration(database.URI, database)
 
+	if err := auth_user.Sync(); err != nil {
+		log.Fatalf("failed to load users: %v", err)
+	}
+
+	stopUserSync := auth_user.InitSync()
 	stopWorkers := worker.InitWorkers()
-	defer stopWorkers()
+	stopQueueFlush := worker_queue.InitFlush()
+	stopConnectionSync := content_proxy.InitConnectionSync()
 
 	mux := http.NewServeMux()
 
@@ -44,7 +84,9 @@ func main() {
 	endpoint.AddDashEndpoint(mux)
 	endpoint.AddAuthEndpoints(mux)
 	endpoint.AddHealthEndpoints(mux)
+	endpoint.AddOpenAPIEndpoints(mux)
 	endpoint.AddMetaEndpoints(mux)
+	endpoint.AddMetricsEndpoints(mux)
 	endpoint.AddProxyEndpoints(mux)
 	endpoint.AddStoreEndpoints(mux)
 	endpoint.AddStremioEndpoints(mux)
@@ -58,14 +100,98 @@ func main() {
 	if config.Environment == config.EnvDev {
 		addr = "localhost" + addr
 	}
-	server := &http.Server{Addr: addr, Handler: handler}
+	server := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// keep-alives are disabled for public instance, checked per request
+		// as it can change on reload
+		if config.IsPublicInstance() {
+			w.Header().Set("Connection", "close")
+		}
+		handler.ServeHTTP(w, r)
+	})}
 
-	if len(config.ProxyAuthPassword) == 0 {
-		server.SetKeepAlivesEnabled(false)
-	}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Println("stremthru listening on " + addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	signalCtx, stopSignal := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignal()
+
+	reloadSignal := make(chan os.Signal, 1)
+	signal.Notify(reloadSignal, syscall.SIGHUP)
+	defer signal.Stop(reloadSignal)
+	go func() {
+		for range reloadSignal {
+			warnings, err := config.Reload()
+			for _, warning := range warnings {
+				log.Printf("WARNING: %s", warning)
+			}
+			if err != nil {
+				log.Printf("failed to reload config: %v", err)
+			} else if err := auth_user.Sync(); err != nil {
+				log.Printf("failed to reload users: %v", err)
+			} else {
+				log.Println("config reloaded")
+			}
+		}
+	}()
 
-	log.Println("stremthru listening on " + addr)
-	if err := server.ListenAndServe(); err != nil {
+	select {
+	case err := <-serverErr:
 		log.Fatalf("failed to start stremthru: %v", err)
+	case <-signalCtx.Done():
+	}
+	stopSignal()
+
+	log.Printf("shutting down, waiting up to %s...", config.ShutdownTimeout)
+	stopConnectionSync()
+	stopUserSync()
+	shutdown(server, stopWorkers, stopQueueFlush, stopTracing)
+	log.Println("stremthru stopped")
+}
+
+func shutdown(server *http.Server, stopWorkers func(ctx context.Context), stopQueueFlush func(), stopTracing func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stopWorkers(ctx)
+	}()
+
+	if err := server.Shutdown(ctx); err != nil {
+		count := content_proxy.TerminateConnections()
+		log.Printf("shutdown timed out, terminated %d active stream(s): %v", count, err)
+
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer closeCancel()
+		if err := server.Shutdown(closeCtx); err != nil {
+			server.Close()
+		}
+	}
+
+	<-done
+
+	// after the server, requests can still queue items until then
+	stopQueueFlush()
+
+	// shutdown timeout may already be used up
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := stopTracing(tracingCtx); err != nil {
+		log.Printf("failed to flush traces: %v", err)
+	}
+	if err := cache.Persist(); err != nil {
+		log.Printf("failed to persist cache: %v", err)
+	}
+	if err := posthog.Close(); err != nil {
+		log.Printf("failed to flush posthog: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("failed to close database: %v", err)
 	}
 }
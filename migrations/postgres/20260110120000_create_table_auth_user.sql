@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."auth_user" (
+  "name" text NOT NULL,
+  "password" text NOT NULL,
+  "disabled" boolean NOT NULL DEFAULT false,
+  "store_auth" text NOT NULL DEFAULT '',
+  "connection_limit" int,
+  "features" text NOT NULL DEFAULT '',
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "uat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "public"."auth_user_api_key" (
+  "id" text NOT NULL,
+  "user_name" text NOT NULL,
+  "name" text NOT NULL DEFAULT '',
+  "key_hash" text NOT NULL,
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("id"),
+  UNIQUE ("key_hash")
+);
+
+CREATE INDEX IF NOT EXISTS "auth_user_api_key_idx_user_name" ON "public"."auth_user_api_key" ("user_name");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."auth_user_api_key";
+DROP TABLE IF EXISTS "public"."auth_user";
+-- +goose StatementEnd
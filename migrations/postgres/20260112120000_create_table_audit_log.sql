@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."audit_log" (
+  "id" text NOT NULL,
+  "actor" text NOT NULL,
+  "action" text NOT NULL,
+  "target" text NOT NULL,
+  "status" int NOT NULL,
+  "ip" text NOT NULL DEFAULT '',
+  "changes" jsonb NOT NULL DEFAULT '[]',
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "audit_log_idx_cat" ON "public"."audit_log" ("cat");
+CREATE INDEX IF NOT EXISTS "audit_log_idx_actor" ON "public"."audit_log" ("actor");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."audit_log";
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."worker_queue" (
+  "queue" text NOT NULL,
+  "key" text NOT NULL,
+  "group_key" text NOT NULL DEFAULT '',
+  "value" jsonb NOT NULL,
+  "seq" int NOT NULL DEFAULT 0,
+  "run_at" timestamptz NOT NULL,
+  "lease_owner" text NOT NULL DEFAULT '',
+  "lease_until" timestamptz,
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "uat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("queue", "key")
+);
+
+CREATE INDEX IF NOT EXISTS "worker_queue_idx_queue_run_at" ON "public"."worker_queue" ("queue", "run_at");
+CREATE INDEX IF NOT EXISTS "worker_queue_idx_queue_lease_owner" ON "public"."worker_queue" ("queue", "lease_owner");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."worker_queue";
+-- +goose StatementEnd
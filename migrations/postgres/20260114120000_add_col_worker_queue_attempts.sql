@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "public"."worker_queue" ADD COLUMN "attempts" int NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "public"."worker_queue" DROP COLUMN "attempts";
+-- +goose StatementEnd
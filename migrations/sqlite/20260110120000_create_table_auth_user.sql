@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `auth_user` (
+  `name` varchar NOT NULL,
+  `password` varchar NOT NULL,
+  `disabled` bool NOT NULL DEFAULT false,
+  `store_auth` varchar NOT NULL DEFAULT '',
+  `connection_limit` int,
+  `features` varchar NOT NULL DEFAULT '',
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+  `uat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `auth_user_api_key` (
+  `id` varchar NOT NULL,
+  `user_name` varchar NOT NULL,
+  `name` varchar NOT NULL DEFAULT '',
+  `key_hash` varchar NOT NULL,
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`id`),
+  UNIQUE (`key_hash`)
+);
+
+CREATE INDEX IF NOT EXISTS `auth_user_api_key_idx_user_name` ON `auth_user_api_key` (`user_name`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `auth_user_api_key`;
+DROP TABLE IF EXISTS `auth_user`;
+-- +goose StatementEnd
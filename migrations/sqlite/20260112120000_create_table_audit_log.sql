@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` varchar NOT NULL,
+  `actor` varchar NOT NULL,
+  `action` varchar NOT NULL,
+  `target` varchar NOT NULL,
+  `status` int NOT NULL,
+  `ip` varchar NOT NULL DEFAULT '',
+  `changes` json NOT NULL DEFAULT '[]',
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `audit_log_idx_cat` ON `audit_log` (`cat`);
+CREATE INDEX IF NOT EXISTS `audit_log_idx_actor` ON `audit_log` (`actor`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `audit_log`;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `worker_queue` (
+  `queue` varchar NOT NULL,
+  `key` varchar NOT NULL,
+  `group_key` varchar NOT NULL DEFAULT '',
+  `value` json NOT NULL,
+  `seq` int NOT NULL DEFAULT 0,
+  `run_at` datetime NOT NULL,
+  `lease_owner` varchar NOT NULL DEFAULT '',
+  `lease_until` datetime,
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+  `uat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`queue`, `key`)
+);
+
+CREATE INDEX IF NOT EXISTS `worker_queue_idx_queue_run_at` ON `worker_queue` (`queue`, `run_at`);
+CREATE INDEX IF NOT EXISTS `worker_queue_idx_queue_lease_owner` ON `worker_queue` (`queue`, `lease_owner`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `worker_queue`;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql"
 	"embed"
+	"fmt"
 	"log"
 	"os"
 
@@ -12,11 +14,11 @@ import (
 //go:embed migrations/**/*.sql
 var migrationsFS embed.FS
 
-func RunSchemaMigration(uri db.ConnectionURI, database *db.DB) {
-	l := log.New(os.Stderr, "=", 0)
+const schemaMigrationTableName = "db_migration_version"
 
+func setupSchemaMigration(uri db.ConnectionURI) string {
 	goose.SetBaseFS(migrationsFS)
-	goose.SetTableName("db_migration_version")
+	goose.SetTableName(schemaMigrationTableName)
 	goose.SetLogger(log.New(os.Stderr, "=   ", 0))
 
 	dir := ""
@@ -28,6 +30,13 @@ func RunSchemaMigration(uri db.ConnectionURI, database *db.DB) {
 		goose.SetDialect("postgres")
 		dir = "migrations/postgres"
 	}
+	return dir
+}
+
+func RunSchemaMigration(uri db.ConnectionURI, database *db.DB) {
+	l := log.New(os.Stderr, "=", 0)
+
+	dir := setupSchemaMigration(uri)
 
 	lock := db.NewAdvisoryLock("goose", "migration")
 
@@ -52,6 +61,58 @@ func RunSchemaMigration(uri db.ConnectionURI, database *db.DB) {
 		l.Fatalf(" Failed to run migrations: %v\n", err)
 	}
 
+	if migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion); err != nil {
+		l.Fatalf(" Failed to collect migrations: %v\n", err)
+	} else if last, err := migrations.Last(); err == nil {
+		db.SetExpectedSchemaVersion(schemaMigrationTableName, last.Version)
+	}
+
 	l.Println()
 	l.Print("========================\n\n")
 }
+
+func PrintSchemaMigrationStatus(uri db.ConnectionURI, database *db.DB) {
+	l := log.New(os.Stderr, "=", 0)
+
+	dir := setupSchemaMigration(uri)
+
+	l.Println("=== Database Schema ====")
+
+	l.Println()
+	l.Println(" STATE:")
+	l.Println()
+	if err := goose.Status(database.DB, dir); err != nil {
+		l.Fatalf(" Failed to check state: %v\n", err)
+	}
+
+	l.Println()
+	l.Print("========================\n\n")
+}
+
+// Applies pending migrations to the database at `uri`, which may not be the
+// configured one.
+func migrateSchemaOf(uri db.ConnectionURI, database *sql.DB) error {
+	dir := setupSchemaMigration(uri)
+	return goose.Up(database, dir)
+}
+
+// Fails if the database at `uri` has pending migrations.
+func checkSchemaOf(uri db.ConnectionURI, database *sql.DB) error {
+	dir := setupSchemaMigration(uri)
+	current, err := goose.GetDBVersion(database)
+	if err != nil {
+		return err
+	}
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return err
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return err
+	}
+	if current != last.Version {
+		return fmt.Errorf("schema version is %d, expected %d, run migrations first", current, last.Version)
+	}
+	return nil
+}
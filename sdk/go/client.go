@@ -0,0 +1,326 @@
+// Package stremthru is the Go SDK for the StremThru API.
+package stremthru
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+const userAgent = "stremthru:sdk:go"
+
+var DefaultHTTPClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+type RetryConfig struct {
+	// Maximum number of retries, `0` disables retry.
+	MaxRetries int
+	MinWait    time.Duration
+	MaxWait    time.Duration
+}
+
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries: 2,
+	MinWait:    500 * time.Millisecond,
+	MaxWait:    5 * time.Second,
+}
+
+type ClientConfig struct {
+	BaseURL string
+	// `user:pass` or its base64 encoded form for `Basic` auth, or API key for
+	// `Bearer` auth.
+	Auth       string
+	StoreName  store.StoreName
+	StoreToken string
+	PeerToken  string
+	ClientIP   string
+	UserAgent  string
+	// Extra headers sent with every request.
+	Header     http.Header
+	HTTPClient *http.Client
+	// Defaults to `DefaultRetryConfig`.
+	Retry *RetryConfig
+}
+
+type Client struct {
+	BaseURL    *url.URL
+	HTTPClient *http.Client
+
+	auth       string
+	storeName  store.StoreName
+	storeToken string
+	peerToken  string
+	clientIP   string
+	userAgent  string
+	header     http.Header
+	retry      RetryConfig
+
+	reqQuery  func(query *url.Values, params request.Context)
+	reqHeader func(header *http.Header, params request.Context)
+
+	Store   *StoreAPI
+	Proxy   *ProxyAPI
+	Torrent *TorrentAPI
+	Torznab *TorznabAPI
+	Meta    *MetaAPI
+}
+
+func toAuthorization(auth string) string {
+	auth = strings.TrimSpace(auth)
+	if auth == "" {
+		return ""
+	}
+	if strings.HasPrefix(auth, "Basic ") || strings.HasPrefix(auth, "Bearer ") {
+		return auth
+	}
+	if strings.Contains(auth, ":") {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(auth); err == nil && strings.Contains(string(decoded), ":") {
+		return "Basic " + auth
+	}
+	return "Bearer " + auth
+}
+
+func NewClient(conf *ClientConfig) *Client {
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = DefaultHTTPClient
+	}
+
+	if conf.Retry == nil {
+		conf.Retry = &DefaultRetryConfig
+	}
+
+	c := &Client{}
+
+	if conf.BaseURL != "" {
+		baseUrl, err := url.Parse(conf.BaseURL)
+		if err != nil {
+			panic(err)
+		}
+		c.BaseURL = baseUrl
+	}
+
+	c.HTTPClient = conf.HTTPClient
+	c.auth = toAuthorization(conf.Auth)
+	c.storeName = conf.StoreName
+	c.storeToken = conf.StoreToken
+	c.peerToken = conf.PeerToken
+	c.clientIP = conf.ClientIP
+	c.userAgent = strings.TrimSpace(userAgent + " " + conf.UserAgent)
+	c.header = conf.Header
+	c.retry = *conf.Retry
+
+	c.reqQuery = func(query *url.Values, params request.Context) {
+	}
+
+	c.reqHeader = func(header *http.Header, params request.Context) {
+		for key, values := range c.header {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+		header.Set("User-Agent", c.userAgent)
+		if c.auth != "" {
+			header.Set("X-StremThru-Authorization", c.auth)
+		}
+		if c.peerToken != "" {
+			header.Set("X-StremThru-Peer-Token", c.peerToken)
+		}
+	}
+
+	c.Store = &StoreAPI{client: c}
+	c.Proxy = &ProxyAPI{client: c}
+	c.Torrent = &TorrentAPI{client: c}
+	c.Torznab = &TorznabAPI{client: c}
+	c.Meta = &MetaAPI{client: c}
+
+	return c
+}
+
+// Per request options, embedded in the params of every request.
+type Ctx struct {
+	Context context.Context `json:"-"`
+	Form    *url.Values     `json:"-"`
+	JSON    any             `json:"-"`
+	Headers *http.Header    `json:"-"`
+	Query   *url.Values     `json:"-"`
+	Body    io.Reader       `json:"-"`
+}
+
+func (ctx *Ctx) getCtx() *Ctx {
+	return ctx
+}
+
+// Implemented by the params of every request, by embedding `Ctx` or
+// `StoreCtx`.
+type RequestContext interface {
+	getCtx() *Ctx
+}
+
+func (ctx *Ctx) toRequestCtx() *request.Ctx {
+	return &request.Ctx{
+		Context: ctx.Context,
+		Form:    ctx.Form,
+		JSON:    ctx.JSON,
+		Headers: ctx.Headers,
+		Query:   ctx.Query,
+		Body:    ctx.Body,
+	}
+}
+
+// Per request store credentials, overrides the ones from `ClientConfig`.
+type StoreCtx struct {
+	Ctx
+	StoreName  store.StoreName `json:"-"`
+	StoreToken string          `json:"-"`
+	ClientIP   string          `json:"-"`
+}
+
+type storeContext interface {
+	getStoreCtx() *StoreCtx
+}
+
+func (ctx *StoreCtx) getStoreCtx() *StoreCtx {
+	return ctx
+}
+
+func (c *Client) prepareStoreCtx(params RequestContext) (
+	reqHeader func(header *http.Header, params request.Context),
+	reqQuery func(query *url.Values, params request.Context),
+) {
+	p, ok := params.(storeContext)
+	if !ok {
+		return c.reqHeader, c.reqQuery
+	}
+
+	sctx := p.getStoreCtx()
+	storeName, storeToken, clientIP := c.storeName, c.storeToken, c.clientIP
+	if sctx.StoreName != "" {
+		storeName = sctx.StoreName
+	}
+	if sctx.StoreToken != "" {
+		storeToken = sctx.StoreToken
+	}
+	if sctx.ClientIP != "" {
+		clientIP = sctx.ClientIP
+	}
+
+	reqHeader = func(header *http.Header, params request.Context) {
+		c.reqHeader(header, params)
+		if storeName != "" {
+			header.Set("X-StremThru-Store-Name", string(storeName))
+		}
+		if storeToken != "" {
+			header.Set("X-StremThru-Store-Authorization", "Bearer "+storeToken)
+		}
+	}
+	reqQuery = func(query *url.Values, params request.Context) {
+		c.reqQuery(query, params)
+		if clientIP != "" {
+			query.Set("client_ip", clientIP)
+		}
+	}
+	return reqHeader, reqQuery
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func shouldRetry(method string, res *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		var netErr net.Error
+		return isIdempotent(method) && (errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF))
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+func (c *Client) retryWait(attempt int, res *http.Response) time.Duration {
+	wait := c.retry.MinWait << attempt
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+	if wait <= 0 {
+		wait = c.retry.MinWait
+	}
+	if jitter := wait / 10; jitter > 0 {
+		wait += rand.N(jitter)
+	}
+	if c.retry.MaxWait > 0 && wait > c.retry.MaxWait {
+		wait = c.retry.MaxWait
+	}
+	return wait
+}
+
+func (c *Client) do(method, path string, params RequestContext) (*http.Request, *http.Response, error) {
+	reqHeader, reqQuery := c.prepareStoreCtx(params)
+	rctx := params.getCtx().toRequestCtx()
+	for attempt := 0; ; attempt++ {
+		req, err := rctx.NewRequest(c.BaseURL, method, path, reqHeader, reqQuery)
+		if err != nil {
+			return nil, nil, err
+		}
+		res, err := rctx.DoRequest(c.HTTPClient, req)
+		if attempt >= c.retry.MaxRetries || !shouldRetry(method, res, err) {
+			return req, res, err
+		}
+		wait := c.retryWait(attempt, res)
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-rctx.GetContext().Done():
+			timer.Stop()
+			return req, nil, rctx.GetContext().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Sends the request, retrying transient failures, and decodes the response
+// body into `v`.
+func (c *Client) Request(method, path string, params RequestContext, v ResponseEnvelop) (*http.Response, error) {
+	if params == nil {
+		params = &Ctx{}
+	}
+	req, res, err := c.do(method, path, params)
+	err = processResponseBody(res, err, v)
+	if err != nil {
+		if rerr, ok := err.(*ResponseError); ok && req != nil {
+			rerr.Method = req.Method
+			rerr.Path = req.URL.Path
+		}
+		return res, err
+	}
+	return res, nil
+}
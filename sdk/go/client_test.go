@@ -0,0 +1,120 @@
+package stremthru
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToAuthorization(t *testing.T) {
+	for _, tc := range []struct {
+		auth     string
+		expected string
+	}{
+		{"", ""},
+		{"user:pass", "Basic dXNlcjpwYXNz"},
+		{"dXNlcjpwYXNz", "Basic dXNlcjpwYXNz"},
+		{"st_key", "Bearer st_key"},
+		{"Bearer st_key", "Bearer st_key"},
+	} {
+		t.Run(tc.auth, func(t *testing.T) {
+			assert.Equal(t, tc.expected, toAuthorization(tc.auth))
+		})
+	}
+}
+
+func newTestClient(handler http.HandlerFunc) (*Client, func()) {
+	server := httptest.NewServer(handler)
+	client := NewClient(&ClientConfig{
+		BaseURL:   server.URL,
+		Auth:      "user:pass",
+		StoreName: store.StoreNameRealDebrid,
+		Retry: &RetryConfig{
+			MaxRetries: 2,
+			MinWait:    time.Millisecond,
+			MaxWait:    5 * time.Millisecond,
+		},
+	})
+	return client, server.Close
+}
+
+func TestClientRequest(t *testing.T) {
+	t.Run("headers", func(t *testing.T) {
+		client, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Basic dXNlcjpwYXNz", r.Header.Get("X-StremThru-Authorization"))
+			assert.Equal(t, "torbox", r.Header.Get("X-StremThru-Store-Name"))
+			assert.Equal(t, "Bearer token", r.Header.Get("X-StremThru-Store-Authorization"))
+			assert.Equal(t, "1.2.3.4", r.URL.Query().Get("client_ip"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"id":"id","email":"user@example.com","subscription_status":"premium"}}`))
+		})
+		defer done()
+
+		params := &GetUserParams{}
+		params.StoreName = store.StoreNameTorBox
+		params.StoreToken = "token"
+		params.ClientIP = "1.2.3.4"
+		res, err := client.Store.GetUser(params)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, store.User{Id: "id", Email: "user@example.com", SubscriptionStatus: store.UserSubscriptionStatusPremium}, res.Data)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var count atomic.Int32
+		client, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"data":{"status":"ok"}}`))
+		})
+		defer done()
+
+		res, err := client.Health(&HealthParams{})
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", res.Data.Status)
+		assert.Equal(t, int32(3), count.Load())
+	})
+
+	t.Run("no retry for non-idempotent", func(t *testing.T) {
+		var count atomic.Int32
+		client, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"type":"api_error","code":"SERVICE_UNAVAILABLE","message":"Service Unavailable"}}`))
+		})
+		defer done()
+
+		_, err := client.Store.GenerateLink(&GenerateLinkParams{Link: "https://example.com"})
+		assert.Equal(t, &ResponseError{
+			Type:       core.ErrorTypeAPI,
+			Code:       core.ErrorCodeServiceUnavailable,
+			Message:    "Service Unavailable",
+			StatusCode: 503,
+			Method:     "POST",
+			Path:       "/v0/store/link/generate",
+		}, err)
+		assert.Equal(t, int32(1), count.Load())
+	})
+
+	t.Run("non-json error", func(t *testing.T) {
+		client, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		})
+		defer done()
+
+		_, err := client.Health(&HealthParams{})
+		rerr, ok := err.(*ResponseError)
+		assert.True(t, ok)
+		assert.Equal(t, 502, rerr.StatusCode)
+		assert.Equal(t, core.ErrorCodeUnknown, rerr.Code)
+	})
+}
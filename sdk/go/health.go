@@ -0,0 +1,15 @@
+package stremthru
+
+type HealthParams struct {
+	Ctx
+}
+
+type HealthData struct {
+	Status string `json:"status"`
+}
+
+func (c *Client) Health(params *HealthParams) (APIResponse[HealthData], error) {
+	response := &Response[HealthData]{}
+	res, err := c.Request("GET", "/v0/health", params, response)
+	return newAPIResponse(res, response.Data), err
+}
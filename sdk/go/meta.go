@@ -0,0 +1,56 @@
+package stremthru
+
+import (
+	"net/url"
+
+	meta_type "github.com/MunifTanjim/stremthru/internal/meta/type"
+)
+
+type MetaAPI struct {
+	client *Client
+}
+
+type MetaIdType = meta_type.IdType
+
+const (
+	MetaIdTypeMovie = meta_type.IdTypeMovie
+	MetaIdTypeShow  = meta_type.IdTypeShow
+)
+
+type MetaIdMap = meta_type.IdMap
+
+type GetIdMapParams struct {
+	Ctx
+	IdType MetaIdType
+	Id     string // IMDB id, e.g. `tt0110912`
+}
+
+func (m MetaAPI) GetIdMap(params *GetIdMapParams) (APIResponse[MetaIdMap], error) {
+	response := &Response[MetaIdMap]{}
+	res, err := m.client.Request("GET", "/v0/meta/id-map/"+string(params.IdType)+"/"+url.PathEscape(params.Id), params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type MetaList = meta_type.List
+
+type GetLetterboxdListParams struct {
+	Ctx
+	ListId string
+}
+
+func (m MetaAPI) GetLetterboxdList(params *GetLetterboxdListParams) (APIResponse[MetaList], error) {
+	response := &Response[MetaList]{}
+	res, err := m.client.Request("GET", "/v0/meta/letterboxd/lists/"+url.PathEscape(params.ListId), params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type GetLetterboxdUserWatchlistParams struct {
+	Ctx
+	UserId string
+}
+
+func (m MetaAPI) GetLetterboxdUserWatchlist(params *GetLetterboxdUserWatchlistParams) (APIResponse[MetaList], error) {
+	response := &Response[MetaList]{}
+	res, err := m.client.Request("GET", "/v0/meta/letterboxd/users/"+url.PathEscape(params.UserId)+"/lists/watchlist", params, response)
+	return newAPIResponse(res, response.Data), err
+}
@@ -0,0 +1,68 @@
+package stremthru
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ProxyAPI struct {
+	client *Client
+}
+
+type ProxifyLink struct {
+	URL string
+	// Headers to add to the request.
+	ReqHeaders map[string]string
+	Filename   string
+}
+
+type ProxifyLinksParams struct {
+	Ctx
+	Links     []ProxifyLink
+	ExpiresIn time.Duration
+	// IP address or CIDR subnet the proxified link is restricted to, `auto`
+	// for the requester's IP.
+	BindIP string
+	// Skips encryption of the proxified link, uses the token for
+	// authorization instead.
+	Token string
+}
+
+type ProxifyLinksData struct {
+	Items      []string `json:"items"`
+	TotalItems int      `json:"total_items"`
+}
+
+func (p ProxyAPI) ProxifyLinks(params *ProxifyLinksParams) (APIResponse[ProxifyLinksData], error) {
+	form := &url.Values{}
+	for i, link := range params.Links {
+		idx := strconv.Itoa(i)
+		form.Add("url", link.URL)
+		if len(link.ReqHeaders) > 0 {
+			headers := make([]string, 0, len(link.ReqHeaders))
+			for k, v := range link.ReqHeaders {
+				headers = append(headers, k+": "+v)
+			}
+			form.Set("req_headers["+idx+"]", strings.Join(headers, "\n"))
+		}
+		if link.Filename != "" {
+			form.Set("filename["+idx+"]", link.Filename)
+		}
+	}
+	if params.ExpiresIn > 0 {
+		form.Set("exp", strconv.Itoa(int(params.ExpiresIn.Seconds())))
+	}
+	if params.BindIP != "" {
+		form.Set("bind_ip", params.BindIP)
+	}
+	params.Form = form
+	if params.Token != "" {
+		params.Query = &url.Values{"token": []string{params.Token}}
+	}
+
+	response := &Response[ProxifyLinksData]{}
+	res, err := p.client.Request("POST", "/v0/proxy", params, response)
+	return newAPIResponse(res, response.Data), err
+}
@@ -0,0 +1,99 @@
+package stremthru
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/core"
+)
+
+type ResponseError struct {
+	Type       core.ErrorType     `json:"type"`
+	Code       core.ErrorCode     `json:"code"`
+	Message    string             `json:"message"`
+	StatusCode int                `json:"status_code"`
+	Errors     []core.ErrorDetail `json:"errors,omitempty"`
+
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+func (e *ResponseError) Error() string {
+	ret, _ := json.Marshal(e)
+	return string(ret)
+}
+
+type ResponseEnvelop interface {
+	GetError() error
+}
+
+type Response[T any] struct {
+	Data  T              `json:"data,omitempty"`
+	Error *ResponseError `json:"error,omitempty"`
+}
+
+func (r Response[any]) GetError() error {
+	if r.Error == nil {
+		return nil
+	}
+	return r.Error
+}
+
+type APIResponse[T any] struct {
+	Header     http.Header
+	StatusCode int
+	Data       T
+}
+
+func newAPIResponse[T any](res *http.Response, data T) APIResponse[T] {
+	apiResponse := APIResponse[T]{
+		StatusCode: http.StatusServiceUnavailable,
+		Data:       data,
+	}
+	if res != nil {
+		apiResponse.Header = res.Header
+		apiResponse.StatusCode = res.StatusCode
+	}
+	return apiResponse
+}
+
+func processResponseBody(res *http.Response, err error, v ResponseEnvelop) error {
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return decodeResponseBody(res.StatusCode, body, v)
+}
+
+func decodeResponseBody(statusCode int, body []byte, v ResponseEnvelop) error {
+	err := core.UnmarshalJSON(statusCode, body, v)
+	if err != nil {
+		if statusCode < http.StatusBadRequest {
+			return err
+		}
+		// non-json error, e.g. from reverse proxy
+		return &ResponseError{
+			Type:       core.ErrorTypeUnknown,
+			Code:       core.ErrorCodeUnknown,
+			Message:    strings.TrimSpace(http.StatusText(statusCode) + " " + err.Error()),
+			StatusCode: statusCode,
+		}
+	}
+
+	if err := v.GetError(); err != nil {
+		if rerr, ok := err.(*ResponseError); ok && rerr.StatusCode == 0 {
+			rerr.StatusCode = statusCode
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package stremthru
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+type StoreAPI struct {
+	client *Client
+}
+
+type GetUserParams struct {
+	StoreCtx
+}
+
+func (s StoreAPI) GetUser(params *GetUserParams) (APIResponse[store.User], error) {
+	response := &Response[store.User]{}
+	res, err := s.client.Request("GET", "/v0/store/user", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type ListMagnetsParams struct {
+	StoreCtx
+	Limit  int // min `1`, max `500`, default `100`
+	Offset int // min `0`, default `0`
+}
+
+func (s StoreAPI) ListMagnets(params *ListMagnetsParams) (APIResponse[store.ListMagnetsData], error) {
+	params.Query = &url.Values{}
+	if params.Limit > 0 {
+		params.Query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		params.Query.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	response := &Response[store.ListMagnetsData]{}
+	res, err := s.client.Request("GET", "/v0/store/magnets", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type AddMagnetParams struct {
+	StoreCtx
+	Magnet  string `json:"magnet,omitempty"`
+	Torrent string `json:"torrent,omitempty"` // link to `.torrent` file
+}
+
+func (s StoreAPI) AddMagnet(params *AddMagnetParams) (APIResponse[store.AddMagnetData], error) {
+	params.JSON = params
+
+	response := &Response[store.AddMagnetData]{}
+	res, err := s.client.Request("POST", "/v0/store/magnets", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type CheckMagnetParams struct {
+	StoreCtx
+	Magnets   []string
+	SId       string
+	LocalOnly bool
+}
+
+func (s StoreAPI) CheckMagnet(params *CheckMagnetParams) (APIResponse[store.CheckMagnetData], error) {
+	params.Query = &url.Values{"magnet": params.Magnets}
+	if params.SId != "" {
+		params.Query.Set("sid", params.SId)
+	}
+	if params.LocalOnly {
+		params.Query.Set("local_only", "1")
+	}
+
+	response := &Response[store.CheckMagnetData]{}
+	res, err := s.client.Request("GET", "/v0/store/magnets/check", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type GetMagnetParams struct {
+	StoreCtx
+	Id string
+}
+
+func (s StoreAPI) GetMagnet(params *GetMagnetParams) (APIResponse[store.GetMagnetData], error) {
+	response := &Response[store.GetMagnetData]{}
+	res, err := s.client.Request("GET", "/v0/store/magnets/"+url.PathEscape(params.Id), params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type RemoveMagnetParams struct {
+	StoreCtx
+	Id string
+}
+
+func (s StoreAPI) RemoveMagnet(params *RemoveMagnetParams) (APIResponse[store.RemoveMagnetData], error) {
+	response := &Response[store.RemoveMagnetData]{}
+	res, err := s.client.Request("DELETE", "/v0/store/magnets/"+url.PathEscape(params.Id), params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type GenerateLinkParams struct {
+	StoreCtx
+	Link string `json:"link"`
+}
+
+func (s StoreAPI) GenerateLink(params *GenerateLinkParams) (APIResponse[store.GenerateLinkData], error) {
+	params.JSON = params
+
+	response := &Response[store.GenerateLinkData]{}
+	res, err := s.client.Request("POST", "/v0/store/link/generate", params, response)
+	return newAPIResponse(res, response.Data), err
+}
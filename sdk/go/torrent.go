@@ -0,0 +1,96 @@
+package stremthru
+
+import (
+	"net/url"
+)
+
+type TorrentAPI struct {
+	client *Client
+}
+
+type TorrentFile struct {
+	Path      string `json:"p"`
+	Idx       int    `json:"i"`
+	Size      int64  `json:"s"`
+	Name      string `json:"n"`
+	SId       string `json:"sid,omitempty"`
+	ASId      string `json:"asid,omitempty"`
+	Source    string `json:"src,omitempty"`
+	VideoHash string `json:"vhash,omitempty"`
+}
+
+type TorrentItem struct {
+	Hash         string        `json:"hash"`
+	TorrentTitle string        `json:"name"`
+	Size         int64         `json:"size"`
+	Indexer      string        `json:"indexer"`
+	Source       string        `json:"src"`
+	Category     string        `json:"category"`
+	Seeders      int           `json:"seeders"`
+	Leechers     int           `json:"leechers"`
+	Private      bool          `json:"private"`
+	Files        []TorrentFile `json:"files"`
+}
+
+type ListTorrentsParams struct {
+	Ctx
+	SId           string
+	LocalOnly     bool
+	NoMissingSize bool
+}
+
+type ListTorrentsData struct {
+	Items      []TorrentItem `json:"items"`
+	TotalItems int           `json:"total_items"`
+}
+
+func (t TorrentAPI) ListTorrents(params *ListTorrentsParams) (APIResponse[ListTorrentsData], error) {
+	params.Query = &url.Values{"sid": []string{params.SId}}
+	if params.LocalOnly {
+		params.Query.Set("local_only", "1")
+	}
+	if params.NoMissingSize {
+		params.Query.Set("no_missing_size", "1")
+	}
+
+	response := &Response[ListTorrentsData]{}
+	res, err := t.client.Request("GET", "/v0/torrents", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+// Requires `PeerToken`.
+type RecordTorrentsParams struct {
+	Ctx
+	Items []TorrentItem `json:"items"`
+}
+
+type RecordTorrentsData struct{}
+
+func (t TorrentAPI) RecordTorrents(params *RecordTorrentsParams) (APIResponse[RecordTorrentsData], error) {
+	params.JSON = params
+
+	response := &Response[RecordTorrentsData]{}
+	res, err := t.client.Request("POST", "/v0/torrents", params, response)
+	return newAPIResponse(res, response.Data), err
+}
+
+type GetTorrentStatsParams struct {
+	Ctx
+}
+
+type TorrentStreamStats struct {
+	TotalCount    int            `json:"total_count"`
+	CountBySource map[string]int `json:"count_by_source"`
+}
+
+type TorrentStats struct {
+	TotalCount    int                 `json:"total_count"`
+	CountBySource map[string]int      `json:"count_by_source"`
+	Streams       *TorrentStreamStats `json:"streams,omitempty"`
+}
+
+func (t TorrentAPI) GetStats(params *GetTorrentStatsParams) (APIResponse[TorrentStats], error) {
+	response := &Response[TorrentStats]{}
+	res, err := t.client.Request("GET", "/v0/torrents/stats", params, response)
+	return newAPIResponse(res, response.Data), err
+}
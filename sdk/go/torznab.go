@@ -0,0 +1,198 @@
+package stremthru
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/core"
+)
+
+type TorznabAPI struct {
+	client *Client
+}
+
+// Torznab responds with `200` for errors too.
+type TorznabError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+func (e *TorznabError) Error() string {
+	return "torznab error (" + strconv.Itoa(e.Code) + "): " + e.Description
+}
+
+func (t TorznabAPI) request(params *Ctx, v any) (*http.Response, error) {
+	_, res, err := t.client.do("GET", "/v0/torznab/api", params)
+	if err != nil {
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	defer res.Body.Close()
+	if err != nil {
+		return res, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		if err := decodeResponseBody(res.StatusCode, body, &Response[any]{}); err != nil {
+			return res, err
+		}
+		return res, &ResponseError{Type: core.ErrorTypeUnknown, Code: core.ErrorCodeUnknown, StatusCode: res.StatusCode}
+	}
+
+	if bytes.Contains(body[:min(len(body), 512)], []byte("<error")) {
+		terr := &TorznabError{}
+		if err := xml.Unmarshal(body, terr); err == nil {
+			return res, terr
+		}
+	}
+
+	return res, xml.Unmarshal(body, v)
+}
+
+type TorznabCapsCategory struct {
+	Id     int    `xml:"id,attr"`
+	Name   string `xml:"name,attr"`
+	Subcat []struct {
+		Id   int    `xml:"id,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"subcat"`
+}
+
+type TorznabCapsSearching struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type TorznabCaps struct {
+	Server struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Max     int `xml:"max,attr"`
+		Default int `xml:"default,attr"`
+	} `xml:"limits"`
+	Searching struct {
+		Search      TorznabCapsSearching `xml:"search"`
+		TVSearch    TorznabCapsSearching `xml:"tv-search"`
+		MovieSearch TorznabCapsSearching `xml:"movie-search"`
+	} `xml:"searching"`
+	Categories []TorznabCapsCategory `xml:"categories>category"`
+}
+
+type GetTorznabCapsParams struct {
+	Ctx
+}
+
+func (t TorznabAPI) GetCaps(params *GetTorznabCapsParams) (APIResponse[TorznabCaps], error) {
+	params.Query = &url.Values{"t": []string{"caps"}}
+
+	caps := TorznabCaps{}
+	res, err := t.request(&params.Ctx, &caps)
+	return newAPIResponse(res, caps), err
+}
+
+type TorznabAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type TorznabItem struct {
+	Title       string `xml:"title"`
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	Category    string `xml:"category"`
+	Description string `xml:"description"`
+	PublishDate string `xml:"pubDate"`
+	Files       int    `xml:"files"`
+	Enclosure   struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Attrs []TorznabAttr `xml:"attr"`
+}
+
+func (item TorznabItem) GetAttr(name string) string {
+	for _, attr := range item.Attrs {
+		if attr.Name == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+type TorznabFeed struct {
+	Channel struct {
+		Title string        `xml:"title"`
+		Items []TorznabItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type TorznabFunction string
+
+const (
+	TorznabFunctionSearch      TorznabFunction = "search"
+	TorznabFunctionSearchTV    TorznabFunction = "tvsearch"
+	TorznabFunctionSearchMovie TorznabFunction = "movie"
+)
+
+type TorznabSearchParams struct {
+	Ctx
+	// Defaults to `search`.
+	T      TorznabFunction
+	Q      string
+	IMDBId string
+	Year   int
+	Season int
+	Ep     int
+	Cat    []int
+	Limit  int
+	Offset int
+}
+
+func (t TorznabAPI) Search(params *TorznabSearchParams) (APIResponse[TorznabFeed], error) {
+	if params.T == "" {
+		params.T = TorznabFunctionSearch
+	}
+	query := url.Values{"t": []string{string(params.T)}}
+	if params.Q != "" {
+		query.Set("q", params.Q)
+	}
+	if params.IMDBId != "" {
+		query.Set("imdbid", params.IMDBId)
+	}
+	if params.Year > 0 {
+		query.Set("year", strconv.Itoa(params.Year))
+	}
+	if params.Season > 0 {
+		query.Set("season", strconv.Itoa(params.Season))
+	}
+	if params.Ep > 0 {
+		query.Set("ep", strconv.Itoa(params.Ep))
+	}
+	if len(params.Cat) > 0 {
+		cat := make([]string, len(params.Cat))
+		for i, c := range params.Cat {
+			cat[i] = strconv.Itoa(c)
+		}
+		query.Set("cat", strings.Join(cat, ","))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	params.Query = &query
+
+	feed := TorznabFeed{}
+	res, err := t.request(&params.Ctx, &feed)
+	return newAPIResponse(res, feed), err
+}
@@ -227,7 +227,7 @@ func (s *StoreClient) CheckMagnet(params *store.CheckMagnetParams) (*store.Check
 		hashes = append(hashes, magnet.Hash)
 	}
 
-	data, err := buddy.CheckMagnet(s, hashes, params.GetAPIKey(s.client.apiKey), params.ClientIP, params.SId)
+	data, err := buddy.CheckMagnet(params.GetContext(), s, hashes, params.GetAPIKey(s.client.apiKey), params.ClientIP, params.SId)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package realdebrid
 
 import (
+	"context"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -333,11 +334,12 @@ func (c *StoreClient) AddMagnet(params *store.AddMagnetParams) (*store.AddMagnet
 	return data, nil
 }
 
-func (c *StoreClient) assertValidSubscription(apiKey string) error {
+func (c *StoreClient) assertValidSubscription(ctx context.Context, apiKey string) error {
 	var status store.UserSubscriptionStatus
 	if !c.subscriptionStatusCache.Get(apiKey, &status) {
 		params := &store.GetUserParams{}
 		params.APIKey = apiKey
+		params.Context = ctx
 		user, err := c.GetUser(params)
 		if err != nil {
 			return err
@@ -359,7 +361,7 @@ func (c *StoreClient) assertValidSubscription(apiKey string) error {
 
 func (c *StoreClient) CheckMagnet(params *store.CheckMagnetParams) (*store.CheckMagnetData, error) {
 	if !params.IsTrustedRequest {
-		if err := c.assertValidSubscription(params.GetAPIKey(c.client.apiKey)); err != nil {
+		if err := c.assertValidSubscription(params.GetContext(), params.GetAPIKey(c.client.apiKey)); err != nil {
 			return nil, err
 		}
 	}
@@ -373,7 +375,7 @@ func (c *StoreClient) CheckMagnet(params *store.CheckMagnetParams) (*store.Check
 		hashes = append(hashes, magnet.Hash)
 	}
 
-	data, err := buddy.CheckMagnet(c, hashes, params.GetAPIKey(c.client.apiKey), params.ClientIP, params.SId)
+	data, err := buddy.CheckMagnet(params.GetContext(), c, hashes, params.GetAPIKey(c.client.apiKey), params.ClientIP, params.SId)
 	if err != nil {
 		return nil, err
 	}
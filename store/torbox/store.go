@@ -122,7 +122,7 @@ func (c *StoreClient) CheckMagnet(params *store.CheckMagnetParams) (*store.Check
 
 	foundItemByHash := map[string]store.CheckMagnetDataItem{}
 
-	if data, err := buddy.CheckMagnet(c, hashes, params.GetAPIKey(c.client.apiKey), params.ClientIP, params.SId); err != nil {
+	if data, err := buddy.CheckMagnet(params.GetContext(), c, hashes, params.GetAPIKey(c.client.apiKey), params.ClientIP, params.SId); err != nil {
 		return nil, err
 	} else {
 		for _, item := range data.Items {